@@ -0,0 +1,37 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Actor the authenticated user performing an operation
+type Actor struct {
+	UserID uuid.UUID
+	Roles  []string
+	// OrganizationID the active organization, uuid.Nil when the user belongs to none
+	OrganizationID uuid.UUID
+	// OrganizationRole the role granted within the active organization, never part of Roles
+	OrganizationRole string
+	// IP and RequestID of the request, recorded in the audit log
+	IP        string
+	RequestID string
+}
+
+// AuditContext returns the actor as recorded in the audit log.
+func (a Actor) AuditContext() AuditContext {
+	return AuditContext{ActorID: a.UserID, IP: a.IP, RequestID: a.RequestID}
+}
+
+// HasRole reports whether the actor has been granted the role.
+func (a Actor) HasRole(role string) bool {
+	for _, r := range a.Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasRoleInOrganization reports whether the actor has been granted the role globally or within the active organization.
+func (a Actor) HasRoleInOrganization(role string) bool {
+	return a.HasRole(role) || (a.OrganizationID != uuid.Nil && a.OrganizationRole == role)
+}
@@ -0,0 +1,64 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ConstAPIKeyPrefix marks the beginning of every personal API key
+const ConstAPIKeyPrefix = "cjk"
+
+// Scopes of API keys for actions every account may take, checked by GoMiddleware.RequireScope
+const (
+	ConstScopeReviewWrite = "review:write"
+)
+
+// AccountScopes scopes any account can grant to its API keys, other scopes must be permissions of its roles
+var AccountScopes = []string{ConstScopeReviewWrite}
+
+type APIKeyForm struct {
+	Name          string   `json:"name" validate:"required,max=100"`
+	Scopes        []string `json:"scopes" validate:"required,min=1,dive,required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"omitempty,min=1,max=365"`
+	// OrganizationID the active organization of the creator, the key acts in it
+	OrganizationID uuid.UUID `json:"-"`
+}
+
+// APIKey the personal API key model, the key itself is never stored
+type APIKey struct {
+	ID         uuid.UUID `json:"id"`
+	Name       string    `json:"name"`
+	Prefix     string    `json:"prefix"`
+	Scopes     []string  `json:"scopes"`
+	ExpiresAt  int       `json:"expires_at"`
+	LastUsedAt int       `json:"last_used_at"`
+	LastUsedIP string    `json:"last_used_ip"`
+	CreatedAt  int       `json:"created_at"`
+	RevokedAt  int       `json:"revoked_at"`
+	// OrganizationID the organization the key acts in, nil for none
+	OrganizationID *uuid.UUID `json:"organization_id"`
+}
+
+// NewAPIKey a freshly created API key, Key is shown only once
+type NewAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyUsecase represent the API key's use cases
+type APIKeyUsecase interface {
+	Create(userID uuid.UUID, f *APIKeyForm) (key NewAPIKey, err error)
+	Fetch(userID uuid.UUID) (keys []APIKey, err error)
+	Revoke(userID, keyID uuid.UUID) (err error)
+	RevokeAll(userID uuid.UUID) (err error)
+	Authenticate(key, ip string) (u User, apiKey APIKey, err error)
+}
+
+// APIKeyRepository represent the API key's repository
+type APIKeyRepository interface {
+	Create(userID uuid.UUID, k *APIKey, keyHash string) (err error)
+	Fetch(userID uuid.UUID) (keys []APIKey, err error)
+	// GetByPrefix returns the key, its owner's ID and the stored hash
+	GetByPrefix(prefix string) (k APIKey, userID uuid.UUID, keyHash string, err error)
+	Revoke(userID, keyID uuid.UUID) (rowsAffected int64, err error)
+	// RevokeAll revokes every key of the user and replaces its auth_key
+	RevokeAll(userID uuid.UUID, newAuthKey string) (err error)
+	Touch(keyID uuid.UUID, ip string) (err error)
+}
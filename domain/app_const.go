@@ -5,3 +5,25 @@ const (
 	ConstUserStatusActive = 1
 	ConstUserStatusDeleted = 2
 )
+
+
+// Policies for releasing the username and email address of a deleted account,
+// configured with USER_DELETED_IDENTITY_POLICY env.
+const (
+	// ConstUserIdentityReleaseImmediate frees username and email as soon as the account is deleted
+	ConstUserIdentityReleaseImmediate = "immediate"
+	// ConstUserIdentityReleaseAfterGrace frees username and email when the account is anonymized
+	ConstUserIdentityReleaseAfterGrace = "after_grace"
+	// ConstUserIdentityReleaseNever keeps username and email (as a digest) reserved forever
+	ConstUserIdentityReleaseNever = "never"
+)
+
+// Registration modes, configured with REGISTRATION_MODE env.
+const (
+	// ConstRegistrationOpen anyone can sign up with a verified email address
+	ConstRegistrationOpen = "open"
+	// ConstRegistrationInviteOnly only invited email addresses can sign up
+	ConstRegistrationInviteOnly = "invite_only"
+	// ConstRegistrationClosed nobody can sign up
+	ConstRegistrationClosed = "closed"
+)
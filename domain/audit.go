@@ -0,0 +1,85 @@
+package domain
+
+import (
+	"encoding/json"
+	"github.com/google/uuid"
+)
+
+// Audited actions, resource type and verb separated by a dot
+const (
+	ConstAuditBookCreate         = "book.create"
+	ConstAuditBookUpdate         = "book.update"
+	ConstAuditBookDelete         = "book.delete"
+	ConstAuditBookUpdateCover    = "book.update_cover"
+	ConstAuditBookSetStatus      = "book.set_status"
+	ConstAuditChapterCreate      = "chapter.create"
+	ConstAuditChapterUpdate      = "chapter.update"
+	ConstAuditChapterDelete      = "chapter.delete"
+	ConstAuditAuthorCreate       = "author.create"
+	ConstAuditAuthorUpdate       = "author.update"
+	ConstAuditAuthorDelete       = "author.delete"
+	ConstAuditCategoryCreate     = "category.create"
+	ConstAuditCategoryUpdate     = "category.update"
+	ConstAuditCategoryDelete     = "category.delete"
+	ConstAuditReviewCreate       = "review.create"
+	ConstAuditReviewUpdate       = "review.update"
+	ConstAuditReviewDelete       = "review.delete"
+	ConstAuditUserSignup         = "user.signup"
+	ConstAuditUserUpdateProfile  = "user.update_profile"
+	ConstAuditUserChangePassword = "user.change_password"
+	ConstAuditUserChangeEmail    = "user.change_email"
+	ConstAuditUserDelete         = "user.delete"
+	ConstAuditUserSetStatus      = "user.set_status"
+)
+
+// Audited resource types
+const (
+	ConstAuditResourceBook     = "book"
+	ConstAuditResourceChapter  = "chapter"
+	ConstAuditResourceAuthor   = "author"
+	ConstAuditResourceCategory = "category"
+	ConstAuditResourceReview   = "review"
+	ConstAuditResourceUser     = "user"
+)
+
+// AuditContext who performs a write operation and from where, recorded together with the change
+type AuditContext struct {
+	// ActorID uuid.Nil for anonymous requests, e.g. signup
+	ActorID   uuid.UUID
+	IP        string
+	RequestID string
+}
+
+// AuditEntry a recorded write operation, Before and After only hold the fields that changed
+type AuditEntry struct {
+	ID           int64           `json:"id"`
+	ActorID      *uuid.UUID      `json:"actor_id"`
+	Action       string          `json:"action"`
+	ResourceType string          `json:"resource_type"`
+	ResourceID   string          `json:"resource_id"`
+	Before       json.RawMessage `json:"before" swaggertype:"object"`
+	After        json.RawMessage `json:"after" swaggertype:"object"`
+	IP           string          `json:"ip"`
+	RequestID    string          `json:"request_id"`
+	CreatedAt    int             `json:"created_at"`
+}
+
+// AuditFilter filters applied when fetching audit entries, zero values match anything
+type AuditFilter struct {
+	ActorID      uuid.UUID
+	ResourceType string
+	ResourceID   string
+	// From and To unix timestamps, both inclusive
+	From int64
+	To   int64
+}
+
+// AuditUsecase represent the audit log's use cases, entries are written by the repositories of the audited resources
+type AuditUsecase interface {
+	Fetch(filter AuditFilter, perPage, page int) (entries []AuditEntry, totalCount, pageCount, currentPage int, err error)
+}
+
+// AuditRepository represent the audit log's repository
+type AuditRepository interface {
+	Fetch(filter AuditFilter, perPage, page int) (entries []AuditEntry, totalCount, pageCount, currentPage int, err error)
+}
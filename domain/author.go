@@ -0,0 +1,59 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Roles of an author on a book
+const (
+	ConstAuthorRoleAuthor     = "author"
+	ConstAuthorRoleEditor     = "editor"
+	ConstAuthorRoleTranslator = "translator"
+)
+
+// AuthorForm form for create or update an author
+type AuthorForm struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Bio  string `json:"bio" validate:"max=10000"`
+}
+
+// BookAuthorForm an author credited on a book
+type BookAuthorForm struct {
+	AuthorID int    `json:"author_id" validate:"required"`
+	Role     string `json:"role" validate:"omitempty,oneof=author editor translator"` // default to author
+}
+
+// Author the author model, authors belong to the catalog of an organization
+type Author struct {
+	ID        int    `json:"id"`
+	Name      string `json:"name"`
+	Bio       string `json:"bio"`
+	CreatedAt int    `json:"created_at"`
+	UpdatedAt int    `json:"updated_at"`
+}
+
+// BookAuthor an author as credited on a book
+type BookAuthor struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// AuthorUsecase represent the author's use cases, authors are managed in the active organization of the actor
+type AuthorUsecase interface {
+	Create(actor Actor, af *AuthorForm) (a Author, err error)
+	Fetch(organizationID uuid.UUID, name string, perPage, page int) (authors []Author, totalCount, pageCount, currentPage int, err error)
+	GetByID(organizationID uuid.UUID, id int) (a Author, err error)
+	Update(actor Actor, id int, af *AuthorForm) (a Author, err error)
+	Delete(actor Actor, id int) (err error)
+}
+
+// AuthorRepository represent the author's repository, every query only sees the authors of the organization.
+// Writes are recorded in the audit log on behalf of the AuditContext.
+type AuthorRepository interface {
+	Create(organizationID uuid.UUID, af *AuthorForm, ac AuditContext) (a Author, err error)
+	// Fetch returns the authors whose name contains name, all authors when it is empty
+	Fetch(organizationID uuid.UUID, name string, perPage, page int) (authors []Author, totalCount, pageCount, currentPage int, err error)
+	GetByID(organizationID uuid.UUID, id int) (a Author, err error)
+	Update(organizationID uuid.UUID, id int, af *AuthorForm, ac AuditContext) (a Author, err error)
+	// Delete returns a DataValidationError while the author is credited on a book
+	Delete(organizationID uuid.UUID, id int, ac AuditContext) (err error)
+}
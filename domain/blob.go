@@ -0,0 +1,12 @@
+package domain
+
+// BlobStore stores uploaded files, eg. book covers, by key. Keys are slash separated paths.
+type BlobStore interface {
+	Put(key, contentType string, data []byte) error
+	// Get returns a NotFoundError when there is no blob with the key
+	Get(key string) (data []byte, contentType string, err error)
+	// Delete removes the blob, a missing blob is not an error
+	Delete(key string) error
+	// URL returns the public URL of the blob
+	URL(key string) string
+}
@@ -2,27 +2,269 @@ package domain
 
 import (
 	"encoding/json"
+	"github.com/google/uuid"
 )
 
-// BookForm form for create book
+// BookForm form for create book. Author is the byline as credited on the book, it defaults to the names
+// of the linked authors. Without Authors the byline is linked to the author of that name, created when unknown.
 type BookForm struct {
-	Title   string  `json:"title" validate:"required"`
-	Author  string  `json:"author" validate:"required"`
-	Content string  `json:"content" validate:"required"`
-	Price   float64 `json:"price" validate:"gte=1"`
-	Rating  int     `json:"rating" validate:"lte=5"`
+	Title   string           `json:"title" validate:"required"`
+	Author  string           `json:"author" validate:"required_without=Authors"`
+	Authors []BookAuthorForm `json:"authors" validate:"omitempty,dive"`
+	// CategoryIDs and Tags replace those of the book, leave them out to keep them on update
+	CategoryIDs []int    `json:"category_ids" validate:"omitempty,max=20,dive,gt=0"`
+	Tags        []string `json:"tags" validate:"omitempty,max=20,dive,required,max=64"`
+	// Summary a short description shown in listings, the content of the book is in its chapters
+	Summary string `json:"summary" validate:"max=1000"`
+	// Price a decimal amount in the currency such as 19.99, the JSON number is read as written without a float
+	Price    json.Number `json:"price" validate:"required"`
+	Currency string      `json:"currency" validate:"required,currency"`
+	// Prices the price list in other currencies, replaces that of the book. Leave it out to keep it on update.
+	Prices []BookPriceForm `json:"prices" validate:"omitempty,max=50,dive"`
+	// ISBN an ISBN-10 or ISBN-13, saved as ISBN-13. Leave it out to keep it on update, empty removes it.
+	ISBN *string `json:"isbn" validate:"omitempty,isbn"`
+	// Chapters replace those of the book when not nil, only set when a revision is restored
+	Chapters []BookRevisionChapter `json:"-" form:"-"`
+	// KeepAuthors keeps the linked authors whatever the byline, set when a revision without credits is restored
+	KeepAuthors bool `json:"-" form:"-"`
+}
+
+// BookPriceForm the price of a book in another currency
+type BookPriceForm struct {
+	Amount   json.Number `json:"amount" validate:"required"`
+	Currency string      `json:"currency" validate:"required,currency"`
+}
+
+// PriceList returns the price of the form and its price list in other currencies as money.
+func (bf *BookForm) PriceList() (price Money, prices []Money, err error) {
+	price, err = ParseMoney(bf.Price.String(), bf.Currency)
+	if err != nil {
+		return price, nil, DataValidationError{Field: "price", Message: err.Error()}
+	}
+	if price.Amount <= 0 {
+		return price, nil, DataValidationError{Field: "price", Message: "price must be greater than zero"}
+	}
+
+	if bf.Prices == nil {
+		return
+	}
+
+	prices = []Money{}
+	seen := map[string]bool{price.Currency: true}
+	for _, pf := range bf.Prices {
+		var p Money
+		p, err = ParseMoney(pf.Amount.String(), pf.Currency)
+		if err != nil {
+			return price, nil, DataValidationError{Field: "prices", Message: err.Error()}
+		}
+		if p.Amount <= 0 {
+			return price, nil, DataValidationError{Field: "prices", Message: "price must be greater than zero"}
+		}
+		if seen[p.Currency] {
+			return price, nil, DataValidationError{Field: "prices", Message: "more than one price in " + p.Currency}
+		}
+		seen[p.Currency] = true
+
+		prices = append(prices, p)
+	}
+
+	return
+}
+
+// BookFilter filters applied when fetching books
+type BookFilter struct {
+	Owner    uuid.UUID // uuid.Nil for any owner
+	AuthorID int       // 0 for any author
+	// CategoryID matches the books in the category or any of its descendants, 0 for any category
+	CategoryID int
+	Tag        string // "" for any tag
+	Status     string // "" for any status
+}
+
+// BookOwner the user who created a book
+type BookOwner struct {
+	ID       uuid.UUID `json:"id"`
+	Username string    `json:"username"`
 }
 
 // Book the book model
 type Book struct {
-	ID        int     `json:"id"`
-	Title     string  `json:"title"`
-	Author    string  `json:"author"`
-	Content   string  `json:"content"`
-	Price     float64 `json:"price"`
-	CreatedAt int     `json:"created_at"`
-	UpdatedAt int     `json:"updated_at"`
-	Rating    int     `json:"rating"`
+	ID        int    `json:"id"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Summary   string `json:"summary"`
+	Price     Money  `json:"price"`
+	CreatedAt int    `json:"created_at"`
+	UpdatedAt int    `json:"updated_at"`
+	// Status the publication status, PublishAt is set while the publication is scheduled
+	Status      string `json:"status"`
+	PublishAt   *int   `json:"publish_at"`
+	PublishedAt *int   `json:"published_at"`
+	// Prices the price list of the book in other currencies
+	Prices []Money `json:"prices"`
+	// DisplayPrice the price in the currency requested for display, nil unless one is requested
+	DisplayPrice *BookDisplayPrice `json:"display_price,omitempty"`
+	// ISBN the ISBN-13 of the book, ISBN10 is empty when the ISBN-13 has no ISBN-10 form
+	ISBN   *string `json:"isbn"`
+	ISBN10 string  `json:"isbn10,omitempty"`
+	// Rating the average rating of the reviews, 0 without reviews
+	Rating      float64        `json:"rating"`
+	RatingCount int            `json:"rating_count"`
+	CreatedBy   *uuid.UUID     `json:"created_by"`
+	UpdatedBy   *uuid.UUID     `json:"updated_by"`
+	Owner       *BookOwner     `json:"owner"`
+	Authors     []BookAuthor   `json:"authors"`
+	Categories  []BookCategory `json:"categories"`
+	Tags        []string       `json:"tags"`
+	Cover       *BookCover     `json:"cover"`
+	// Chapters the table of contents, only set when a single book is read
+	Chapters []BookChapterHeading `json:"chapters,omitempty"`
+	// OrganizationID the organization whose catalog the book belongs to
+	OrganizationID uuid.UUID `json:"organization_id"`
+}
+
+// BookDisplayPrice the price of a book in the currency requested for display
+type BookDisplayPrice struct {
+	Price Money `json:"price"`
+	// Converted the price is converted from the book price with the configured rates, it is not in the price list
+	Converted bool `json:"converted"`
+}
+
+// PriceIn returns the price of the book in the currency, from its price list when listed there or else
+// converted from its price.
+func (b Book) PriceIn(currency string, converter CurrencyConverter) (BookDisplayPrice, error) {
+	if b.Price.Currency == currency {
+		return BookDisplayPrice{Price: b.Price}, nil
+	}
+
+	for _, p := range b.Prices {
+		if p.Currency == currency {
+			return BookDisplayPrice{Price: p}, nil
+		}
+	}
+
+	price, err := converter.Convert(b.Price, currency)
+	return BookDisplayPrice{Price: price, Converted: true}, err
+}
+
+// BookRevision a version of a book, revision 1 is the book as created
+type BookRevision struct {
+	BookID    int        `json:"book_id"`
+	Revision  int        `json:"revision"`
+	Title     string     `json:"title"`
+	Author    string     `json:"author"`
+	Summary   string     `json:"summary"`
+	Price     Money      `json:"price"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	CreatedAt int        `json:"created_at"`
+	// Chapters the chapters of the book as they were at the revision
+	Chapters []BookRevisionChapter `json:"chapters"`
+	// Authors the credits of the book at the revision, nil for revisions stored before credits were kept
+	Authors []BookRevisionAuthor `json:"authors"`
+}
+
+// BookRevisionAuthor an author credited on a book as stored with a revision
+type BookRevisionAuthor struct {
+	AuthorID int    `json:"author_id"`
+	Role     string `json:"role"`
+	Position int    `json:"position"`
+}
+
+// BookRevisionChapter a chapter of a book as stored with a revision
+type BookRevisionChapter struct {
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+}
+
+// EqualBookChapters reports whether both have the same chapters in the same order.
+func EqualBookChapters(a, b []BookRevisionChapter) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Title != b[i].Title || a[i].Content != b[i].Content {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EqualBookAuthors reports whether both credit the same authors in the same roles and order.
+func EqualBookAuthors(a, b []BookRevisionAuthor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].AuthorID != b[i].AuthorID || a[i].Role != b[i].Role {
+			return false
+		}
+	}
+
+	return true
+}
+
+// BookFieldChange a field of a book that differs between two revisions
+type BookFieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// BookRevisionDiff the changes between two revisions of a book
+type BookRevisionDiff struct {
+	BookID  int               `json:"book_id"`
+	From    int               `json:"from"`
+	To      int               `json:"to"`
+	Changes []BookFieldChange `json:"changes"`
+}
+
+// Diff returns the fields changed from r to the revision to.
+func (r BookRevision) Diff(to BookRevision) BookRevisionDiff {
+	d := BookRevisionDiff{BookID: r.BookID, From: r.Revision, To: to.Revision, Changes: []BookFieldChange{}}
+
+	if r.Title != to.Title {
+		d.Changes = append(d.Changes, BookFieldChange{Field: "title", From: r.Title, To: to.Title})
+	}
+	if r.Author != to.Author {
+		d.Changes = append(d.Changes, BookFieldChange{Field: "author", From: r.Author, To: to.Author})
+	}
+	if r.Summary != to.Summary {
+		d.Changes = append(d.Changes, BookFieldChange{Field: "summary", From: r.Summary, To: to.Summary})
+	}
+	if r.Price != to.Price {
+		d.Changes = append(d.Changes, BookFieldChange{Field: "price", From: r.Price, To: to.Price})
+	}
+	// revisi lama tanpa kredit tidak dibandingkan
+	if r.Authors != nil && to.Authors != nil && !EqualBookAuthors(r.Authors, to.Authors) {
+		d.Changes = append(d.Changes, BookFieldChange{Field: "authors", From: r.Authors, To: to.Authors})
+	}
+	if !EqualBookChapters(r.Chapters, to.Chapters) {
+		d.Changes = append(d.Changes, BookFieldChange{Field: "chapters", From: r.Chapters, To: to.Chapters})
+	}
+
+	return d
+}
+
+// Form returns the revision as a form, for restoring it together with its chapters and credits.
+// A revision without credits keeps the authors linked to the book.
+func (r BookRevision) Form() BookForm {
+	// tidak nil, revisi tanpa bab menghapus bab buku
+	chapters := make([]BookRevisionChapter, len(r.Chapters))
+	copy(chapters, r.Chapters)
+
+	f := BookForm{Title: r.Title, Author: r.Author, Summary: r.Summary, Price: json.Number(r.Price.Decimal()), Currency: r.Price.Currency, Chapters: chapters}
+	if len(r.Authors) == 0 {
+		f.KeepAuthors = true
+		return f
+	}
+
+	for _, a := range r.Authors {
+		f.Authors = append(f.Authors, BookAuthorForm{AuthorID: a.AuthorID, Role: a.Role})
+	}
+
+	return f
 }
 
 // FromJSON decode json to book struct
@@ -36,20 +278,51 @@ func (b *Book) ToJSON() []byte {
 	return str
 }
 
-// BookUsecase represent the book's use cases
+// BookUsecase represent the book's use cases, books are created in the active organization of the actor as draft.
+// GetByID, GetByISBN and the revisions only find published books, GetForEditing finds books of any status.
 type BookUsecase interface {
-	Create(b *BookForm) (book Book, err error)
-	Fetch(perPage, page int) (books []Book, totalCount, pageCount, currentPage int, err error)
-	GetByID(id int) (Book, error)
-	Update(id int, b *BookForm) (book Book, err error)
-	Delete(id int) (rowsAffected int64, err error)
+	Create(actor Actor, b *BookForm) (book Book, err error)
+	Fetch(organizationID uuid.UUID, filter BookFilter, perPage, page int) (books []Book, totalCount, pageCount, currentPage int, err error)
+	GetByID(organizationID uuid.UUID, id int) (Book, error)
+	// GetByISBN finds a book by its ISBN-10 or ISBN-13
+	GetByISBN(organizationID uuid.UUID, isbn string) (Book, error)
+	GetForEditing(actor Actor, id int) (Book, error)
+	// SubmitForReview moves a draft of the owner or an admin to in review
+	SubmitForReview(actor Actor, id int) (book Book, err error)
+	// SetStatus moves the book to the status of the form or schedules its publication
+	SetStatus(actor Actor, id int, sf *BookStatusForm) (book Book, err error)
+	// PublishDue publishes the books of every organization whose scheduled publication is due
+	PublishDue() (published int64, err error)
+	Update(actor Actor, id int, b *BookForm) (book Book, err error)
+	Delete(actor Actor, id int) (rowsAffected int64, err error)
+	Revisions(organizationID uuid.UUID, id int) (revisions []BookRevision, err error)
+	DiffRevisions(organizationID uuid.UUID, id, from, to int) (diff BookRevisionDiff, err error)
+	// RestoreRevision updates the book and its chapters to the content of the revision, which is stored as a new revision
+	RestoreRevision(actor Actor, id, revision int) (book Book, err error)
+	// UploadCover replaces the cover of the book by the image, thumbnails are generated from it
+	UploadCover(actor Actor, id int, image []byte) (book Book, err error)
+	// DisplayPrices sets the display price of the books in the currency
+	DisplayPrices(books []Book, currency string) error
 }
 
-// BookRepository represent the book's repository
+// BookRepository represent the book's repository, every query only sees the books of the organization.
+// Writes are recorded in the audit log on behalf of the AuditContext, every change of a book is stored as a new revision.
 type BookRepository interface {
-	Create(b *BookForm) (book Book, err error)
-	Fetch(perPage, page int) (books []Book, totalCount, pageCount, currentPage int, err error)
-	GetByID(id int) (Book, error)
-	Update(id int, b *BookForm) (book Book, err error)
-	Delete(id int) (rowsAffected int64, err error)
+	Create(organizationID uuid.UUID, b *BookForm, ac AuditContext) (book Book, err error)
+	Fetch(organizationID uuid.UUID, filter BookFilter, perPage, page int) (books []Book, totalCount, pageCount, currentPage int, err error)
+	GetByID(organizationID uuid.UUID, id int) (Book, error)
+	// GetByISBN finds a book by its normalized ISBN-13
+	GetByISBN(organizationID uuid.UUID, isbn string) (Book, error)
+	Update(organizationID uuid.UUID, id int, b *BookForm, ac AuditContext) (book Book, err error)
+	Delete(organizationID uuid.UUID, id int, ac AuditContext) (rowsAffected int64, err error)
+	// FetchRevisions returns the revisions of the book, newest first
+	FetchRevisions(organizationID uuid.UUID, id int) (revisions []BookRevision, err error)
+	GetRevision(organizationID uuid.UUID, id, revision int) (r BookRevision, err error)
+	// SetStatus moves the book from a status to another and sets its scheduled publication, a ConflictError
+	// is returned when the book is no longer in the from status
+	SetStatus(organizationID uuid.UUID, id int, from, to string, publishAt *int, ac AuditContext) (book Book, err error)
+	// PublishDue publishes the books of every organization whose scheduled publication is at or before now
+	PublishDue(now int) (published int64, err error)
+	// SetCover replaces the cover of the book and returns the previous one, nil when it had none
+	SetCover(organizationID uuid.UUID, id int, cover *BookCover, ac AuditContext) (book Book, previous *BookCover, err error)
 }
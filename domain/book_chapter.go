@@ -0,0 +1,55 @@
+package domain
+
+import "github.com/google/uuid"
+
+// BookChapterForm form for create or edit a chapter, Content is Markdown of at most 200000 characters
+type BookChapterForm struct {
+	Title   string `json:"title" validate:"required,max=255"`
+	Content string `json:"content" validate:"max=200000"`
+	// Position 1 is the first chapter, the following chapters move down. Leave it out to append
+	// the chapter on create and to keep its position on update.
+	Position *int `json:"position" validate:"omitempty,gt=0"`
+}
+
+// BookChapterHeading a chapter in the table of contents of a book
+type BookChapterHeading struct {
+	ID       int    `json:"id"`
+	Position int    `json:"position"`
+	Title    string `json:"title"`
+}
+
+// BookChapter a chapter of a book. Content is the Markdown source, HTML its sanitized rendering.
+type BookChapter struct {
+	BookChapterHeading
+	BookID    int        `json:"book_id"`
+	Content   string     `json:"content"`
+	HTML      string     `json:"html"`
+	CreatedAt int        `json:"created_at"`
+	UpdatedAt int        `json:"updated_at"`
+	CreatedBy *uuid.UUID `json:"created_by"`
+	UpdatedBy *uuid.UUID `json:"updated_by"`
+}
+
+// BookChapterUsecase represent the chapter's use cases. Fetch and GetByID only find the chapters of
+// published books, the editing methods find those of books of any status of the active organization.
+// Chapters are returned with their content rendered as HTML, headings only in the table of contents.
+type BookChapterUsecase interface {
+	Fetch(organizationID uuid.UUID, bookID int) (chapters []BookChapterHeading, err error)
+	GetByID(organizationID uuid.UUID, bookID, id int) (chapter BookChapter, err error)
+	FetchForEditing(actor Actor, bookID int) (chapters []BookChapterHeading, err error)
+	GetForEditing(actor Actor, bookID, id int) (chapter BookChapter, err error)
+	// Create, Update and Delete are allowed to the owner of the book or an admin
+	Create(actor Actor, bookID int, cf *BookChapterForm) (chapter BookChapter, err error)
+	Update(actor Actor, bookID, id int, cf *BookChapterForm) (chapter BookChapter, err error)
+	Delete(actor Actor, bookID, id int) (err error)
+}
+
+// BookChapterRepository represent the chapter's repository. Positions of the chapters of a book are kept
+// Writes that change a chapter store the book as a new revision. The table of contents is read with the book, see Book.Chapters.
+// Every write stores the book as a new revision, the table of contents is read with the book, see Book.Chapters.
+type BookChapterRepository interface {
+	GetByID(organizationID uuid.UUID, bookID, id int) (chapter BookChapter, err error)
+	Create(organizationID uuid.UUID, bookID int, cf *BookChapterForm, ac AuditContext) (chapter BookChapter, err error)
+	Update(organizationID uuid.UUID, bookID, id int, cf *BookChapterForm, ac AuditContext) (chapter BookChapter, err error)
+	Delete(organizationID uuid.UUID, bookID, id int, ac AuditContext) (err error)
+}
@@ -0,0 +1,29 @@
+package domain
+
+// Cover upload limits
+const (
+	ConstBookCoverMaxBytes  = 2 << 20
+	ConstBookCoverMaxPixels = 25000000
+)
+
+// BookCoverThumbnailWidths the widths of the generated cover thumbnails by size name, the height keeps the
+// aspect ratio. A cover narrower than the width is not enlarged.
+var BookCoverThumbnailWidths = map[string]int{
+	"small":  160,
+	"medium": 320,
+	"large":  640,
+}
+
+// BookCover the cover image of a book as uploaded and its thumbnails by size name
+type BookCover struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+	// Keys the blob keys of the cover and its thumbnails
+	Keys []string `json:"-"`
+}
+
+var (
+	ErrBookCoverTooLarge    = DataValidationError{Field: "cover", Message: "cover image must not be larger than 2 MB"}
+	ErrBookCoverUnsupported = DataValidationError{Field: "cover", Message: "cover must be a JPEG, PNG or GIF image"}
+	ErrBookCoverDimensions  = DataValidationError{Field: "cover", Message: "cover image has too many pixels"}
+)
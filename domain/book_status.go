@@ -0,0 +1,36 @@
+package domain
+
+// Book statuses, only published books are in the public catalog
+const (
+	ConstBookStatusDraft     = "draft"
+	ConstBookStatusInReview  = "in_review"
+	ConstBookStatusPublished = "published"
+	ConstBookStatusArchived  = "archived"
+)
+
+// bookStatusTransitions the statuses a book may move to from each status
+var bookStatusTransitions = map[string][]string{
+	ConstBookStatusDraft:     {ConstBookStatusInReview, ConstBookStatusPublished, ConstBookStatusArchived},
+	ConstBookStatusInReview:  {ConstBookStatusDraft, ConstBookStatusPublished, ConstBookStatusArchived},
+	ConstBookStatusPublished: {ConstBookStatusDraft, ConstBookStatusArchived},
+	ConstBookStatusArchived:  {ConstBookStatusDraft},
+}
+
+// CanTransitionBook reports whether a book may move from a status to another.
+func CanTransitionBook(from, to string) bool {
+	for _, s := range bookStatusTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// BookStatusForm form for moving a book to another status. Publishing with PublishAt in the future schedules
+// the publication instead, the book keeps its status until then.
+type BookStatusForm struct {
+	Status string `json:"status" validate:"required,oneof=draft in_review published archived"`
+	// PublishAt unix time to publish the book at, only with status published
+	PublishAt *int `json:"publish_at" validate:"omitempty,gt=0"`
+}
@@ -0,0 +1,51 @@
+package domain
+
+import "github.com/google/uuid"
+
+// CategoryForm form for create or update a category, ParentID 0 for a root category
+type CategoryForm struct {
+	Name     string `json:"name" validate:"required,max=128"`
+	ParentID int    `json:"parent_id" validate:"gte=0"`
+}
+
+// Category a node of the category tree of a catalog
+type Category struct {
+	ID       int    `json:"id"`
+	ParentID *int   `json:"parent_id"`
+	Name     string `json:"name"`
+	// Path the ids from the root, e.g. /1/4/9/
+	Path      string `json:"path"`
+	CreatedAt int    `json:"created_at"`
+	UpdatedAt int    `json:"updated_at"`
+	// BookCount the number of books in the category or any of its descendants
+	BookCount int         `json:"book_count"`
+	Children  []*Category `json:"children,omitempty"`
+}
+
+// BookCategory a category a book is assigned to
+type BookCategory struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Path string `json:"path"`
+}
+
+// CategoryUsecase represent the category's use cases, categories are managed in the active organization of the actor
+type CategoryUsecase interface {
+	Create(actor Actor, cf *CategoryForm) (c Category, err error)
+	// Tree returns the root categories with their descendants
+	Tree(organizationID uuid.UUID) (roots []*Category, err error)
+	Update(actor Actor, id int, cf *CategoryForm) (c Category, err error)
+	Delete(actor Actor, id int) (err error)
+}
+
+// CategoryRepository represent the category's repository, every query only sees the categories of the organization.
+// Writes are recorded in the audit log on behalf of the AuditContext.
+type CategoryRepository interface {
+	Create(organizationID uuid.UUID, cf *CategoryForm, ac AuditContext) (c Category, err error)
+	// Fetch returns every category with its book count, ordered by path
+	Fetch(organizationID uuid.UUID) (categories []Category, err error)
+	// Update moves the subtree when the parent changes
+	Update(organizationID uuid.UUID, id int, cf *CategoryForm, ac AuditContext) (c Category, err error)
+	// Delete returns a DataValidationError while the category has children
+	Delete(organizationID uuid.UUID, id int, ac AuditContext) (err error)
+}
@@ -0,0 +1,13 @@
+package domain
+
+// Email screening rejections
+var (
+	ErrEmailInvalid       = DataValidationError{Field: "email", Message: "invalid email address"}
+	ErrEmailDisposable    = DataValidationError{Field: "email", Message: "disposable email addresses are not allowed"}
+	ErrEmailUndeliverable = DataValidationError{Field: "email", Message: "email address domain does not accept mail"}
+)
+
+// EmailScreener checks an email address before mail is sent to it or an account is created with it
+type EmailScreener interface {
+	Screen(email string) (err error)
+}
@@ -4,6 +4,13 @@ import "errors"
 
 var (
 	ErrSqlNoRowFoundToUpsert = errors.New("no row found to insert or update")
+	ErrUserInactive          = ForbiddenError{Message: "account is inactive"}
+	ErrUserDeleted           = ForbiddenError{Message: "account has been deleted"}
+	// ErrInvalidCredentials same error for unknown email and wrong password, avoid user enumeration
+	ErrInvalidCredentials = DataValidationError{Field: "password", Message: "invalid email or password"}
+	// ErrInvalidAPIKey same error for unknown, revoked and expired API keys
+	ErrInvalidAPIKey = errors.New("invalid or expired API key")
+	ErrSessionRevoked = errors.New("session has been revoked or expired")
 )
 
 type DataValidationError struct {
@@ -20,4 +27,28 @@ type NotFoundError struct {
 }
 func (nf NotFoundError) Error() string {
 	return nf.Message
-}
\ No newline at end of file
+}
+
+type ForbiddenError struct {
+	Message string
+}
+func (f ForbiddenError) Error() string {
+	return f.Message
+}
+
+// ConflictError the data conflicts with an existing one, eg. a duplicate unique value
+type ConflictError struct {
+	Field   string
+	Message string
+}
+func (c ConflictError) Error() string {
+	return c.Message
+}
+
+type TooManyRequestsError struct {
+	Message    string
+	RetryAfter int // seconds
+}
+func (t TooManyRequestsError) Error() string {
+	return t.Message
+}
@@ -3,12 +3,14 @@ package domain
 import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"strconv"
 )
 
 // HTTPError example
 type HTTPError struct {
 	Field    string    `json:"field"`
 	Message string `json:"message"`
+	Code     string    `json:"code,omitempty"`
 }
 
 // NewHttpError custom error response
@@ -18,6 +20,22 @@ func NewHttpError(ctx *fiber.Ctx, err error) error {
 	if _, ok1 := err.(DataValidationError); ok1 {
 		status = fiber.StatusUnprocessableEntity
 		return ctx.Status(status).JSON(HTTPError{Field: err.(DataValidationError).Field, Message: err.Error()})
+	} else if pp, ok := err.(PasswordPolicyError); ok {
+		lang := ctx.AcceptsLanguages(PasswordLanguages...)
+		fields := make([]HTTPError, 0, len(pp.Violations))
+		for _, v := range pp.Violations {
+			fields = append(fields, HTTPError{Field: pp.Field, Message: v.Message(lang), Code: v.Code})
+		}
+		return ctx.Status(fiber.StatusUnprocessableEntity).JSON(fields)
+	} else if tm, ok := err.(TooManyRequestsError); ok {
+		ctx.Set(fiber.HeaderRetryAfter, strconv.Itoa(tm.RetryAfter))
+		return ctx.Status(fiber.StatusTooManyRequests).JSON(HTTPError{Message: tm.Error()})
+	} else if fe, ok := err.(ForbiddenError); ok {
+		return ctx.Status(fiber.StatusForbidden).JSON(HTTPError{Message: fe.Error()})
+	} else if ce, ok := err.(ConflictError); ok {
+		return ctx.Status(fiber.StatusConflict).JSON(HTTPError{Field: ce.Field, Message: ce.Error()})
+	} else if nf, ok := err.(NotFoundError); ok {
+		return ctx.Status(fiber.StatusNotFound).JSON(HTTPError{Message: nf.Error()})
 	}else if _, ok2 := err.(validator.ValidationErrors); ok2 {
 		var fields []HTTPError
 		for _, err := range err.(validator.ValidationErrors) {
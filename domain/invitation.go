@@ -0,0 +1,48 @@
+package domain
+
+import "github.com/google/uuid"
+
+var (
+	ErrRegistrationClosed = ForbiddenError{Message: "registration is closed"}
+	ErrInvitationRequired = DataValidationError{Field: "invite_token", Message: "registration is by invitation only"}
+	// ErrInvitationInvalid same error for unknown, expired, revoked and used invitations
+	ErrInvitationInvalid = DataValidationError{Field: "invite_token", Message: "invalid or expired invitation"}
+)
+
+type InvitationForm struct {
+	Email         string `json:"email" validate:"required,email"`
+	Role          string `json:"role"`
+	ExpiresInDays int    `json:"expires_in_days" validate:"omitempty,min=1,max=90"`
+}
+
+// Invitation the signup invitation model, the token itself is never stored
+type Invitation struct {
+	ID         uuid.UUID `json:"id"`
+	Email      string    `json:"email"`
+	Role       string    `json:"role"`
+	InvitedBy  uuid.UUID `json:"invited_by"`
+	ExpiresAt  int       `json:"expires_at"`
+	AcceptedAt int       `json:"accepted_at"`
+	RevokedAt  int       `json:"revoked_at"`
+	CreatedAt  int       `json:"created_at"`
+}
+
+// IsUsable reports whether the invitation can still be accepted at the given Unix time.
+func (i Invitation) IsUsable(now int64) bool {
+	return i.AcceptedAt == 0 && i.RevokedAt == 0 && int64(i.ExpiresAt) > now
+}
+
+// InvitationUsecase represent the invitation's use cases
+type InvitationUsecase interface {
+	Create(actor Actor, f *InvitationForm) (i Invitation, err error)
+	Fetch() (invitations []Invitation, err error)
+	Revoke(id uuid.UUID) (err error)
+}
+
+// InvitationRepository represent the invitation's repository
+type InvitationRepository interface {
+	Create(i *Invitation, tokenHash string) (err error)
+	Fetch() (invitations []Invitation, err error)
+	GetByTokenHash(tokenHash string) (i Invitation, err error)
+	Revoke(id uuid.UUID) (rowsAffected int64, err error)
+}
@@ -0,0 +1,33 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Reasons recorded for a login attempt
+const (
+	ConstLoginAttemptSuccess            = "success"
+	ConstLoginAttemptInvalidCredentials = "invalid_credentials"
+	ConstLoginAttemptThrottled          = "throttled"
+	ConstLoginAttemptLocked             = "locked"
+	ConstLoginAttemptInactive           = "inactive"
+	ConstLoginAttemptDeleted            = "deleted"
+)
+
+// LoginAttempt the login attempt audit record
+type LoginAttempt struct {
+	ID        int64      `json:"id"`
+	UserID    *uuid.UUID `json:"user_id"`
+	Email     string     `json:"email"`
+	IP        string     `json:"ip"`
+	Success   bool       `json:"success"`
+	Reason    string     `json:"reason"`
+	CreatedAt int        `json:"created_at"`
+}
+
+// LoginAttemptRepository represent the login attempt's repository
+type LoginAttemptRepository interface {
+	Record(a *LoginAttempt) (err error)
+	// CountFailuresByEmail counts invalid credential attempts since the later of `since` and the last successful login.
+	CountFailuresByEmail(email string, since int64) (count int, lastAt int64, err error)
+	// CountFailuresByIP counts invalid credential attempts since `since`, firstAt is the oldest of them.
+	CountFailuresByIP(ip string, since int64) (count int, firstAt int64, err error)
+}
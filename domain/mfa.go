@@ -0,0 +1,68 @@
+package domain
+
+import "github.com/google/uuid"
+
+// MfaEligibleRoles roles allowed to enroll in two-factor authentication
+var MfaEligibleRoles = []string{ConstRoleAdmin, ConstRoleEditor}
+
+type MfaCodeForm struct {
+	Code string `json:"code" validate:"required,min=6,max=16"`
+}
+
+type MfaLoginForm struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required,min=6,max=16"`
+}
+
+type DisableMfaForm struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required,min=6,max=16"`
+}
+
+// MfaEnrollment pending TOTP enrollment, shown to the user to configure an authenticator app
+type MfaEnrollment struct {
+	Secret     string `json:"secret"`
+	OtpauthURI string `json:"otpauth_uri"`
+	QRCodePNG  string `json:"qr_code_png"` // data URI
+}
+
+// MfaChallenge returned by login when a TOTP code is required before an access token is issued
+type MfaChallenge struct {
+	MfaRequired    bool   `json:"mfa_required"`
+	ChallengeToken string `json:"challenge_token"`
+	ExpiresAt      int64  `json:"expires_at"`
+}
+
+// UserMfa the user's TOTP settings
+type UserMfa struct {
+	UserID          uuid.UUID
+	Secret          string
+	Enabled         bool
+	LastUsedCounter int64
+	FailedAttempts  int
+	LastFailedAt    int
+	CreatedAt       int
+	ConfirmedAt     int
+}
+
+// MfaUsecase represent the two-factor authentication use cases
+type MfaUsecase interface {
+	IsEnabled(userID uuid.UUID) (enabled bool, err error)
+	Enroll(userID uuid.UUID) (enrollment MfaEnrollment, err error)
+	Confirm(userID uuid.UUID, code string) (recoveryCodes []string, err error)
+	RegenerateRecoveryCodes(userID uuid.UUID, code string) (recoveryCodes []string, err error)
+	Disable(userID uuid.UUID, df *DisableMfaForm) (err error)
+	CompleteLogin(mf *MfaLoginForm) (u User, err error)
+}
+
+// MfaRepository represent the two-factor authentication repository
+type MfaRepository interface {
+	Get(userID uuid.UUID) (m UserMfa, err error)
+	SavePending(userID uuid.UUID, secret string) (err error)
+	Enable(userID uuid.UUID, counter int64, recoveryCodeHashes []string) (err error)
+	ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) (err error)
+	UseRecoveryCode(userID uuid.UUID, codeHash string) (used bool, err error)
+	MarkCodeUsed(userID uuid.UUID, counter int64) (accepted bool, err error)
+	RecordFailure(userID uuid.UUID) (err error)
+	Delete(userID uuid.UUID) (err error)
+}
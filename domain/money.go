@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// currencyCodes the ISO 4217 codes of the currencies in circulation
+const currencyCodes = `AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND BOB BOV BRL BSD BTN BWP
+BYN BZD CAD CDF CHE CHF CHW CLF CLP CNY COP COU CRC CUC CUP CVE CZK DJF DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL
+GHS GIP GMD GNF GTQ GYD HKD HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW KWD KYD KZT LAK
+LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN
+PGK PHP PKR PLN PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL THB TJS TMT
+TND TOP TRY TTD TWD TZS UAH UGX USD USN UYI UYU UYW UZS VED VES VND VUV WST XAF XCD XOF XPF YER ZAR ZMW ZWL`
+
+// currencyExponents the number of decimals of the minor unit of the currencies that do not have 2
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+var currencies = func() map[string]bool {
+	m := map[string]bool{}
+	for _, code := range strings.Fields(currencyCodes) {
+		m[code] = true
+	}
+	return m
+}()
+
+// maxMoneyDigits digits of the largest amount, it fits the BIGINT columns. Converted amounts are checked separately
+const maxMoneyDigits = 15
+
+var errInvalidAmount = errors.New("invalid amount")
+
+// IsCurrency reports whether the code is an ISO 4217 currency code in circulation.
+func IsCurrency(code string) bool {
+	return currencies[code]
+}
+
+// CurrencyExponent returns the number of decimals of the minor unit of the currency, eg. 2 for USD and 0 for JPY.
+func CurrencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+
+	return 2
+}
+
+// Money an amount in the minor unit of its currency, eg. 19.99 USD is Amount 1999. It is encoded
+// in JSON with the amount as a decimal string so it never passes through a float.
+type Money struct {
+	Amount   int64
+	Currency string
+}
+
+// ParseMoney parses a decimal amount such as "19.99" in the currency. The amount may not have more
+// decimals than the minor unit of the currency.
+func ParseMoney(amount, currency string) (m Money, err error) {
+	currency = strings.ToUpper(currency)
+	if !IsCurrency(currency) {
+		return m, errors.New("unknown currency " + currency)
+	}
+
+	s := strings.TrimSpace(amount)
+	negative := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	whole, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, fraction = s[:i], s[i+1:]
+		if fraction == "" {
+			return m, errInvalidAmount
+		}
+	}
+
+	exponent := CurrencyExponent(currency)
+	if len(fraction) > exponent {
+		return m, errors.New(currency + " amounts have at most " + strconv.Itoa(exponent) + " decimals")
+	}
+
+	digits := strings.TrimLeft(whole+fraction+strings.Repeat("0", exponent-len(fraction)), "0")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) || len(digits) > maxMoneyDigits {
+		return m, errInvalidAmount
+	}
+
+	m.Currency = currency
+	if digits != "" {
+		m.Amount, err = strconv.ParseInt(digits, 10, 64)
+		if err != nil {
+			return m, errInvalidAmount
+		}
+	}
+	if negative {
+		m.Amount = -m.Amount
+	}
+
+	return m, nil
+}
+
+// Decimal returns the amount in the major unit, eg. "19.99" for 1999 USD.
+func (m Money) Decimal() string {
+	exponent := CurrencyExponent(m.Currency)
+
+	sign, amount := "", m.Amount
+	if amount < 0 {
+		sign, amount = "-", -amount
+	}
+
+	s := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + s
+	}
+	if len(s) <= exponent {
+		s = strings.Repeat("0", exponent-len(s)+1) + s
+	}
+
+	return sign + s[:len(s)-exponent] + "." + s[len(s)-exponent:]
+}
+
+// String returns the money as amount and currency, eg. "19.99 USD".
+func (m Money) String() string {
+	return m.Decimal() + " " + m.Currency
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+// MarshalJSON encode money as {"amount": "19.99", "currency": "USD"}
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.Decimal(), Currency: m.Currency})
+}
+
+// UnmarshalJSON decode money encoded by MarshalJSON
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var mj moneyJSON
+	err := json.Unmarshal(data, &mj)
+	if err != nil {
+		return err
+	}
+
+	*m, err = ParseMoney(mj.Amount, mj.Currency)
+	return err
+}
+
+// CurrencyConverter converts money to another currency for display
+type CurrencyConverter interface {
+	// Convert returns a DataValidationError when there is no rate for one of the currencies
+	Convert(m Money, currency string) (Money, error)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,53 @@
+package domain
+
+import "github.com/google/uuid"
+
+// OidcProviderConfig configuration of an OpenID Connect identity provider
+type OidcProviderConfig struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// OidcAuthorization where the browser has to be sent to login with the provider
+type OidcAuthorization struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"state"`
+}
+
+// OidcLoginState a pending login, kept between the authorize redirect and the callback
+type OidcLoginState struct {
+	State        string
+	Provider     string
+	CodeVerifier string
+	Nonce        string
+	CreatedAt    int
+}
+
+// UserIdentity an account of an external identity provider linked to a user
+type UserIdentity struct {
+	Provider  string
+	Subject   string
+	UserID    uuid.UUID
+	Email     string
+	CreatedAt int
+}
+
+// OidcUsecase represent the OpenID Connect login use cases
+type OidcUsecase interface {
+	Providers() (names []string)
+	Authorize(provider string) (auth OidcAuthorization, err error)
+	Callback(provider, code, state string, ac AuditContext) (u User, err error)
+}
+
+// OidcRepository represent the OpenID Connect login repository
+type OidcRepository interface {
+	SaveState(s *OidcLoginState) (err error)
+	// ConsumeState returns and deletes the pending login, a state can be used only once
+	ConsumeState(state string) (s OidcLoginState, err error)
+	GetIdentity(provider, subject string) (i UserIdentity, err error)
+	LinkIdentity(i *UserIdentity) (err error)
+}
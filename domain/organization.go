@@ -0,0 +1,72 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ConstDefaultOrganizationSlug the organization the catalog existing before multi-tenancy was moved to
+const ConstDefaultOrganizationSlug = "default"
+
+// ErrNoActiveOrganization the access token is not scoped to an organization
+var ErrNoActiveOrganization = ForbiddenError{Message: "no active organization, create or switch to an organization first"}
+
+type OrganizationForm struct {
+	Name string `json:"name" validate:"required,max=255"`
+	Slug string `json:"slug" validate:"required,min=2,max=64,lowercase,alphanum"`
+}
+
+type MembershipForm struct {
+	Email string `json:"email" validate:"required,email"`
+	// Role granted within the organization, empty for a plain member
+	Role string `json:"role"`
+}
+
+// Organization a tenant owning its own book catalog
+type Organization struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	CreatedAt int       `json:"created_at"`
+}
+
+// Membership an organization the user belongs to, with the role granted within it
+type Membership struct {
+	Organization
+	Role string `json:"role"`
+}
+
+// OrganizationMember a user belonging to an organization
+type OrganizationMember struct {
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	CreatedAt int       `json:"created_at"`
+}
+
+// OrganizationUsecase represent the organization's use cases
+type OrganizationUsecase interface {
+	Create(actor Actor, f *OrganizationForm) (m Membership, err error)
+	Fetch(userID uuid.UUID) (memberships []Membership, err error)
+	GetBySlug(slug string) (o Organization, err error)
+	// DefaultMembership returns the organization a new access token is scoped to, nil when the user belongs to none
+	DefaultMembership(userID uuid.UUID) (m *Membership, err error)
+	GetMembership(userID, organizationID uuid.UUID) (m Membership, err error)
+	FetchMembers(actor Actor, organizationID uuid.UUID) (members []OrganizationMember, err error)
+	SetMember(actor Actor, organizationID uuid.UUID, f *MembershipForm) (member OrganizationMember, err error)
+	RemoveMember(actor Actor, organizationID, userID uuid.UUID) (err error)
+}
+
+// OrganizationRepository represent the organization's repository
+type OrganizationRepository interface {
+	// Create creates the organization with the owner as its first member
+	Create(o *Organization, ownerID uuid.UUID, ownerRole string) (err error)
+	GetBySlug(slug string) (o Organization, err error)
+	// FetchMemberships returns the organizations of the user, oldest membership first
+	FetchMemberships(userID uuid.UUID) (memberships []Membership, err error)
+	GetMembership(userID, organizationID uuid.UUID) (m Membership, err error)
+	FetchMembers(organizationID uuid.UUID) (members []OrganizationMember, err error)
+	GetMember(organizationID, userID uuid.UUID) (member OrganizationMember, err error)
+	// UpsertMember and RemoveMember return a ConflictError instead of removing or demoting the last member
+	// whose role grants the role:manage permission
+	UpsertMember(organizationID, userID uuid.UUID, role string) (err error)
+	RemoveMember(organizationID, userID uuid.UUID) (rowsAffected int64, err error)
+}
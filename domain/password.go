@@ -0,0 +1,10 @@
+package domain
+
+// PasswordHasher hashes passwords into the PHC string format stored in user.password_hash
+type PasswordHasher interface {
+	Hash(password string) (encoded string, err error)
+	// Verify reports whether the password matches the hash, hashes of older algorithms are accepted
+	Verify(password, encoded string) bool
+	// NeedsRehash reports whether the hash was made with another algorithm or outdated parameters
+	NeedsRehash(encoded string) bool
+}
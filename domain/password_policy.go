@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Password policy violation codes
+const (
+	ConstPasswordTooShort         = "password_too_short"
+	ConstPasswordTooLong          = "password_too_long"
+	ConstPasswordMissingClasses   = "password_missing_character_classes"
+	ConstPasswordContainsIdentity = "password_contains_identity"
+	ConstPasswordTooWeak          = "password_too_weak"
+	ConstPasswordBreached         = "password_breached"
+)
+
+// passwordViolationMessages localized messages of the violation codes, %d is replaced by the violation param
+var passwordViolationMessages = map[string]map[string]string{
+	"en": {
+		ConstPasswordTooShort:         "password must be at least %d characters long",
+		ConstPasswordTooLong:          "password must be at most %d characters long",
+		ConstPasswordMissingClasses:   "password must contain at least %d of: lowercase letters, uppercase letters, digits, symbols",
+		ConstPasswordContainsIdentity: "password must not contain your username or email address",
+		ConstPasswordTooWeak:          "password is too easy to guess, use a longer phrase or less common words",
+		ConstPasswordBreached:         "password has appeared in a data breach, choose another one",
+	},
+	"id": {
+		ConstPasswordTooShort:         "password minimal %d karakter",
+		ConstPasswordTooLong:          "password maksimal %d karakter",
+		ConstPasswordMissingClasses:   "password harus mengandung minimal %d dari: huruf kecil, huruf besar, angka, simbol",
+		ConstPasswordContainsIdentity: "password tidak boleh mengandung username atau alamat email anda",
+		ConstPasswordTooWeak:          "password terlalu mudah ditebak, gunakan kalimat yang lebih panjang atau kata yang tidak umum",
+		ConstPasswordBreached:         "password pernah bocor dalam kebocoran data, pilih password lain",
+	},
+}
+
+// PasswordLanguages languages the password policy errors are translated to, the first one is the default
+var PasswordLanguages = []string{"en", "id"}
+
+// PasswordViolation a rule of the password policy the password does not satisfy
+type PasswordViolation struct {
+	Code  string
+	Param int
+}
+
+// Message returns the violation message in the language, English when it is not supported.
+func (v PasswordViolation) Message(lang string) string {
+	messages, ok := passwordViolationMessages[strings.ToLower(lang)]
+	if !ok {
+		messages = passwordViolationMessages[PasswordLanguages[0]]
+	}
+
+	if strings.Contains(messages[v.Code], "%d") {
+		return fmt.Sprintf(messages[v.Code], v.Param)
+	}
+
+	return messages[v.Code]
+}
+
+// PasswordPolicyError the violations of a rejected password
+type PasswordPolicyError struct {
+	Field      string
+	Violations []PasswordViolation
+}
+
+func (p PasswordPolicyError) Error() string {
+	messages := make([]string, 0, len(p.Violations))
+	for _, v := range p.Violations {
+		messages = append(messages, v.Message(PasswordLanguages[0]))
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// PasswordPolicy checks new passwords, identity holds the username, email address and name of the account
+type PasswordPolicy interface {
+	Check(password string, identity ...string) (err error)
+}
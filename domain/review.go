@@ -0,0 +1,41 @@
+package domain
+
+import "github.com/google/uuid"
+
+// ReviewForm form for create or edit a review
+type ReviewForm struct {
+	Rating int    `json:"rating" validate:"required,min=1,max=5"`
+	Body   string `json:"body" validate:"max=5000"`
+}
+
+// Review a review of a book, a user reviews a book once
+type Review struct {
+	ID        int       `json:"id"`
+	BookID    int       `json:"book_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	Rating    int       `json:"rating"`
+	Body      string    `json:"body"`
+	CreatedAt int       `json:"created_at"`
+	UpdatedAt int       `json:"updated_at"`
+}
+
+// ReviewUsecase represent the review's use cases, actor reviews the books of the catalog of the organization
+type ReviewUsecase interface {
+	Fetch(organizationID uuid.UUID, bookID, perPage, page int) (reviews []Review, totalCount, pageCount, currentPage int, err error)
+	Create(actor Actor, organizationID uuid.UUID, bookID int, rf *ReviewForm) (r Review, err error)
+	Update(actor Actor, organizationID uuid.UUID, bookID int, rf *ReviewForm) (r Review, err error)
+	Delete(actor Actor, organizationID uuid.UUID, bookID int) (err error)
+}
+
+// ReviewRepository represent the review's repository. Every write updates the rating of the book in the
+// same transaction and is recorded in the audit log on behalf of the AuditContext.
+type ReviewRepository interface {
+	Fetch(organizationID uuid.UUID, bookID, perPage, page int) (reviews []Review, totalCount, pageCount, currentPage int, err error)
+	// Create returns a DataValidationError when the user has already reviewed the book
+	Create(organizationID uuid.UUID, bookID int, rf *ReviewForm, ac AuditContext) (r Review, err error)
+	// Update edits the review of the user of the AuditContext
+	Update(organizationID uuid.UUID, bookID int, rf *ReviewForm, ac AuditContext) (r Review, err error)
+	// Delete deletes the review of the user of the AuditContext
+	Delete(organizationID uuid.UUID, bookID int, ac AuditContext) (err error)
+}
@@ -0,0 +1,52 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Default roles, see platform/migrations
+const (
+	ConstRoleAdmin  = "admin"
+	ConstRoleEditor = "editor"
+)
+
+// Permissions checked by GoMiddleware.RequirePermission
+const (
+	ConstPermissionBookWrite   = "book:write"
+	ConstPermissionBookPublish = "book:publish"
+	ConstPermissionRoleManage  = "role:manage"
+	ConstPermissionUserManage  = "user:manage"
+	ConstPermissionAuditRead   = "audit:read"
+)
+
+type GrantRoleForm struct {
+	Role string `json:"role" validate:"required"`
+}
+
+type UserStatusForm struct {
+	Status int `json:"status" validate:"oneof=0 1"`
+}
+
+// Role the role model
+type Role struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// RoleUsecase represent the role's use cases
+type RoleUsecase interface {
+	Fetch() (roles []Role, err error)
+	GetUserRoles(userID uuid.UUID) (roles []string, err error)
+	Grant(userID uuid.UUID, role string) (err error)
+	Revoke(userID uuid.UUID, role string) (err error)
+	HasPermission(roles []string, permission string) (allowed bool, err error)
+	Bootstrap(emails []string) (err error)
+}
+
+// RoleRepository represent the role's repository
+type RoleRepository interface {
+	Fetch() (roles []Role, err error)
+	GetUserRoles(userID uuid.UUID) (roles []string, err error)
+	Grant(userID uuid.UUID, role string) (err error)
+	GrantByEmail(email, role string) (rowsAffected int64, err error)
+	Revoke(userID uuid.UUID, role string) (rowsAffected int64, err error)
+}
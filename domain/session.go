@@ -0,0 +1,38 @@
+package domain
+
+import "github.com/google/uuid"
+
+// Session a login of a user, access tokens carry its ID in the sid claim
+type Session struct {
+	ID         uuid.UUID `json:"id"`
+	UserID     uuid.UUID `json:"-"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  int       `json:"created_at"`
+	LastSeenAt int       `json:"last_seen_at"`
+	ExpiresAt  int       `json:"expires_at"`
+	RevokedAt  int       `json:"-"`
+	Current    bool      `json:"current"`
+}
+
+// SessionUsecase represent the session's use cases
+type SessionUsecase interface {
+	Start(userID uuid.UUID, userAgent, ip string) (s Session, err error)
+	Fetch(userID, currentID uuid.UUID) (sessions []Session, err error)
+	Revoke(userID, id uuid.UUID) (err error)
+	// Validate reports an error when the session of an access token has been revoked
+	Validate(userID, id uuid.UUID, ip string) (err error)
+}
+
+// SessionRepository represent the session's repository
+type SessionRepository interface {
+	Create(s *Session) (err error)
+	// Fetch returns the sessions that are neither revoked nor expired
+	Fetch(userID uuid.UUID) (sessions []Session, err error)
+	GetByID(id uuid.UUID) (s Session, err error)
+	Revoke(userID, id uuid.UUID) (rowsAffected int64, err error)
+	RevokeAll(userID uuid.UUID) (err error)
+	// RevokeAllExcept revokes every session of the user but keepID, e.g. the session changing the password
+	RevokeAllExcept(userID, keepID uuid.UUID) (err error)
+	Touch(id uuid.UUID, ip string) (err error)
+}
@@ -17,27 +17,58 @@ type SignupForm struct {
 	Password       string `json:"password" validate:"required"`
 	PasswordRepeat string `json:"password_repeat" validate:"required,eqfield=Password"`
 	Captcha        string `json:"captcha" validate:"required"`
-	EmailSecretCode string `json:"email_secret_code" validate:"required,len=6"`
+	EmailSecretCode string `json:"email_secret_code" validate:"required_without=InviteToken,omitempty,len=6"`
+	InviteToken     string `json:"invite_token"`
+	InvitationID    uuid.UUID `json:"-"`
 }
 
 type LoginForm struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
 	Captcha  string `json:"captcha" validate:"required"`
+	IP       string `json:"-"`
+}
+
+type UpdateProfileForm struct {
+	Username string `json:"username" validate:"required,lowercase,alphanumunicode"`
+	FullName string `json:"full_name"`
+}
+
+type ChangePasswordForm struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	PasswordRepeat  string `json:"password_repeat" validate:"required,eqfield=Password"`
+}
+
+type ChangeEmailForm struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmEmailChangeForm struct {
+	SecretCode string `json:"secret_code" validate:"required,len=6"`
+}
+
+type DeleteAccountForm struct {
+	Password string `json:"password" validate:"required"`
 }
 
 type User struct {
 	ID                 uuid.UUID `json:"id"`
 	Username           string    `json:"username"`
 	FullName           string    `json:"full_name"`
-	AuthKey            string    `json:"auth_key"`
-	PasswordHash       string    `json:"password_hash"`
-	PasswordResetToken string    `json:"password_reset_token"`
-	VerificationToken  string    `json:"verification_token"`
+	AuthKey            string    `json:"-"`
+	PasswordHash       string    `json:"-"`
+	PasswordResetToken string    `json:"-"`
+	VerificationToken  string    `json:"-"`
 	Email              string    `json:"email"`
 	Status             int       `json:"status"`
 	CreatedAt          int       `json:"created_at"`
 	UpdatedAt          int       `json:"updated_at"`
+	DeletedAt          int       `json:"-"`
+	LockedUntil        int       `json:"-"`
+	Roles              []string  `json:"roles"`
+	// Organization the organization access tokens of the user are scoped to
+	Organization *Membership `json:"-"`
 }
 
 // FromJSON decode json to user struct
@@ -54,14 +85,44 @@ func (u *User) ToJSON() []byte {
 // UserUseCase represent the user's use cases
 type UserUseCase interface {
 	RequestSecret(email string) (secret string, err error)
-	Signup(s *SignupForm) (err error)
+	Signup(s *SignupForm, ac AuditContext) (err error)
 	Login(l *LoginForm) (u User, err error)
 	Profile() error
+	GetByID(id uuid.UUID) (u User, err error)
+	UpdateProfile(id uuid.UUID, pf *UpdateProfileForm, ac AuditContext) (u User, err error)
+	// ChangePassword signs out every other session of the user, sessionID is the session kept signed in
+	ChangePassword(id, sessionID uuid.UUID, cf *ChangePasswordForm, ac AuditContext) (err error)
+	RequestEmailChange(id uuid.UUID, ef *ChangeEmailForm) (err error)
+	ConfirmEmailChange(id uuid.UUID, cf *ConfirmEmailChangeForm, ac AuditContext) (u User, err error)
+	DeleteAccount(id uuid.UUID, df *DeleteAccountForm, ac AuditContext) (err error)
+	AnonymizeDeletedUsers() (count int64, err error)
+	SetStatus(id uuid.UUID, status int, ac AuditContext) (err error)
+	UsernameAvailable(username string) (a UsernameAvailability, err error)
+	BackfillUsernameSkeletons() (count int64, err error)
 }
 
-// UserRepository represent the user's repository
+// UserRepository represent the user's repository, account changes are recorded in the audit log on behalf of the AuditContext
 type UserRepository interface {
 	RequestSecret(email string) (secret string, err error)
-	Signup(s *SignupForm) (err error)
+	// Signup creates the account, with an InvitationID it accepts the invitation and grants its role in the same transaction
+	Signup(s *SignupForm, passwordHash string, ac AuditContext) (err error)
 	Login(l *LoginForm) (u User, err error)
+	GetByID(id uuid.UUID) (u User, err error)
+	UpdateProfile(id uuid.UUID, pf *UpdateProfileForm, ac AuditContext) (err error)
+	// UpdatePassword records nothing when ac is nil, e.g. when a hash is upgraded on login
+	UpdatePassword(id uuid.UUID, passwordHash string, ac *AuditContext) (err error)
+	RequestEmailChange(id uuid.UUID, email string) (secret string, err error)
+	ConfirmEmailChange(id uuid.UUID, secret string, ac AuditContext) (oldEmail, newEmail string, err error)
+	MarkDeleted(id uuid.UUID, releaseIdentity bool, ac AuditContext) (err error)
+	AnonymizeDeleted(deletedBefore int64, releaseIdentity bool) (count int64, err error)
+	UpdateStatus(id uuid.UUID, status int, ac AuditContext) (rowsAffected int64, err error)
+	// Lock only locks an account that is not locked yet, locked is false when another request locked it first
+	Lock(id uuid.UUID, until int64) (locked bool, err error)
+	GetByEmail(email string) (u User, err error)
+	// CreateExternal creates an active account without password for a user of an identity provider
+	CreateExternal(u *User, ac AuditContext) (err error)
+	// CheckUsernameAvailable returns ErrUsernameTaken or ErrUsernameSimilar when the username clashes with an account
+	CheckUsernameAvailable(username string) (err error)
+	// BackfillUsernameSkeletons fills the username skeleton of accounts created before it existed
+	BackfillUsernameSkeletons() (count int64, err error)
 }
@@ -0,0 +1,22 @@
+package domain
+
+// Username policy rejections
+var (
+	ErrUsernameReserved = DataValidationError{Field: "username", Message: "username is reserved"}
+	ErrUsernameTaken    = DataValidationError{Field: "username", Message: "username already taken"}
+	ErrUsernameSimilar  = DataValidationError{Field: "username", Message: "username is too similar to an existing username"}
+)
+
+// UsernameAvailability result of the live availability check of the signup form
+type UsernameAvailability struct {
+	Username  string `json:"username"`
+	Available bool   `json:"available"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// UsernamePolicy checks usernames chosen at signup and profile update, existing usernames are checked by the repository
+type UsernamePolicy interface {
+	Check(username string) (err error)
+	// MaxLength the maximum number of characters of a username, 0 for no limit
+	MaxLength() int
+}
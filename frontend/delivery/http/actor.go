@@ -0,0 +1,37 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// currentActor returns the authenticated user of a private route request.
+func currentActor(c *fiber.Ctx) (domain.Actor, error) {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.Actor{}, err
+	}
+
+	return domain.Actor{
+		UserID:           tokenMeta.UserID,
+		Roles:            tokenMeta.Roles,
+		OrganizationID:   tokenMeta.OrganizationID,
+		OrganizationRole: tokenMeta.OrganizationRole,
+		IP:               c.IP(),
+		RequestID:        requestID(c),
+	}, nil
+}
+
+// auditContext returns the audit context of a request made by the user, uuid.Nil for anonymous requests.
+func auditContext(c *fiber.Ctx, userID uuid.UUID) domain.AuditContext {
+	return domain.AuditContext{ActorID: userID, IP: c.IP(), RequestID: requestID(c)}
+}
+
+// requestID returns the ID assigned to the request by the RequestID middleware.
+func requestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(middleware.RequestIDContextKey).(string)
+	return id
+}
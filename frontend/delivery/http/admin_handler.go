@@ -0,0 +1,213 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// AdminHandler represent the httphandler for user & role administration
+type AdminHandler struct {
+	RoleUsecase       domain.RoleUsecase
+	UserUsecase       domain.UserUseCase
+	InvitationUsecase domain.InvitationUsecase
+	Validate          *validator.Validate
+}
+
+// NewAdminHandler will initialize the /admin resources endpoint
+func NewAdminHandler(app *fiber.App, validator *validator.Validate, roleUseCase domain.RoleUsecase, userUseCase domain.UserUseCase, invitationUseCase domain.InvitationUsecase, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
+	handler := &AdminHandler{
+		RoleUsecase:       roleUseCase,
+		UserUsecase:       userUseCase,
+		InvitationUsecase: invitationUseCase,
+		Validate:          validator,
+	}
+
+	canManageRoles := middL.RequirePermission(domain.ConstPermissionRoleManage)
+	canManageUsers := middL.RequirePermission(domain.ConstPermissionUserManage)
+
+	rAdmin := rPrivate.Group("/admin")
+	rAdmin.Get("/roles", canManageRoles, handler.FetchRoles)
+	rAdmin.Get("/user/:id/roles", canManageRoles, handler.GetUserRoles)
+	rAdmin.Post("/user/:id/roles", canManageRoles, handler.GrantRole)
+	rAdmin.Delete("/user/:id/roles/:role", canManageRoles, handler.RevokeRole)
+	rAdmin.Put("/user/:id/status", canManageUsers, handler.SetUserStatus)
+	rAdmin.Get("/invitations", canManageUsers, handler.FetchInvitations)
+	rAdmin.Post("/invitations", canManageUsers, handler.CreateInvitation)
+	rAdmin.Delete("/invitations/:id", canManageUsers, handler.RevokeInvitation)
+}
+
+// FetchRoles func gets all roles with their permissions.
+// @Summary get all roles
+// @Description Get all roles with their permissions.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=[]domain.Role,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/roles [get]
+func (ah *AdminHandler) FetchRoles(c *fiber.Ctx) error {
+	roles, err := ah.RoleUsecase.Fetch()
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: roles, Message: "Success"})
+}
+
+// GetUserRoles func gets roles granted to a user.
+// @Summary get user roles
+// @Description Get roles granted to a user.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Success 200 {object} domain.JSONResult{data=[]string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/user/{id}/roles [get]
+func (ah *AdminHandler) GetUserRoles(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid user id"})
+	}
+
+	roles, err := ah.RoleUsecase.GetUserRoles(userID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: roles, Message: "Success"})
+}
+
+// GrantRole func grants a role to a user.
+// @Summary grant role
+// @Description Grant a role to a user, takes effect on the user's next login.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role body domain.GrantRoleForm true "Role to grant"
+// @Success 200 {object} domain.JSONResult{data=[]string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/user/{id}/roles [post]
+func (ah *AdminHandler) GrantRole(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid user id"})
+	}
+
+	grantForm := new(domain.GrantRoleForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(grantForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ah.Validate.Struct(grantForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = ah.RoleUsecase.Grant(userID, grantForm.Role)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	roles, err := ah.RoleUsecase.GetUserRoles(userID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: roles, Message: "Success"})
+}
+
+// RevokeRole func revokes a role from a user.
+// @Summary revoke role
+// @Description Revoke a role from a user, takes effect on the user's next login.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} domain.JSONResult{data=[]string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/user/{id}/roles/{role} [delete]
+func (ah *AdminHandler) RevokeRole(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid user id"})
+	}
+
+	err = ah.RoleUsecase.Revoke(userID, c.Params("role"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	roles, err := ah.RoleUsecase.GetUserRoles(userID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: roles, Message: "Success"})
+}
+
+// SetUserStatus func activates or deactivates a user account.
+// @Summary set user status
+// @Description Activate (1) or deactivate (0) a user account. Deleted accounts can not be changed.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param id path string true "User ID"
+// @Param status body domain.UserStatusForm true "New status"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/user/{id}/status [put]
+func (ah *AdminHandler) SetUserStatus(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid user id"})
+	}
+
+	statusForm := new(domain.UserStatusForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(statusForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ah.Validate.Struct(statusForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = ah.UserUsecase.SetStatus(userID, statusForm.Status, actor.AuditContext())
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "updated", Message: "Success"})
+}
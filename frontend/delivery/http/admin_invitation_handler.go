@@ -0,0 +1,94 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// FetchInvitations func gets all signup invitations.
+// @Summary get invitations
+// @Description Get all signup invitations, newest first.
+// @Tags Admin
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=[]domain.Invitation,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/invitations [get]
+func (ah *AdminHandler) FetchInvitations(c *fiber.Ctx) error {
+	invitations, err := ah.InvitationUsecase.Fetch()
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: invitations, Message: "Success"})
+}
+
+// CreateInvitation func invites an email address to sign up.
+// @Summary create invitation
+// @Description Email an invitation token that replaces the email secret code on signup. Inviting with a role requires the role:manage permission.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Param invitation body domain.InvitationForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=domain.Invitation,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/invitations [post]
+func (ah *AdminHandler) CreateInvitation(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	invitationForm := new(domain.InvitationForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(invitationForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ah.Validate.Struct(invitationForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	invitation, err := ah.InvitationUsecase.Create(actor, invitationForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: invitation, Message: "Success"})
+}
+
+// RevokeInvitation func revokes an unused invitation.
+// @Summary revoke invitation
+// @Description Revoke an invitation that has not been used yet.
+// @Tags Admin
+// @Produce json
+// @Param id path string true "Invitation ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/admin/invitations/{id} [delete]
+func (ah *AdminHandler) RevokeInvitation(c *fiber.Ctx) error {
+	invitationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid invitation id"})
+	}
+
+	err = ah.InvitationUsecase.Revoke(invitationID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "revoked", Message: "Success"})
+}
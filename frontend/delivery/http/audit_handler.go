@@ -0,0 +1,86 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"strconv"
+)
+
+// AuditHandler represent the httphandler for the audit log
+type AuditHandler struct {
+	AuditUsecase domain.AuditUsecase
+}
+
+// NewAuditHandler will initialize the /audit resources endpoint
+func NewAuditHandler(app *fiber.App, auditUseCase domain.AuditUsecase, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
+	handler := &AuditHandler{
+		AuditUsecase: auditUseCase,
+	}
+
+	rPrivate.Get("/audit", middL.RequirePermission(domain.ConstPermissionAuditRead), handler.FetchAudit)
+}
+
+// FetchAudit func gets audit log entries.
+// @Summary get audit log
+// @Description Get recorded write operations, newest first. Before and after only hold the fields that changed.
+// @Tags Admin
+// @Produce json
+// @Param actor query string false "filter by actor (user ID)"
+// @Param resource_type query string false "filter by resource type, e.g. book or user"
+// @Param resource_id query string false "filter by resource ID"
+// @Param from query int false "unix timestamp, entries recorded at or after"
+// @Param to query int false "unix timestamp, entries recorded at or before"
+// @Param page query string false "page to display, default to 1"
+// @Param perPage query string false "num of records per page, default to 20"
+// @Success 200 {object} domain.JSONResult{data=[]domain.AuditEntry,meta=domain.JSONResultMeta,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/audit [get]
+func (ah *AuditHandler) FetchAudit(c *fiber.Ctx) error {
+	perPage, err := strconv.Atoi(c.Query("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = 20
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil {
+		page = 1
+	}
+
+	filter := domain.AuditFilter{
+		ResourceType: c.Query("resource_type"),
+		ResourceID:   c.Query("resource_id"),
+	}
+
+	if actor := c.Query("actor"); actor != "" {
+		filter.ActorID, err = uuid.Parse(actor)
+		if err != nil {
+			return domain.NewHttpError(c, domain.DataValidationError{Field: "actor", Message: "invalid actor id"})
+		}
+	}
+
+	if from := c.Query("from"); from != "" {
+		filter.From, err = strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return domain.NewHttpError(c, domain.DataValidationError{Field: "from", Message: "invalid unix timestamp"})
+		}
+	}
+
+	if to := c.Query("to"); to != "" {
+		filter.To, err = strconv.ParseInt(to, 10, 64)
+		if err != nil {
+			return domain.NewHttpError(c, domain.DataValidationError{Field: "to", Message: "invalid unix timestamp"})
+		}
+	}
+
+	entries, totalCount, pageCount, currentPage, err := ah.AuditUsecase.Fetch(filter, perPage, page)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: entries, Message: "Success", Meta: domain.JSONResultMeta{TotalCount: totalCount, PageCount: pageCount, CurrentPage: currentPage, PerPage: perPage}})
+}
@@ -0,0 +1,226 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// AuthorHandler represent the httphandler for author
+type AuthorHandler struct {
+	AuthorUsecase       domain.AuthorUsecase
+	OrganizationUsecase domain.OrganizationUsecase
+	Validate            *validator.Validate
+}
+
+// NewAuthorHandler will initialize the /author resources endpoint
+func NewAuthorHandler(app *fiber.App, validator *validator.Validate, authorUseCase domain.AuthorUsecase, organizationUseCase domain.OrganizationUsecase, rPublic, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
+	handler := &AuthorHandler{
+		AuthorUsecase:       authorUseCase,
+		OrganizationUsecase: organizationUseCase,
+		Validate:            validator,
+	}
+
+	rAuthor := rPublic.Group("/author")
+	rAuthor.Get("/", handler.FetchAuthors)
+	rAuthor.Get("/:id", handler.GetByID)
+
+	canWrite := middL.RequireOrganizationPermission(domain.ConstPermissionBookWrite)
+
+	rAuthAuthor := rPrivate.Group("/author")
+	rAuthAuthor.Post("/", canWrite, handler.Create)
+	rAuthAuthor.Put("/:id", canWrite, handler.Update)
+	rAuthAuthor.Delete("/:id", canWrite, handler.Delete)
+}
+
+// FetchAuthors func gets the authors of a catalog.
+// @Summary get all authors
+// @Description Get the authors of the catalog of an organization, ordered by name. Their works are listed by /v1/book?author={id}.
+// @Tags Author
+// @Produce json
+// @Param org query string false "organization slug, default to the default organization"
+// @Param name query string false "search by name, punctuation and case are ignored"
+// @Param page query string false "page to display, default to 1"
+// @Param perPage query string false "num of records per page, default to 20"
+// @Success 200 {object} domain.JSONResult{data=[]domain.Author,meta=domain.JSONResultMeta,message=string} "Description"
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/author [get]
+func (ah *AuthorHandler) FetchAuthors(c *fiber.Ctx) error {
+	perPage, err := strconv.Atoi(c.Query("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = 20
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil {
+		page = 1
+	}
+
+	organization, err := catalogOrganization(c, ah.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	authors, totalCount, pageCount, currentPage, err := ah.AuthorUsecase.Fetch(organization.ID, c.Query("name"), perPage, page)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: authors, Message: "Success", Meta: domain.JSONResultMeta{TotalCount: totalCount, PageCount: pageCount, CurrentPage: currentPage, PerPage: perPage}})
+}
+
+// GetByID func gets an author.
+// @Summary show an author
+// @Description Get author by ID.
+// @Tags Author
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=domain.Author,message=string}
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/author/{id} [get]
+func (ah *AuthorHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organization, err := catalogOrganization(c, ah.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	author, err := ah.AuthorUsecase.GetByID(organization.ID, id)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: author, Message: "Success"})
+}
+
+// Create func for creates a new author.
+// @Summary create a new author
+// @Description Create a new author in the catalog of the active organization.
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param author body domain.AuthorForm true "Add Author"
+// @Success 200 {object} domain.JSONResult{data=domain.Author,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/author [post]
+func (ah *AuthorHandler) Create(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	authorForm := new(domain.AuthorForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(authorForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ah.Validate.Struct(authorForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	author, err := ah.AuthorUsecase.Create(actor, authorForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: author, Message: "Success"})
+}
+
+// Update func for update an author.
+// @Summary update an author
+// @Description Update an author, the bylines of the books credited to the author are kept as they are.
+// @Tags Author
+// @Accept json
+// @Produce json
+// @Param id path int true "Author ID"
+// @Param author body domain.AuthorForm true "Update Author"
+// @Success 200 {object} domain.JSONResult{data=domain.Author,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/author/{id} [put]
+func (ah *AuthorHandler) Update(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	authorForm := new(domain.AuthorForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(authorForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ah.Validate.Struct(authorForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	author, err := ah.AuthorUsecase.Update(actor, id, authorForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: author, Message: "Success"})
+}
+
+// Delete func for delete an author.
+// @Summary delete an author
+// @Description Delete an author that is not credited on any book.
+// @Tags Author
+// @Produce json
+// @Param id path int true "Author ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/author/{id} [delete]
+func (ah *AuthorHandler) Delete(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = ah.AuthorUsecase.Delete(actor, id)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "deleted", Message: "Success"})
+}
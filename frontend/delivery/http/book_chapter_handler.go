@@ -0,0 +1,302 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// BookChapterHandler represent the httphandler for book chapters
+type BookChapterHandler struct {
+	BookChapterUsecase  domain.BookChapterUsecase
+	OrganizationUsecase domain.OrganizationUsecase
+	Validate            *validator.Validate
+}
+
+// NewBookChapterHandler will initialize the book chapter resources endpoint
+func NewBookChapterHandler(app *fiber.App, validator *validator.Validate, bookChapterUseCase domain.BookChapterUsecase, organizationUseCase domain.OrganizationUsecase, rPublic, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
+	handler := &BookChapterHandler{
+		BookChapterUsecase:  bookChapterUseCase,
+		OrganizationUsecase: organizationUseCase,
+		Validate:            validator,
+	}
+
+	rPublic.Get("/book/:id/chapters", handler.FetchChapters)
+	rPublic.Get("/book/:id/chapters/:chapter", handler.GetByID)
+
+	canWrite := middL.RequireOrganizationPermission(domain.ConstPermissionBookWrite)
+
+	rPrivate.Get("/book/:id/chapters", canWrite, handler.FetchForEditing)
+	rPrivate.Get("/book/:id/chapters/:chapter", canWrite, handler.GetForEditing)
+	rPrivate.Post("/book/:id/chapters", canWrite, handler.Create)
+	rPrivate.Put("/book/:id/chapters/:chapter", canWrite, handler.Update)
+	rPrivate.Delete("/book/:id/chapters/:chapter", canWrite, handler.Delete)
+}
+
+// FetchChapters func gets the table of contents of a book.
+// @Summary get book chapters
+// @Description Get the chapters of a published book in order, without their content.
+// @Tags Chapter
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=[]domain.BookChapterHeading,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/book/{id}/chapters [get]
+func (ch *BookChapterHandler) FetchChapters(c *fiber.Ctx) error {
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organization, err := catalogOrganization(c, ch.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapters, err := ch.BookChapterUsecase.Fetch(organization.ID, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: chapters, Message: "Success"})
+}
+
+// GetByID func gets a chapter of a book.
+// @Summary get book chapter
+// @Description Get a chapter of a published book with its Markdown content and the content rendered as sanitized HTML.
+// @Tags Chapter
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param chapter path int true "Chapter ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=domain.BookChapter,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/book/{id}/chapters/{chapter} [get]
+func (ch *BookChapterHandler) GetByID(c *fiber.Ctx) error {
+	idBook, idChapter, err := chapterParams(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organization, err := catalogOrganization(c, ch.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapter, err := ch.BookChapterUsecase.GetByID(organization.ID, idBook, idChapter)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: chapter, Message: "Success"})
+}
+
+// FetchForEditing func gets the table of contents of a book of the active organization.
+// @Summary get book chapters for editing
+// @Description Get the chapters of a book of the active organization whatever its status, without their content.
+// @Tags Chapter
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} domain.JSONResult{data=[]domain.BookChapterHeading,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/chapters [get]
+func (ch *BookChapterHandler) FetchForEditing(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapters, err := ch.BookChapterUsecase.FetchForEditing(actor, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: chapters, Message: "Success"})
+}
+
+// GetForEditing func gets a chapter of a book of the active organization.
+// @Summary get book chapter for editing
+// @Description Get a chapter of a book of the active organization whatever its status, with its Markdown content and HTML preview.
+// @Tags Chapter
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param chapter path int true "Chapter ID"
+// @Success 200 {object} domain.JSONResult{data=domain.BookChapter,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/chapters/{chapter} [get]
+func (ch *BookChapterHandler) GetForEditing(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, idChapter, err := chapterParams(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapter, err := ch.BookChapterUsecase.GetForEditing(actor, idBook, idChapter)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: chapter, Message: "Success"})
+}
+
+// Create func for add a chapter to a book.
+// @Summary add book chapter
+// @Description Add a chapter of Markdown to a book, at the end or at the position of the form. The book is stored as a new revision.
+// @Tags Chapter
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param chapter body domain.BookChapterForm true "Chapter"
+// @Success 200 {object} domain.JSONResult{data=domain.BookChapter,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/chapters [post]
+func (ch *BookChapterHandler) Create(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapterForm, err := ch.chapterForm(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapter, err := ch.BookChapterUsecase.Create(actor, idBook, chapterForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: chapter, Message: "Success"})
+}
+
+// Update func for update a chapter of a book.
+// @Summary update book chapter
+// @Description Update the title and content of a chapter, and move it when the form has a position. The book is stored as a new revision.
+// @Tags Chapter
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param chapter path int true "Chapter ID"
+// @Param form body domain.BookChapterForm true "Chapter"
+// @Success 200 {object} domain.JSONResult{data=domain.BookChapter,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/chapters/{chapter} [put]
+func (ch *BookChapterHandler) Update(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, idChapter, err := chapterParams(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapterForm, err := ch.chapterForm(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	chapter, err := ch.BookChapterUsecase.Update(actor, idBook, idChapter, chapterForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: chapter, Message: "Success"})
+}
+
+// Delete func for delete a chapter of a book.
+// @Summary delete book chapter
+// @Description Delete a chapter, the following chapters move up. The book is stored as a new revision.
+// @Tags Chapter
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param chapter path int true "Chapter ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/chapters/{chapter} [delete]
+func (ch *BookChapterHandler) Delete(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, idChapter, err := chapterParams(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = ch.BookChapterUsecase.Delete(actor, idBook, idChapter)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "deleted", Message: "Success"})
+}
+
+// chapterForm parses and validates the chapter of the request.
+func (ch *BookChapterHandler) chapterForm(c *fiber.Ctx) (*domain.BookChapterForm, error) {
+	chapterForm := new(domain.BookChapterForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(chapterForm); err != nil {
+		return nil, err
+	}
+
+	// Validate form input
+	return chapterForm, ch.Validate.Struct(chapterForm)
+}
+
+// chapterParams returns the book and chapter IDs of the request path.
+func chapterParams(c *fiber.Ctx) (idBook, idChapter int, err error) {
+	idBook, err = strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return
+	}
+
+	idChapter, err = strconv.Atoi(c.Params("chapter"))
+	return
+}
@@ -2,37 +2,56 @@ package http
 
 import (
 	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
 	"github.com/cooljar/go-postgres-fiber/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"io"
+	"io/ioutil"
 	"strconv"
+	"strings"
 )
 
 // BookHandler  represent the httphandler for book
 type BookHandler struct {
 	BookUsecase domain.BookUsecase
+	OrganizationUsecase domain.OrganizationUsecase
 	Validate *validator.Validate
 }
 
-func NewBookHandler(app *fiber.App, bookUseCase domain.BookUsecase, rPublic, rPrivate fiber.Router) {
+func NewBookHandler(app *fiber.App, bookUseCase domain.BookUsecase, organizationUseCase domain.OrganizationUsecase, rPublic, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
 	handler := &BookHandler{
 		BookUsecase: bookUseCase,
+		OrganizationUsecase: organizationUseCase,
 		Validate: utils.NewValidator(),
 	}
 
 	rBook := rPublic.Group("/book")
 	rBook.Get("/", handler.FetchBooks)
+	rBook.Get("/isbn/:isbn", handler.GetByISBN)
 	rBook.Get("/:id", handler.GetByID)
+	rBook.Get("/:id/revisions", handler.FetchRevisions)
+	rBook.Get("/:id/revisions/diff", handler.DiffRevisions)
+
+	canWrite := middL.RequireOrganizationPermission(domain.ConstPermissionBookWrite)
+	canPublish := middL.RequireOrganizationPermission(domain.ConstPermissionBookPublish)
 
 	rAuthBook := rPrivate.Group("/book")
-	rAuthBook.Post("/", handler.Create)
-	rAuthBook.Put("/:id", handler.Update)
-	rAuthBook.Delete("/:id", handler.Delete)
+	rAuthBook.Get("/", canWrite, handler.FetchForEditing)
+	rAuthBook.Get("/:id", canWrite, handler.GetForEditing)
+	rAuthBook.Post("/", canWrite, handler.Create)
+	rAuthBook.Put("/:id", canWrite, handler.Update)
+	rAuthBook.Delete("/:id", canWrite, handler.Delete)
+	rAuthBook.Post("/:id/revisions/:rev/restore", canWrite, handler.RestoreRevision)
+	rAuthBook.Post("/:id/cover", canWrite, handler.UploadCover)
+	rAuthBook.Post("/:id/submit", canWrite, handler.SubmitForReview)
+	rAuthBook.Put("/:id/status", canPublish, handler.SetStatus)
 }
 
 // Create func for creates a new book.
 // @Summary create a new book
-// @Description Create a new book.
+// @Description Create a new book in the catalog of the active organization.
 // @Tags Book
 // @Accept json
 // @Produce json
@@ -40,11 +59,18 @@ func NewBookHandler(app *fiber.App, bookUseCase domain.BookUsecase, rPublic, rPr
 // @Success 200 {object} domain.JSONResult{data=[]domain.Book,message=string} "Description"
 // @Failure 422 {object} []domain.HTTPError
 // @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
 // @Failure 404 {object} domain.HTTPError
+// @Failure 409 {object} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
 // @Security ApiKeyAuth
 // @Router /v1/auth/book [post]
 func (b *BookHandler) Create(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
 	// Instantiate new Book struct
 	book := new(domain.BookForm)
 
@@ -54,12 +80,12 @@ func (b *BookHandler) Create(c *fiber.Ctx) error {
 	}
 
 	// Validate form input
-	err := b.Validate.Struct(book)
+	err = b.Validate.Struct(book)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
 
-	newBook, err := b.BookUsecase.Create(book)
+	newBook, err := b.BookUsecase.Create(actor, book)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
@@ -71,28 +97,39 @@ func (b *BookHandler) Create(c *fiber.Ctx) error {
 }
 
 // FetchBooks func gets all exists books.
-// @Description Get all exists books.
+// @Description Get all exists books of an organization catalog.
 // @Summary get all exists books
 // @Tags Book
 // @Produce json
+// @Param org query string false "organization slug, default to the default organization"
 // @Param title query string false "search by title"
 // @Param page query string false "page to display, default to 1"
-// @Param perPage query string false "num of records per page, default to 20"
+// @Param perPage query string false "num of records per page, default to 20, at most 100"
+// @Param owner query string false "filter by owner (user ID)"
+// @Param author query int false "filter by credited author (author ID)"
+// @Param category query int false "filter by category (category ID), including its subcategories"
+// @Param tag query string false "filter by tag"
+// @Param currency query string false "ISO 4217 currency to display the prices in"
 // @Success 200 {object} domain.JSONResult{data=[]domain.Book,meta=domain.JSONResultMeta,message=string} "Description"
 // @Router /v1/book [get]
 func (b *BookHandler) FetchBooks(c *fiber.Ctx) error {
-	perPage, err := strconv.Atoi(c.Query("perPage"))
+	filter, perPage, page, err := bookFilter(c)
 	if err != nil {
-		perPage = 20
+		return domain.NewHttpError(c, err)
 	}
+	filter.Status = domain.ConstBookStatusPublished
 
-	page, err := strconv.Atoi(c.Query("page"))
+	organization, err := b.organization(c)
 	if err != nil {
-		page = 1
+		return domain.NewHttpError(c, err)
 	}
 
-	books, totalCount, pageCount, currentPage, err := b.BookUsecase.Fetch(perPage, page)
+	books, totalCount, pageCount, currentPage, err := b.BookUsecase.Fetch(organization.ID, filter, perPage, page)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
 
+	err = b.displayPrices(c, books)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
@@ -106,9 +143,12 @@ func (b *BookHandler) FetchBooks(c *fiber.Ctx) error {
 // @Tags Book
 // @Produce  json
 // @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Param currency query string false "ISO 4217 currency to display the price in"
 // @Success 200 {object} domain.JSONResult{data=domain.Book,message=string}
 // @Failure 400 {object} domain.HTTPError
 // @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
 // @Router /v1/book/{id} [get]
 func (b *BookHandler) GetByID(c *fiber.Ctx) error {
@@ -117,10 +157,56 @@ func (b *BookHandler) GetByID(c *fiber.Ctx) error {
 		return domain.NewHttpError(c, err)
 	}
 
-	book, err := b.BookUsecase.GetByID(idBook)
+	organization, err := b.organization(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	book, err := b.BookUsecase.GetByID(organization.ID, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	books := []domain.Book{book}
+	err = b.displayPrices(c, books)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+	book = books[0]
+
+	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
+}
+
+// GetByISBN func gets a book by its ISBN.
+// @Summary get book by ISBN
+// @Description Get a book by its ISBN-10 or ISBN-13, hyphens allowed.
+// @Tags Book
+// @Produce json
+// @Param isbn path string true "ISBN"
+// @Param org query string false "organization slug, default to the default organization"
+// @Param currency query string false "ISO 4217 currency to display the price in"
+// @Success 200 {object} domain.JSONResult{data=domain.Book,message=string}
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/book/isbn/{isbn} [get]
+func (b *BookHandler) GetByISBN(c *fiber.Ctx) error {
+	organization, err := b.organization(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	book, err := b.BookUsecase.GetByISBN(organization.ID, c.Params("isbn"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	books := []domain.Book{book}
+	err = b.displayPrices(c, books)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
+	book = books[0]
 
 	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
 }
@@ -136,11 +222,18 @@ func (b *BookHandler) GetByID(c *fiber.Ctx) error {
 // @Success 200 {object} domain.JSONResult{data=[]domain.Book,message=string} "Description"
 // @Failure 422 {object} []domain.HTTPError
 // @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
 // @Failure 404 {object} domain.HTTPError
+// @Failure 409 {object} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
 // @Security ApiKeyAuth
 // @Router /v1/auth/book/{id} [put]
 func (b *BookHandler) Update(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
 	idBook, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
 		return domain.NewHttpError(c, err)
@@ -160,7 +253,7 @@ func (b *BookHandler) Update(c *fiber.Ctx) error {
 		return domain.NewHttpError(c, err)
 	}
 
-	updatedBook, err := b.BookUsecase.Update(idBook, book)
+	updatedBook, err := b.BookUsecase.Update(actor, idBook, book)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
@@ -178,22 +271,307 @@ func (b *BookHandler) Update(c *fiber.Ctx) error {
 // @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
 // @Security ApiKeyAuth
 // @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
 // @Router /v1/auth/book/{id} [delete]
 func (b *BookHandler) Delete(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
 	idBook, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
 
-	rowsAffected, err := b.BookUsecase.Delete(idBook)
+	rowsAffected, err := b.BookUsecase.Delete(actor, idBook)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
 
 	if rowsAffected < 1 {
-		return domain.NewHttpError(c, err)
+		return domain.NewHttpError(c, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"})
 	}
 
 	return c.JSON(domain.JSONResult{Data: "deleted", Message: "Success"})
 }
+
+// UploadCover func for upload the cover image of a book.
+// @Summary upload book cover
+// @Description Upload a JPEG, PNG or GIF cover image of at most 2 MB, replacing the current cover. Small, medium and large JPEG thumbnails are generated.
+// @Tags Book
+// @Accept mpfd
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param cover formData file true "Cover image"
+// @Success 200 {object} domain.JSONResult{data=domain.Book,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/cover [post]
+func (b *BookHandler) UploadCover(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	fileHeader, err := c.FormFile("cover")
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "cover", Message: "cover file is required"})
+	}
+	if fileHeader.Size > domain.ConstBookCoverMaxBytes {
+		return domain.NewHttpError(c, domain.ErrBookCoverTooLarge)
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+	defer file.Close()
+
+	// dibaca satu byte lebih dari batas supaya file yang terlalu besar tetap ketahuan
+	data, err := ioutil.ReadAll(io.LimitReader(file, domain.ConstBookCoverMaxBytes+1))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	book, err := b.BookUsecase.UploadCover(actor, idBook, data)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
+}
+
+// FetchForEditing func gets the books of the active organization of any status.
+// @Description Get the books of the active organization including drafts, books in review and archived books.
+// @Summary get books for editing
+// @Tags Book
+// @Produce json
+// @Param status query string false "filter by status: draft, in_review, published or archived"
+// @Param page query string false "page to display, default to 1"
+// @Param perPage query string false "num of records per page, default to 20, at most 100"
+// @Param owner query string false "filter by owner (user ID)"
+// @Param author query int false "filter by credited author (author ID)"
+// @Param category query int false "filter by category (category ID), including its subcategories"
+// @Param tag query string false "filter by tag"
+// @Success 200 {object} domain.JSONResult{data=[]domain.Book,meta=domain.JSONResultMeta,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book [get]
+func (b *BookHandler) FetchForEditing(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	filter, perPage, page, err := bookFilter(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	filter.Status = c.Query("status")
+	if filter.Status != "" && b.Validate.Var(filter.Status, "oneof=draft in_review published archived") != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "status", Message: "invalid status"})
+	}
+
+	books, totalCount, pageCount, currentPage, err := b.BookUsecase.Fetch(actor.OrganizationID, filter, perPage, page)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: books, Message: "Success", Meta: domain.JSONResultMeta{TotalCount: totalCount, PageCount: pageCount, CurrentPage: currentPage, PerPage: perPage}})
+}
+
+// GetForEditing func gets a book of the active organization of any status.
+// @Summary get book for editing
+// @Description Get a book of the active organization by ID whatever its status.
+// @Tags Book
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} domain.JSONResult{data=domain.Book,message=string}
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id} [get]
+func (b *BookHandler) GetForEditing(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	book, err := b.BookUsecase.GetForEditing(actor, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
+}
+
+// SubmitForReview func for submit a draft for review.
+// @Summary submit book for review
+// @Description Move a draft to in review, only the owner or an admin may submit it.
+// @Tags Book
+// @Produce json
+// @Param id path int true "Book ID"
+// @Success 200 {object} domain.JSONResult{data=domain.Book,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 409 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/submit [post]
+func (b *BookHandler) SubmitForReview(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	book, err := b.BookUsecase.SubmitForReview(actor, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
+}
+
+// SetStatus func for move a book to another status.
+// @Summary set book status
+// @Description Publish, unpublish, archive or return a book to draft. Publishing with publish_at in the future schedules the publication.
+// @Tags Book
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param status body domain.BookStatusForm true "Book status"
+// @Success 200 {object} domain.JSONResult{data=domain.Book,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 409 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/status [put]
+func (b *BookHandler) SetStatus(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	statusForm := new(domain.BookStatusForm)
+	if err := c.BodyParser(statusForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = b.Validate.Struct(statusForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	book, err := b.BookUsecase.SetStatus(actor, idBook, statusForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
+}
+
+// maxBooksPerPage caps the page size of the book listings
+const maxBooksPerPage = 100
+
+// bookFilter parses the paging and the filters of a book listing request.
+func bookFilter(c *fiber.Ctx) (filter domain.BookFilter, perPage, page int, err error) {
+	perPage, err = strconv.Atoi(c.Query("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = 20
+	}
+	if perPage > maxBooksPerPage {
+		perPage = maxBooksPerPage
+	}
+
+	page, err = strconv.Atoi(c.Query("page"))
+	if err != nil {
+		page = 1
+	}
+	err = nil
+
+	if owner := c.Query("owner"); owner != "" {
+		filter.Owner, err = uuid.Parse(owner)
+		if err != nil {
+			err = domain.DataValidationError{Field: "owner", Message: "invalid owner id"}
+			return
+		}
+	}
+
+	if category := c.Query("category"); category != "" {
+		filter.CategoryID, err = strconv.Atoi(category)
+		if err != nil {
+			err = domain.DataValidationError{Field: "category", Message: "invalid category id"}
+			return
+		}
+	}
+
+	filter.Tag = c.Query("tag")
+
+	if author := c.Query("author"); author != "" {
+		filter.AuthorID, err = strconv.Atoi(author)
+		if err != nil {
+			err = domain.DataValidationError{Field: "author", Message: "invalid author id"}
+			return
+		}
+	}
+
+	return
+}
+
+// displayPrices sets the display price of the books when the request asks for a currency.
+func (b *BookHandler) displayPrices(c *fiber.Ctx, books []domain.Book) error {
+	currency := strings.ToUpper(c.Query("currency"))
+	if currency == "" {
+		return nil
+	}
+
+	return b.BookUsecase.DisplayPrices(books, currency)
+}
+
+// organization returns the organization whose catalog a public request browses.
+func (b *BookHandler) organization(c *fiber.Ctx) (domain.Organization, error) {
+	return catalogOrganization(c, b.OrganizationUsecase)
+}
+
+// catalogOrganization returns the organization whose catalog a public request browses, selected with the org query.
+func catalogOrganization(c *fiber.Ctx, organizationUsecase domain.OrganizationUsecase) (domain.Organization, error) {
+	slug := c.Query("org")
+	if slug == "" {
+		slug = utils.GetEnv("DEFAULT_ORGANIZATION", domain.ConstDefaultOrganizationSlug)
+	}
+
+	return organizationUsecase.GetBySlug(slug)
+}
@@ -0,0 +1,39 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookFilter_PerPage(t *testing.T) {
+	app := fiber.New()
+	app.Get("/books", func(c *fiber.Ctx) error {
+		_, perPage, _, err := bookFilter(c)
+		if err != nil {
+			return err
+		}
+		return c.SendString(strconv.Itoa(perPage))
+	})
+
+	for query, expected := range map[string]int{
+		"":             20,
+		"?perPage=abc": 20,
+		"?perPage=0":   20,
+		"?perPage=-5":  20,
+		"?perPage=50":  50,
+		"?perPage=500": maxBooksPerPage,
+	} {
+		resp, err := app.Test(httptest.NewRequest("GET", "/books"+query, nil), -1)
+		require.NoError(t, err, query)
+
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err, query)
+		assert.Equal(t, strconv.Itoa(expected), string(body), query)
+	}
+}
@@ -0,0 +1,120 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// FetchRevisions func gets the revisions of a book.
+// @Summary get book revisions
+// @Description Get every version of a book, newest first. Revision 1 is the book as created.
+// @Tags Book
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=[]domain.BookRevision,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/book/{id}/revisions [get]
+func (b *BookHandler) FetchRevisions(c *fiber.Ctx) error {
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organization, err := b.organization(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	revisions, err := b.BookUsecase.Revisions(organization.ID, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: revisions, Message: "Success"})
+}
+
+// DiffRevisions func compares two revisions of a book.
+// @Summary diff book revisions
+// @Description Get the fields that changed from one revision of a book to another.
+// @Tags Book
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param from query int true "revision to compare from"
+// @Param to query int true "revision to compare to"
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=domain.BookRevisionDiff,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/book/{id}/revisions/diff [get]
+func (b *BookHandler) DiffRevisions(c *fiber.Ctx) error {
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "from", Message: "invalid revision"})
+	}
+
+	to, err := strconv.Atoi(c.Query("to"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "to", Message: "invalid revision"})
+	}
+
+	organization, err := b.organization(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	diff, err := b.BookUsecase.DiffRevisions(organization.ID, idBook, from, to)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: diff, Message: "Success"})
+}
+
+// RestoreRevision func rolls a book back to a revision.
+// @Summary restore book revision
+// @Description Update the book and its chapters to the content of a revision, the restored version is stored as a new revision.
+// @Tags Book
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param rev path int true "Revision to restore"
+// @Success 200 {object} domain.JSONResult{data=domain.Book,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/revisions/{rev}/restore [post]
+func (b *BookHandler) RestoreRevision(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	revision, err := strconv.Atoi(c.Params("rev"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "rev", Message: "invalid revision"})
+	}
+
+	book, err := b.BookUsecase.RestoreRevision(actor, idBook, revision)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: book, Message: "Success"})
+}
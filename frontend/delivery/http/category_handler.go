@@ -0,0 +1,180 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"strconv"
+)
+
+// CategoryHandler represent the httphandler for category
+type CategoryHandler struct {
+	CategoryUsecase     domain.CategoryUsecase
+	OrganizationUsecase domain.OrganizationUsecase
+	Validate            *validator.Validate
+}
+
+// NewCategoryHandler will initialize the /category resources endpoint
+func NewCategoryHandler(app *fiber.App, validator *validator.Validate, categoryUseCase domain.CategoryUsecase, organizationUseCase domain.OrganizationUsecase, rPublic, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
+	handler := &CategoryHandler{
+		CategoryUsecase:     categoryUseCase,
+		OrganizationUsecase: organizationUseCase,
+		Validate:            validator,
+	}
+
+	rPublic.Get("/category", handler.Tree)
+
+	canWrite := middL.RequireOrganizationPermission(domain.ConstPermissionBookWrite)
+
+	rAuthCategory := rPrivate.Group("/category")
+	rAuthCategory.Post("/", canWrite, handler.Create)
+	rAuthCategory.Put("/:id", canWrite, handler.Update)
+	rAuthCategory.Delete("/:id", canWrite, handler.Delete)
+}
+
+// Tree func gets the category tree of a catalog.
+// @Summary get category tree
+// @Description Get the root categories with their descendants. book_count counts the books in a category or any of its descendants, list them with /v1/book?category={id}.
+// @Tags Category
+// @Produce json
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=[]domain.Category,message=string} "Description"
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/category [get]
+func (ch *CategoryHandler) Tree(c *fiber.Ctx) error {
+	organization, err := catalogOrganization(c, ch.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	roots, err := ch.CategoryUsecase.Tree(organization.ID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: roots, Message: "Success"})
+}
+
+// Create func for creates a new category.
+// @Summary create a new category
+// @Description Create a category in the catalog of the active organization, below the parent category when given.
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param category body domain.CategoryForm true "Add Category"
+// @Success 200 {object} domain.JSONResult{data=domain.Category,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/category [post]
+func (ch *CategoryHandler) Create(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	categoryForm := new(domain.CategoryForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(categoryForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ch.Validate.Struct(categoryForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	category, err := ch.CategoryUsecase.Create(actor, categoryForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: category, Message: "Success"})
+}
+
+// Update func for update a category.
+// @Summary update a category
+// @Description Rename a category or move it, with its descendants, below another parent.
+// @Tags Category
+// @Accept json
+// @Produce json
+// @Param id path int true "Category ID"
+// @Param category body domain.CategoryForm true "Update Category"
+// @Success 200 {object} domain.JSONResult{data=domain.Category,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/category/{id} [put]
+func (ch *CategoryHandler) Update(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	categoryForm := new(domain.CategoryForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(categoryForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = ch.Validate.Struct(categoryForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	category, err := ch.CategoryUsecase.Update(actor, id, categoryForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: category, Message: "Success"})
+}
+
+// Delete func for delete a category.
+// @Summary delete a category
+// @Description Delete a category without subcategories, its books are unassigned from it.
+// @Tags Category
+// @Produce json
+// @Param id path int true "Category ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/category/{id} [delete]
+func (ch *CategoryHandler) Delete(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	id, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = ch.CategoryUsecase.Delete(actor, id)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "deleted", Message: "Success"})
+}
@@ -1,16 +1,24 @@
 package middleware
 
 import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	jwtMiddleware "github.com/gofiber/jwt/v2"
+	"github.com/google/uuid"
 	"os"
+	"strings"
 )
 
 // GoMiddleware represent the data-struct for middleware
 type GoMiddleware struct {
-	appCtx *fiber.App
+	appCtx         *fiber.App
+	roleUsecase    domain.RoleUsecase
+	apiKeyUsecase  domain.APIKeyUsecase
+	sessionUsecase domain.SessionUsecase
 	// another stuff , may be needed by middleware
 }
 
@@ -19,7 +27,7 @@ func (m *GoMiddleware) CORS() fiber.Handler {
 	return cors.New(cors.Config{
 		AllowOrigins: "*",
 		//AllowOrigins: "https://gofiber.io, https://gofiber.net",
-		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders: "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Request-ID",
 		AllowMethods: "GET, HEAD, PUT, PATCH, POST, DELETE",
 	})
 }
@@ -29,16 +37,200 @@ func (m *GoMiddleware) LOGGER() fiber.Handler {
 	return logger.New()
 }
 
-// JWT jwt.
+// RequestIDContextKey the fiber.Ctx locals key holding the request ID
+const RequestIDContextKey = "requestid"
+
+// RequestID assigns an ID to every request, or keeps the X-Request-ID sent by a proxy, and echoes it in the response.
+func (m *GoMiddleware) RequestID() fiber.Handler {
+	return requestid.New(requestid.Config{ContextKey: RequestIDContextKey})
+}
+
+// JWT jwt, personal API keys sent in X-API-Key or "Authorization: ApiKey <key>" are accepted as well.
+// A request with an API key only reaches the handler through a middleware checking its scopes,
+// RequirePermission, RequireOrganizationPermission or RequireScope, see utils.ExtractTokenMetadata.
 func (m *GoMiddleware) JWT() fiber.Handler {
 	// Create config for JWT authentication middleware.
 	config := jwtMiddleware.Config{
-		SigningKey:   []byte(os.Getenv("JWT_SECRET_KEY")),
-		ContextKey:   "jwt", // used in private routes
-		ErrorHandler: jwtError,
+		SigningKey:     []byte(os.Getenv("JWT_SECRET_KEY")),
+		ContextKey:     "jwt", // used in private routes
+		ErrorHandler:   jwtError,
+		SuccessHandler: m.checkSession,
+	}
+	jwtHandler := jwtMiddleware.New(config)
+
+	return func(c *fiber.Ctx) error {
+		if key := apiKeyFromRequest(c); key != "" {
+			return m.apiKeyAuth(c, key)
+		}
+
+		return jwtHandler(c)
+	}
+}
+
+// DenyAPIKey rejects requests authenticated with an API key, for account management routes. Must be used after JWT.
+// API keys are already denied on routes without a scope check, DenyAPIKey rejects them before any other middleware.
+func (m *GoMiddleware) DenyAPIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenMeta, err := utils.ParseTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		if tokenMeta.IsAPIKey() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "not available with an API key",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// RequirePermission only lets the request through when one of the roles
+// in the access token grants the permission. Must be used after JWT.
+func (m *GoMiddleware) RequirePermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenMeta, err := utils.ParseTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		allowed, err := m.roleUsecase.HasPermission(tokenMeta.Roles, permission)
+		if err != nil {
+			return domain.NewHttpError(c, err)
+		}
+
+		// API key hanya boleh memakai permission yang ada di scope-nya
+		if tokenMeta.IsAPIKey() && !hasScope(tokenMeta.Scopes, permission) {
+			allowed = false
+		}
+
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "missing permission " + permission,
+			})
+		}
+
+		utils.AllowAPIKey(c)
+
+		return c.Next()
 	}
+}
+
+// RequireOrganizationPermission is RequirePermission for routes acting in the active organization:
+// the role granted within the organization counts as well. Must be used after JWT.
+func (m *GoMiddleware) RequireOrganizationPermission(permission string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenMeta, err := utils.ParseTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
 
-	return jwtMiddleware.New(config)
+		roles := tokenMeta.Roles
+		if tokenMeta.OrganizationID != uuid.Nil && tokenMeta.OrganizationRole != "" {
+			roles = append(append([]string{}, roles...), tokenMeta.OrganizationRole)
+		}
+
+		allowed, err := m.roleUsecase.HasPermission(roles, permission)
+		if err != nil {
+			return domain.NewHttpError(c, err)
+		}
+
+		if tokenMeta.IsAPIKey() && !hasScope(tokenMeta.Scopes, permission) {
+			allowed = false
+		}
+
+		if !allowed {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "missing permission " + permission,
+			})
+		}
+
+		utils.AllowAPIKey(c)
+
+		return c.Next()
+	}
+}
+
+// RequireScope lets access tokens through and API keys only when their scopes include the scope,
+// for routes open to every user. Must be used after JWT.
+func (m *GoMiddleware) RequireScope(scope string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenMeta, err := utils.ParseTokenMetadata(c)
+		if err != nil {
+			return jwtError(c, err)
+		}
+
+		if tokenMeta.IsAPIKey() && !hasScope(tokenMeta.Scopes, scope) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": true,
+				"msg":   "missing scope " + scope,
+			})
+		}
+
+		utils.AllowAPIKey(c)
+
+		return c.Next()
+	}
+}
+
+// checkSession rejects access tokens whose session has been revoked or expired.
+func (m *GoMiddleware) checkSession(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ParseTokenMetadata(c)
+	if err != nil {
+		return jwtError(c, err)
+	}
+
+	err = m.sessionUsecase.Validate(tokenMeta.UserID, tokenMeta.SessionID, c.IP())
+	if err == domain.ErrSessionRevoked {
+		return jwtError(c, err)
+	}
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.Next()
+}
+
+// apiKeyAuth verifies the API key and stores a token built from it under the same context key as the JWT middleware.
+func (m *GoMiddleware) apiKeyAuth(c *fiber.Ctx, key string) error {
+	user, apiKey, err := m.apiKeyUsecase.Authenticate(key, c.IP())
+	if err == domain.ErrInvalidAPIKey {
+		return jwtError(c, err)
+	}
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	c.Locals("jwt", utils.NewAPIKeyToken(&user, &apiKey))
+
+	return c.Next()
+}
+
+// apiKeyFromRequest returns the API key sent in X-API-Key or as "Authorization: ApiKey <key>".
+func apiKeyFromRequest(c *fiber.Ctx) string {
+	if key := c.Get("X-API-Key"); key != "" {
+		return strings.TrimSpace(key)
+	}
+
+	auth := c.Get(fiber.HeaderAuthorization)
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "ApiKey ") {
+		return strings.TrimSpace(auth[7:])
+	}
+
+	return ""
+}
+
+func hasScope(scopes []string, permission string) bool {
+	for _, s := range scopes {
+		if s == permission {
+			return true
+		}
+	}
+	return false
 }
 
 func jwtError(c *fiber.Ctx, err error) error {
@@ -58,6 +250,6 @@ func jwtError(c *fiber.Ctx, err error) error {
 }
 
 // InitMiddleware initialize the middleware
-func InitMiddleware(ctx *fiber.App) *GoMiddleware {
-	return &GoMiddleware{appCtx: ctx}
+func InitMiddleware(ctx *fiber.App, roleUsecase domain.RoleUsecase, apiKeyUsecase domain.APIKeyUsecase, sessionUsecase domain.SessionUsecase) *GoMiddleware {
+	return &GoMiddleware{appCtx: ctx, roleUsecase: roleUsecase, apiKeyUsecase: apiKeyUsecase, sessionUsecase: sessionUsecase}
 }
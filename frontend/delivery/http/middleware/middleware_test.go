@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeRoleUsecase struct {
+	domain.RoleUsecase
+}
+
+func (f *fakeRoleUsecase) HasPermission(roles []string, permission string) (bool, error) {
+	for _, r := range roles {
+		if r == domain.ConstRoleAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type fakeAPIKeyUsecase struct {
+	domain.APIKeyUsecase
+	user domain.User
+	key  domain.APIKey
+}
+
+func (f *fakeAPIKeyUsecase) Authenticate(key, ip string) (domain.User, domain.APIKey, error) {
+	if key != "cjk_valid" {
+		return domain.User{}, domain.APIKey{}, domain.ErrInvalidAPIKey
+	}
+	return f.user, f.key, nil
+}
+
+type fakeSessionUsecase struct {
+	domain.SessionUsecase
+}
+
+func (f *fakeSessionUsecase) Validate(userID, id uuid.UUID, ip string) error {
+	return nil
+}
+
+// whoami a handler identifying the caller the way private route handlers do
+func whoami(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.SendString(tokenMeta.UserID.String())
+}
+
+func TestJWT_APIKeyScopes(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "test-secret")
+	defer os.Unsetenv("JWT_SECRET_KEY")
+
+	user := domain.User{ID: uuid.New(), Email: "jane@example.com", Roles: []string{domain.ConstRoleAdmin}}
+	apiKeys := &fakeAPIKeyUsecase{
+		user: user,
+		key:  domain.APIKey{ID: uuid.New(), Scopes: []string{domain.ConstPermissionBookWrite, domain.ConstScopeReviewWrite}, ExpiresAt: int(time.Now().Add(time.Hour).Unix())},
+	}
+	middL := InitMiddleware(nil, &fakeRoleUsecase{}, apiKeys, &fakeSessionUsecase{})
+
+	app := fiber.New()
+	rPrivate := app.Group("/auth", middL.JWT())
+	rPrivate.Get("/unscoped", whoami)
+	rPrivate.Get("/write", middL.RequirePermission(domain.ConstPermissionBookWrite), whoami)
+	rPrivate.Get("/publish", middL.RequirePermission(domain.ConstPermissionBookPublish), whoami)
+	rPrivate.Get("/org-write", middL.RequireOrganizationPermission(domain.ConstPermissionBookWrite), whoami)
+	rPrivate.Get("/account", middL.DenyAPIKey(), whoami)
+	rPrivate.Get("/review", middL.RequireScope(domain.ConstScopeReviewWrite), whoami)
+	rPrivate.Get("/other-scope", middL.RequireScope("other:write"), whoami)
+
+	accessToken, err := utils.GenerateNewAccessToken(&user, &domain.Session{ID: uuid.New(), ExpiresAt: int(time.Now().Add(time.Hour).Unix())})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+
+	tests := []struct {
+		description  string
+		route        string
+		header       string
+		value        string
+		expectedCode int
+	}{
+		{"api key without scope check", "/auth/unscoped", "X-API-Key", "cjk_valid", fiber.StatusForbidden},
+		{"api key with the scope", "/auth/write", "X-API-Key", "cjk_valid", fiber.StatusOK},
+		{"api key without the scope", "/auth/publish", "X-API-Key", "cjk_valid", fiber.StatusForbidden},
+		{"api key with the scope in the organization", "/auth/org-write", "Authorization", "ApiKey cjk_valid", fiber.StatusOK},
+		{"api key on account route", "/auth/account", "X-API-Key", "cjk_valid", fiber.StatusForbidden},
+		{"api key with the review scope", "/auth/review", "X-API-Key", "cjk_valid", fiber.StatusOK},
+		{"api key without the required scope", "/auth/other-scope", "X-API-Key", "cjk_valid", fiber.StatusForbidden},
+		{"access token on scoped route", "/auth/other-scope", "Authorization", "Bearer " + accessToken, fiber.StatusOK},
+		{"invalid api key", "/auth/write", "X-API-Key", "cjk_invalid", fiber.StatusUnauthorized},
+		{"access token without scope check", "/auth/unscoped", "Authorization", "Bearer " + accessToken, fiber.StatusOK},
+		{"access token on account route", "/auth/account", "Authorization", "Bearer " + accessToken, fiber.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest("GET", test.route, nil)
+		req.Header.Set(test.header, test.value)
+
+		resp, err := app.Test(req, -1)
+		assert.NoErrorf(t, err, test.description)
+		assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "test-secret")
+	defer os.Unsetenv("JWT_SECRET_KEY")
+
+	middL := InitMiddleware(nil, &fakeRoleUsecase{}, &fakeAPIKeyUsecase{}, &fakeSessionUsecase{})
+
+	app := fiber.New()
+	rPrivate := app.Group("/auth", middL.JWT())
+	rPrivate.Get("/write", middL.RequirePermission(domain.ConstPermissionBookWrite), whoami)
+
+	session := &domain.Session{ID: uuid.New(), ExpiresAt: int(time.Now().Add(time.Hour).Unix())}
+	tests := []struct {
+		description  string
+		roles        []string
+		expectedCode int
+	}{
+		{"admin", []string{domain.ConstRoleAdmin}, fiber.StatusOK},
+		{"role without the permission", []string{domain.ConstRoleEditor}, fiber.StatusForbidden},
+		{"no role", nil, fiber.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		accessToken, err := utils.GenerateNewAccessToken(&domain.User{ID: uuid.New(), Roles: test.roles}, session)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+
+		req := httptest.NewRequest("GET", "/auth/write", nil)
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+
+		resp, err := app.Test(req, -1)
+		assert.NoErrorf(t, err, test.description)
+		assert.Equalf(t, test.expectedCode, resp.StatusCode, test.description)
+	}
+}
@@ -0,0 +1,193 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"strconv"
+)
+
+// ReviewHandler represent the httphandler for book reviews
+type ReviewHandler struct {
+	ReviewUsecase       domain.ReviewUsecase
+	OrganizationUsecase domain.OrganizationUsecase
+	Validate            *validator.Validate
+}
+
+// NewReviewHandler will initialize the book review resources endpoint
+func NewReviewHandler(app *fiber.App, validator *validator.Validate, reviewUseCase domain.ReviewUsecase, organizationUseCase domain.OrganizationUsecase, rPublic, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
+	handler := &ReviewHandler{
+		ReviewUsecase:       reviewUseCase,
+		OrganizationUsecase: organizationUseCase,
+		Validate:            validator,
+	}
+
+	rPublic.Get("/book/:id/reviews", handler.FetchReviews)
+
+	// ulasan bisa ditulis semua user yang login, tidak perlu permission, API key perlu scope review:write
+	canReview := middL.RequireScope(domain.ConstScopeReviewWrite)
+
+	rPrivate.Post("/book/:id/review", canReview, handler.Create)
+	rPrivate.Put("/book/:id/review", canReview, handler.Update)
+	rPrivate.Delete("/book/:id/review", canReview, handler.Delete)
+}
+
+// FetchReviews func gets the reviews of a book.
+// @Summary get book reviews
+// @Description Get the reviews of a book, newest first.
+// @Tags Review
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Param page query string false "page to display, default to 1"
+// @Param perPage query string false "num of records per page, default to 20"
+// @Success 200 {object} domain.JSONResult{data=[]domain.Review,meta=domain.JSONResultMeta,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/book/{id}/reviews [get]
+func (rh *ReviewHandler) FetchReviews(c *fiber.Ctx) error {
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	perPage, err := strconv.Atoi(c.Query("perPage"))
+	if err != nil || perPage < 1 {
+		perPage = 20
+	}
+
+	page, err := strconv.Atoi(c.Query("page"))
+	if err != nil {
+		page = 1
+	}
+
+	organization, err := catalogOrganization(c, rh.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	reviews, totalCount, pageCount, currentPage, err := rh.ReviewUsecase.Fetch(organization.ID, idBook, perPage, page)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: reviews, Message: "Success", Meta: domain.JSONResultMeta{TotalCount: totalCount, PageCount: pageCount, CurrentPage: currentPage, PerPage: perPage}})
+}
+
+// Create func for review a book.
+// @Summary review a book
+// @Description Review a book with 1 to 5 stars, a user reviews a book once. The rating of the book is updated.
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Param review body domain.ReviewForm true "Review"
+// @Success 200 {object} domain.JSONResult{data=domain.Review,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/review [post]
+func (rh *ReviewHandler) Create(c *fiber.Ctx) error {
+	return rh.save(c, rh.ReviewUsecase.Create)
+}
+
+// Update func for edit your review of a book.
+// @Summary edit your review
+// @Description Edit your review of a book. The rating of the book is updated.
+// @Tags Review
+// @Accept json
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Param review body domain.ReviewForm true "Review"
+// @Success 200 {object} domain.JSONResult{data=domain.Review,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} []domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/review [put]
+func (rh *ReviewHandler) Update(c *fiber.Ctx) error {
+	return rh.save(c, rh.ReviewUsecase.Update)
+}
+
+// Delete func for delete your review of a book.
+// @Summary delete your review
+// @Description Delete your review of a book, also after the book was unpublished. The rating of the book is updated.
+// @Tags Review
+// @Produce json
+// @Param id path int true "Book ID"
+// @Param org query string false "organization slug, default to the default organization"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/book/{id}/review [delete]
+func (rh *ReviewHandler) Delete(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organization, err := catalogOrganization(c, rh.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = rh.ReviewUsecase.Delete(actor, organization.ID, idBook)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "deleted", Message: "Success"})
+}
+
+// save parses and validates the review of the request and saves it with fn.
+func (rh *ReviewHandler) save(c *fiber.Ctx, fn func(actor domain.Actor, organizationID uuid.UUID, bookID int, rf *domain.ReviewForm) (domain.Review, error)) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	idBook, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	reviewForm := new(domain.ReviewForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(reviewForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = rh.Validate.Struct(reviewForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organization, err := catalogOrganization(c, rh.OrganizationUsecase)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	review, err := fn(actor, organization.ID, idBook, reviewForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: review, Message: "Success"})
+}
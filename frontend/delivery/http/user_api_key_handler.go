@@ -0,0 +1,131 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// FetchAPIKeys func for list personal API keys.
+// @Summary list personal API keys
+// @Description List the personal API keys of the current user, including revoked and expired ones. Key secrets are never returned.
+// @Tags User
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=[]domain.APIKey,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/api-keys [get]
+func (uh *UserHandler) FetchAPIKeys(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	keys, err := uh.APIKeyUsecase.Fetch(tokenMeta.UserID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: keys, Message: "Success"})
+}
+
+// CreateAPIKey func for create a personal API key.
+// @Summary create personal API key
+// @Description Create a named API key limited to the given scopes, permissions of your roles or review:write, acting in the active organization. Send it in the X-API-Key header or as "Authorization: ApiKey <key>". The key is shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param key body domain.APIKeyForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=domain.NewAPIKey,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/api-keys [post]
+func (uh *UserHandler) CreateAPIKey(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	keyForm := new(domain.APIKeyForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(keyForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(keyForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	keyForm.OrganizationID = tokenMeta.OrganizationID
+
+	key, err := uh.APIKeyUsecase.Create(tokenMeta.UserID, keyForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: key, Message: "API key created, store it in a safe place"})
+}
+
+// RevokeAPIKey func for revoke a personal API key.
+// @Summary revoke personal API key
+// @Description Revoke one personal API key of the current user.
+// @Tags User
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/api-keys/{id} [delete]
+func (uh *UserHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	keyID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid api key id"})
+	}
+
+	err = uh.APIKeyUsecase.Revoke(tokenMeta.UserID, keyID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "revoked", Message: "Success"})
+}
+
+// RevokeAllAPIKeys func for revoke every personal API key.
+// @Summary revoke all personal API keys
+// @Description Revoke every personal API key of the current user by rotating the account auth key.
+// @Tags User
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/api-keys [delete]
+func (uh *UserHandler) RevokeAllAPIKeys(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = uh.APIKeyUsecase.RevokeAll(tokenMeta.UserID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "revoked", Message: "Success"})
+}
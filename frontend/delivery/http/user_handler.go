@@ -2,36 +2,78 @@ package http
 
 import (
 	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
 	"github.com/cooljar/go-postgres-fiber/utils"
 	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"strings"
 )
 
 type UserHandler struct {
 	UserUsecase domain.UserUseCase
+	MfaUsecase  domain.MfaUsecase
+	APIKeyUsecase domain.APIKeyUsecase
+	OidcUsecase domain.OidcUsecase
+	SessionUsecase domain.SessionUsecase
+	OrganizationUsecase domain.OrganizationUsecase
 	Validate *validator.Validate
 }
 
 // NewUserHandler will initialize the /user resources endpoint
-func NewUserHandler(app *fiber.App, validator *validator.Validate, userUseCase domain.UserUseCase, rPublic, rPrivate fiber.Router) {
+func NewUserHandler(app *fiber.App, validator *validator.Validate, userUseCase domain.UserUseCase, mfaUseCase domain.MfaUsecase, apiKeyUseCase domain.APIKeyUsecase, oidcUseCase domain.OidcUsecase, sessionUseCase domain.SessionUsecase, organizationUseCase domain.OrganizationUsecase, rPublic, rPrivate fiber.Router, middL *middleware.GoMiddleware) {
 	handler := &UserHandler{
 		UserUsecase: userUseCase,
+		MfaUsecase:  mfaUseCase,
+		APIKeyUsecase: apiKeyUseCase,
+		OidcUsecase: oidcUseCase,
+		SessionUsecase: sessionUseCase,
+		OrganizationUsecase: organizationUseCase,
 		Validate: validator,
 	}
 
 	rUser := rPublic.Group("/user")
 	rUser.Post("/email-validation-secret", handler.RequestSecret)
 	rUser.Post("/signup", handler.Signup)
+	rUser.Get("/username-available", handler.UsernameAvailable)
 	rUser.Post("/login", handler.Login)
+	rUser.Post("/login/mfa", handler.LoginMfa)
+	rUser.Get("/oidc", handler.OidcProviders)
+	rUser.Get("/oidc/:provider/authorize", handler.OidcAuthorize)
+	rUser.Get("/oidc/:provider/callback", handler.OidcCallback)
 
-	rUserPrivate := rPrivate.Group("/user")
+	// account management is not available to API keys
+	rUserPrivate := rPrivate.Group("/user", middL.DenyAPIKey())
 	rUserPrivate.Get("/", handler.Profile)
+	rUserPrivate.Put("/", handler.UpdateProfile)
+	rUserPrivate.Post("/password", handler.ChangePassword)
+	rUserPrivate.Post("/email", handler.RequestEmailChange)
+	rUserPrivate.Post("/email/confirm", handler.ConfirmEmailChange)
+	rUserPrivate.Delete("/", handler.DeleteAccount)
+	rUserPrivate.Post("/mfa/enroll", handler.EnrollMfa)
+	rUserPrivate.Post("/mfa/confirm", handler.ConfirmMfa)
+	rUserPrivate.Post("/mfa/recovery-codes", handler.RegenerateRecoveryCodes)
+	rUserPrivate.Delete("/mfa", handler.DisableMfa)
+	rUserPrivate.Get("/api-keys", handler.FetchAPIKeys)
+	rUserPrivate.Post("/api-keys", handler.CreateAPIKey)
+	rUserPrivate.Delete("/api-keys", handler.RevokeAllAPIKeys)
+	rUserPrivate.Delete("/api-keys/:id", handler.RevokeAPIKey)
+	rUserPrivate.Get("/sessions", handler.FetchSessions)
+	rUserPrivate.Delete("/sessions/:id", handler.RevokeSession)
+
+	rOrganization := rPrivate.Group("/organizations", middL.DenyAPIKey())
+	rOrganization.Get("/", handler.FetchOrganizations)
+	rOrganization.Post("/", handler.CreateOrganization)
+	rOrganization.Post("/:id/switch", handler.SwitchOrganization)
+	rOrganization.Get("/:id/members", handler.FetchOrganizationMembers)
+	rOrganization.Put("/:id/members", handler.SetOrganizationMember)
+	rOrganization.Delete("/:id/members/:user_id", handler.RemoveOrganizationMember)
 }
 
 // RequestSecret func for send secret code to specified email address.
 // @Summary request email validation secret code
-// @Description Sending secret code to specified email address.
+// @Description Sending secret code to specified email address. Only available when registration is open.
 // @Tags User
 // @Accept mpfd
 // @Param email formData string true "Destination Email address"
@@ -39,6 +81,7 @@ func NewUserHandler(app *fiber.App, validator *validator.Validate, userUseCase d
 // @Success 200 {object} domain.JSONResult{data=domain.EmailValidation} "Description"
 // @Failure 422 {object} []domain.HTTPError
 // @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
 // @Failure 404 {object} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
 // @Router /v1/user/email-validation-secret [post]
@@ -64,13 +107,14 @@ func (uh *UserHandler) RequestSecret(c *fiber.Ctx) error {
 
 // Signup func for signup.
 // @Summary user signup
-// @Description Signup to get JWT token.
+// @Description Signup to get JWT token. An invite_token replaces the email_secret_code, it is required when registration is invite only.
 // @Tags User
 // @Accept json
 // @Produce json
 // @Param login form body domain.SignupForm true "Fill form"
 // @success 200 {object} domain.JSONResult{data=string} "Registration Success"
 // @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
 // @Failure 404 {object} domain.HTTPError
 // @Failure 422 {array} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
@@ -91,7 +135,7 @@ func (uh *UserHandler) Signup(c *fiber.Ctx) error {
 		return domain.NewHttpError(c, err)
 	}
 
-	err = uh.UserUsecase.Signup(signupForm)
+	err = uh.UserUsecase.Signup(signupForm, auditContext(c, uuid.Nil))
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
@@ -101,6 +145,32 @@ func (uh *UserHandler) Signup(c *fiber.Ctx) error {
 	return c.JSON(signupResponse)
 }
 
+// UsernameAvailable func for check whether a username can be registered.
+// @Summary check username availability
+// @Description Live feedback for the signup form: the username is checked against the username rules, reserved names and lookalikes of existing usernames.
+// @Tags User
+// @Produce json
+// @Param u query string true "Username"
+// @Success 200 {object} domain.JSONResult{data=domain.UsernameAvailability,message=string} "Description"
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/user/username-available [get]
+func (uh *UserHandler) UsernameAvailable(c *fiber.Ctx) error {
+	username := strings.ToLower(strings.TrimSpace(c.Query("u")))
+
+	err := uh.Validate.Var(username, "required,alphanumunicode")
+	if err != nil {
+		availability := domain.UsernameAvailability{Username: username, Reason: "username may only contain letters and digits"}
+		return c.JSON(domain.JSONResult{Data: availability, Message: "Success"})
+	}
+
+	availability, err := uh.UserUsecase.UsernameAvailable(username)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: availability, Message: "Success"})
+}
+
 // Login func for login.
 // @Summary user login
 // @Description Login to get JWT token.
@@ -110,10 +180,12 @@ func (uh *UserHandler) Signup(c *fiber.Ctx) error {
 // @Param password formData string true "Password"
 // @Param captcha formData string true "Captcha"
 // @Produce json
-// @Success 200 {object} domain.JSONResult{data=string} "Login Success, JWT Token provided"
+// @Success 200 {object} domain.JSONResult{data=string} "Login Success, JWT Token provided. When two-factor authentication is enabled data is a domain.MfaChallenge"
 // @Failure 400 {object} domain.HTTPError
 // @Failure 422 {object} []domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
 // @Failure 404 {object} domain.HTTPError
+// @Failure 429 {object} domain.HTTPError
 // @Failure 500 {object} domain.HTTPError
 // @Router /v1/user/login [post]
 func (uh *UserHandler) Login(c *fiber.Ctx) error {
@@ -121,6 +193,7 @@ func (uh *UserHandler) Login(c *fiber.Ctx) error {
 	loginForm.Email = c.FormValue("email")
 	loginForm.Password = c.FormValue("password")
 	loginForm.Captcha = c.FormValue("captcha")
+	loginForm.IP = c.IP()
 
 	// Validate form input
 	err := uh.Validate.Struct(loginForm)
@@ -133,11 +206,50 @@ func (uh *UserHandler) Login(c *fiber.Ctx) error {
 		return domain.NewHttpError(c, err)
 	}
 
+	return uh.loginResponse(c, user)
+}
+
+// loginResponse returns the access token of an authenticated user, or a MFA challenge
+// when the account has two-factor authentication enabled.
+func (uh *UserHandler) loginResponse(c *fiber.Ctx, user domain.User) error {
+	// akun dengan 2FA harus menukar challenge token dengan kode TOTP di /user/login/mfa
+	mfaEnabled, err := uh.MfaUsecase.IsEnabled(user.ID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+	if mfaEnabled {
+		challengeToken, expiresAt, err := utils.GenerateMfaChallengeToken(user.ID)
+		if err != nil {
+			return domain.NewHttpError(c, err)
+		}
+
+		return c.JSON(domain.JSONResult{
+			Message: "MFA code required",
+			Data:    domain.MfaChallenge{MfaRequired: true, ChallengeToken: challengeToken, ExpiresAt: expiresAt},
+		})
+	}
+
+	return uh.issueAccessToken(c, user)
+}
+
+// issueAccessToken starts a session for the device of the request and returns its access token.
+func (uh *UserHandler) issueAccessToken(c *fiber.Ctx, user domain.User) error {
+	session, err := uh.SessionUsecase.Start(user.ID, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// token baru selalu dimulai di organisasi pertama user, pindah lewat /organizations/:id/switch
+	user.Organization, err = uh.OrganizationUsecase.DefaultMembership(user.ID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
 	var loginResponse domain.JSONResult
 	loginResponse.Message = "Login Success, JWT Token provided"
 
 	// Generate a new Access token.
-	loginResponse.Data, err = utils.GenerateNewAccessToken(&user)
+	loginResponse.Data, err = utils.GenerateNewAccessToken(&user, &session)
 	if err != nil {
 		return domain.NewHttpError(c, err)
 	}
@@ -162,3 +274,204 @@ func (uh *UserHandler) Profile(c *fiber.Ctx) error {
 
 	return c.JSON(claims)
 }
+
+// UpdateProfile func for update current user profile.
+// @Summary update profile
+// @Description Update full name and username of the current user.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param profile body domain.UpdateProfileForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=domain.User,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user [put]
+func (uh *UserHandler) UpdateProfile(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	profileForm := new(domain.UpdateProfileForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(profileForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(profileForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	user, err := uh.UserUsecase.UpdateProfile(tokenMeta.UserID, profileForm, auditContext(c, tokenMeta.UserID))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: user, Message: "Success"})
+}
+
+// ChangePassword func for change current user password.
+// @Summary change password
+// @Description Change password of the current user, current password is required. Every other session of the user is signed out.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param password body domain.ChangePasswordForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/password [post]
+func (uh *UserHandler) ChangePassword(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	passwordForm := new(domain.ChangePasswordForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(passwordForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(passwordForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = uh.UserUsecase.ChangePassword(tokenMeta.UserID, tokenMeta.SessionID, passwordForm, auditContext(c, tokenMeta.UserID))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "Password changed", Message: "Success"})
+}
+
+// RequestEmailChange func for request email address change.
+// @Summary request email change
+// @Description Sending confirmation secret code to the new email address.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param email body domain.ChangeEmailForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/email [post]
+func (uh *UserHandler) RequestEmailChange(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	emailForm := new(domain.ChangeEmailForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(emailForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(emailForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = uh.UserUsecase.RequestEmailChange(tokenMeta.UserID, emailForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "Confirmation code sent to the new email address", Message: "Success"})
+}
+
+// ConfirmEmailChange func for confirm email address change.
+// @Summary confirm email change
+// @Description Confirm new email address using the secret code, the old address will be notified.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param confirmation body domain.ConfirmEmailChangeForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=domain.User,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/email/confirm [post]
+func (uh *UserHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	confirmForm := new(domain.ConfirmEmailChangeForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(confirmForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(confirmForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	user, err := uh.UserUsecase.ConfirmEmailChange(tokenMeta.UserID, confirmForm, auditContext(c, tokenMeta.UserID))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: user, Message: "Success"})
+}
+
+// DeleteAccount func for delete current user account.
+// @Summary delete account
+// @Description Delete the current user account, password confirmation is required. Personal data is scrubbed after a grace period.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param confirmation body domain.DeleteAccountForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user [delete]
+func (uh *UserHandler) DeleteAccount(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	deleteForm := new(domain.DeleteAccountForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(deleteForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(deleteForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = uh.UserUsecase.DeleteAccount(tokenMeta.UserID, deleteForm, auditContext(c, tokenMeta.UserID))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "deleted", Message: "Success"})
+}
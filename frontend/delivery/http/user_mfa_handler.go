@@ -0,0 +1,192 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+)
+
+// LoginMfa func for second step of login with two-factor authentication.
+// @Summary user login second step
+// @Description Exchange the challenge token returned by login and a TOTP or recovery code for a JWT token.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param login body domain.MfaLoginForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=string} "Login Success, JWT Token provided"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 429 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/user/login/mfa [post]
+func (uh *UserHandler) LoginMfa(c *fiber.Ctx) error {
+	mfaForm := new(domain.MfaLoginForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(mfaForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err := uh.Validate.Struct(mfaForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	user, err := uh.MfaUsecase.CompleteLogin(mfaForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return uh.issueAccessToken(c, user)
+}
+
+// EnrollMfa func for start two-factor authentication enrollment.
+// @Summary enroll two-factor authentication
+// @Description Generate a TOTP secret, otpauth:// URI and QR code to configure an authenticator app. Editor and admin accounts only.
+// @Tags User
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=domain.MfaEnrollment,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/mfa/enroll [post]
+func (uh *UserHandler) EnrollMfa(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	enrollment, err := uh.MfaUsecase.Enroll(tokenMeta.UserID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: enrollment, Message: "Success"})
+}
+
+// ConfirmMfa func for confirm two-factor authentication enrollment.
+// @Summary confirm two-factor authentication
+// @Description Enable two-factor authentication with a code from the authenticator app. Returns single-use recovery codes, shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param code body domain.MfaCodeForm true "TOTP code"
+// @Success 200 {object} domain.JSONResult{data=[]string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 429 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/mfa/confirm [post]
+func (uh *UserHandler) ConfirmMfa(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	codeForm := new(domain.MfaCodeForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(codeForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(codeForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	recoveryCodes, err := uh.MfaUsecase.Confirm(tokenMeta.UserID, codeForm.Code)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: recoveryCodes, Message: "Two-factor authentication enabled, store the recovery codes in a safe place"})
+}
+
+// RegenerateRecoveryCodes func for replace two-factor authentication recovery codes.
+// @Summary regenerate recovery codes
+// @Description Replace all recovery codes, a TOTP code is required. The new codes are shown only once.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param code body domain.MfaCodeForm true "TOTP code"
+// @Success 200 {object} domain.JSONResult{data=[]string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 429 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/mfa/recovery-codes [post]
+func (uh *UserHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	codeForm := new(domain.MfaCodeForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(codeForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(codeForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	recoveryCodes, err := uh.MfaUsecase.RegenerateRecoveryCodes(tokenMeta.UserID, codeForm.Code)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: recoveryCodes, Message: "Success"})
+}
+
+// DisableMfa func for disable two-factor authentication.
+// @Summary disable two-factor authentication
+// @Description Disable two-factor authentication, password and a TOTP or recovery code are required.
+// @Tags User
+// @Accept json
+// @Produce json
+// @Param confirmation body domain.DisableMfaForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 429 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/mfa [delete]
+func (uh *UserHandler) DisableMfa(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	disableForm := new(domain.DisableMfaForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(disableForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(disableForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	err = uh.MfaUsecase.Disable(tokenMeta.UserID, disableForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "disabled", Message: "Success"})
+}
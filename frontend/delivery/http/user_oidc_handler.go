@@ -0,0 +1,64 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// OidcProviders func for list the configured identity providers.
+// @Summary list identity providers
+// @Description List the names of the OpenID Connect providers available for login.
+// @Tags User
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=[]string,message=string} "Description"
+// @Router /v1/user/oidc [get]
+func (uh *UserHandler) OidcProviders(c *fiber.Ctx) error {
+	return c.JSON(domain.JSONResult{Data: uh.OidcUsecase.Providers(), Message: "Success"})
+}
+
+// OidcAuthorize func for start login with an identity provider.
+// @Summary start login with identity provider
+// @Description Start an authorization code flow with PKCE. Send the browser to authorization_url, the provider redirects it back to the callback endpoint.
+// @Tags User
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Success 200 {object} domain.JSONResult{data=domain.OidcAuthorization,message=string} "Description"
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/user/oidc/{provider}/authorize [get]
+func (uh *UserHandler) OidcAuthorize(c *fiber.Ctx) error {
+	auth, err := uh.OidcUsecase.Authorize(c.Params("provider"))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: auth, Message: "Success"})
+}
+
+// OidcCallback func for complete login with an identity provider.
+// @Summary complete login with identity provider
+// @Description Exchange the authorization code for an ID token, link the identity to the account with the same verified email (or a new account) and issue a JWT token.
+// @Tags User
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State returned by the authorize endpoint"
+// @Success 200 {object} domain.JSONResult{data=string} "Login Success, JWT Token provided. When two-factor authentication is enabled data is a domain.MfaChallenge"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 422 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Router /v1/user/oidc/{provider}/callback [get]
+func (uh *UserHandler) OidcCallback(c *fiber.Ctx) error {
+	if errCode := c.Query("error"); errCode != "" {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "code", Message: "login was not completed at the provider: " + errCode})
+	}
+
+	user, err := uh.OidcUsecase.Callback(c.Params("provider"), c.Query("code"), c.Query("state"), auditContext(c, uuid.Nil))
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return uh.loginResponse(c, user)
+}
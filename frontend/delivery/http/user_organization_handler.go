@@ -0,0 +1,235 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// FetchOrganizations func for list the organizations of the current user.
+// @Summary list organizations
+// @Description List the organizations the current user belongs to, with the role granted within each.
+// @Tags Organization
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=[]domain.Membership,message=string} "Description"
+// @Failure 403 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/organizations [get]
+func (uh *UserHandler) FetchOrganizations(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	memberships, err := uh.OrganizationUsecase.Fetch(tokenMeta.UserID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: memberships, Message: "Success"})
+}
+
+// CreateOrganization func for create an organization.
+// @Summary create organization
+// @Description Create an organization with its own book catalog, the current user becomes its admin.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param organization body domain.OrganizationForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=domain.Membership,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/organizations [post]
+func (uh *UserHandler) CreateOrganization(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organizationForm := new(domain.OrganizationForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(organizationForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(organizationForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	membership, err := uh.OrganizationUsecase.Create(actor, organizationForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: membership, Message: "Success"})
+}
+
+// SwitchOrganization func for switch the active organization.
+// @Summary switch organization
+// @Description Get an access token of the same session scoped to another organization the current user belongs to.
+// @Tags Organization
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/organizations/{id}/switch [post]
+func (uh *UserHandler) SwitchOrganization(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organizationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid organization id"})
+	}
+
+	membership, err := uh.OrganizationUsecase.GetMembership(tokenMeta.UserID, organizationID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	user, err := uh.UserUsecase.GetByID(tokenMeta.UserID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+	user.Organization = &membership
+
+	// token baru tetap milik session yang sama dan kedaluwarsa bersamaan
+	session := domain.Session{ID: tokenMeta.SessionID, ExpiresAt: int(tokenMeta.Expires)}
+	token, err := utils.GenerateNewAccessToken(&user, &session)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: token, Message: "Switched to " + membership.Name})
+}
+
+// FetchOrganizationMembers func for list the members of an organization.
+// @Summary list organization members
+// @Description List the members of an organization the current user belongs to.
+// @Tags Organization
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Success 200 {object} domain.JSONResult{data=[]domain.OrganizationMember,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/organizations/{id}/members [get]
+func (uh *UserHandler) FetchOrganizationMembers(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organizationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid organization id"})
+	}
+
+	members, err := uh.OrganizationUsecase.FetchMembers(actor, organizationID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: members, Message: "Success"})
+}
+
+// SetOrganizationMember func for add a member or change the role of a member.
+// @Summary set organization member
+// @Description Add a registered user to the organization, or change the role of a member. Requires the role:manage permission within the organization, a member whose role changes is signed out. The last member granted role:manage keeps a role granting it.
+// @Tags Organization
+// @Accept json
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param member body domain.MembershipForm true "Fill form"
+// @Success 200 {object} domain.JSONResult{data=domain.OrganizationMember,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 409 {object} domain.HTTPError
+// @Failure 422 {array} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/organizations/{id}/members [put]
+func (uh *UserHandler) SetOrganizationMember(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organizationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid organization id"})
+	}
+
+	memberForm := new(domain.MembershipForm)
+
+	//  Parse body into application struct
+	if err := c.BodyParser(memberForm); err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	// Validate form input
+	err = uh.Validate.Struct(memberForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	member, err := uh.OrganizationUsecase.SetMember(actor, organizationID, memberForm)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: member, Message: "Success"})
+}
+
+// RemoveOrganizationMember func for remove a member from an organization.
+// @Summary remove organization member
+// @Description Remove a member from the organization, requires the role:manage permission within the organization unless leaving it yourself. The member is signed out, the last member granted role:manage can not be removed.
+// @Tags Organization
+// @Produce json
+// @Param id path string true "Organization ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 403 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 409 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/organizations/{id}/members/{user_id} [delete]
+func (uh *UserHandler) RemoveOrganizationMember(c *fiber.Ctx) error {
+	actor, err := currentActor(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	organizationID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid organization id"})
+	}
+
+	userID, err := uuid.Parse(c.Params("user_id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "user_id", Message: "invalid user id"})
+	}
+
+	err = uh.OrganizationUsecase.RemoveMember(actor, organizationID, userID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "removed", Message: "Success"})
+}
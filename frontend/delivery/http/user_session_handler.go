@@ -0,0 +1,63 @@
+package http
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// FetchSessions func for list active sessions.
+// @Summary list active sessions
+// @Description List the devices the current user is logged in from. The session of the request is marked as current.
+// @Tags User
+// @Produce json
+// @Success 200 {object} domain.JSONResult{data=[]domain.Session,message=string} "Description"
+// @Failure 401 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/sessions [get]
+func (uh *UserHandler) FetchSessions(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	sessions, err := uh.SessionUsecase.Fetch(tokenMeta.UserID, tokenMeta.SessionID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: sessions, Message: "Success"})
+}
+
+// RevokeSession func for revoke a session.
+// @Summary revoke session
+// @Description Log out a device, access tokens of the session are rejected from now on.
+// @Tags User
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} domain.JSONResult{data=string,message=string} "Description"
+// @Failure 400 {object} domain.HTTPError
+// @Failure 404 {object} domain.HTTPError
+// @Failure 500 {object} domain.HTTPError
+// @Security ApiKeyAuth
+// @Router /v1/auth/user/sessions/{id} [delete]
+func (uh *UserHandler) RevokeSession(c *fiber.Ctx) error {
+	tokenMeta, err := utils.ExtractTokenMetadata(c)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	sessionID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return domain.NewHttpError(c, domain.DataValidationError{Field: "id", Message: "invalid session id"})
+	}
+
+	err = uh.SessionUsecase.Revoke(tokenMeta.UserID, sessionID)
+	if err != nil {
+		return domain.NewHttpError(c, err)
+	}
+
+	return c.JSON(domain.JSONResult{Data: "revoked", Message: "Success"})
+}
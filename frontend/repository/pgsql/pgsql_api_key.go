@@ -0,0 +1,107 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+// apiKeyTouchInterval minimum seconds between two updates of last_used_at of the same key
+const apiKeyTouchInterval = 60
+
+const apiKeyColumns = `id, name, prefix, scopes, expires_at, last_used_at, last_used_ip, created_at, revoked_at, organization_id`
+
+type pgsqlAPIKeyRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlAPIKeyRepository will create an object that represent the api key Repository interface
+func NewPgsqlAPIKeyRepository(conn *pgxpool.Pool) domain.APIKeyRepository {
+	return &pgsqlAPIKeyRepository{Conn: conn}
+}
+
+func (ar *pgsqlAPIKeyRepository) Create(userID uuid.UUID, k *domain.APIKey, keyHash string) (err error) {
+	qStr := `INSERT INTO api_keys (user_id, name, prefix, key_hash, scopes, expires_at, created_at, organization_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8) returning id`
+	return ar.Conn.QueryRow(context.Background(), qStr, userID, k.Name, k.Prefix, keyHash, k.Scopes, k.ExpiresAt, k.CreatedAt, k.OrganizationID).Scan(&k.ID)
+}
+
+func (ar *pgsqlAPIKeyRepository) Fetch(userID uuid.UUID) (keys []domain.APIKey, err error) {
+	qStr := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE user_id = $1 ORDER BY created_at DESC`
+	rows, err := ar.Conn.Query(context.Background(), qStr, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var k domain.APIKey
+		k, err = scanAPIKey(rows)
+		if err != nil {
+			return
+		}
+		keys = append(keys, k)
+	}
+
+	return keys, rows.Err()
+}
+
+func (ar *pgsqlAPIKeyRepository) GetByPrefix(prefix string) (k domain.APIKey, userID uuid.UUID, keyHash string, err error) {
+	qStr := `SELECT ` + apiKeyColumns + `, user_id, key_hash FROM api_keys WHERE prefix = $1`
+	err = ar.Conn.QueryRow(context.Background(), qStr, prefix).Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.ExpiresAt, &k.LastUsedAt, &k.LastUsedIP, &k.CreatedAt, &k.RevokedAt, &k.OrganizationID, &userID, &keyHash)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "api key not found"}
+	}
+
+	return
+}
+
+func (ar *pgsqlAPIKeyRepository) Revoke(userID, keyID uuid.UUID) (rowsAffected int64, err error) {
+	qCmd := `UPDATE api_keys SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at = 0`
+	commandTag, err := ar.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), keyID, userID)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (ar *pgsqlAPIKeyRepository) RevokeAll(userID uuid.UUID, newAuthKey string) (err error) {
+	tx, err := ar.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	ts := time.Now().Unix()
+
+	_, err = tx.Exec(context.Background(), `UPDATE api_keys SET revoked_at = $1 WHERE user_id = $2 AND revoked_at = 0`, ts, userID)
+	if err != nil {
+		return
+	}
+
+	// hash yang tersimpan dibuat dengan auth_key lama, sehingga tidak ada key lama yang bisa cocok lagi
+	_, err = tx.Exec(context.Background(), `UPDATE "user" SET auth_key = $1, updated_at = $2 WHERE id = $3`, newAuthKey, ts, userID)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (ar *pgsqlAPIKeyRepository) Touch(keyID uuid.UUID, ip string) (err error) {
+	ts := time.Now().Unix()
+	qCmd := `UPDATE api_keys SET last_used_at = $1, last_used_ip = $2 WHERE id = $3 AND (last_used_at < $4 OR last_used_ip <> $2)`
+	_, err = ar.Conn.Exec(context.Background(), qCmd, ts, ip, keyID, ts-apiKeyTouchInterval)
+
+	return
+}
+
+// scanAPIKey scans a row selected with apiKeyColumns.
+func scanAPIKey(row pgx.Row) (k domain.APIKey, err error) {
+	err = row.Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.ExpiresAt, &k.LastUsedAt, &k.LastUsedIP, &k.CreatedAt, &k.RevokedAt, &k.OrganizationID)
+	return
+}
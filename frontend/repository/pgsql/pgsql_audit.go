@@ -0,0 +1,108 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"time"
+)
+
+const auditColumns = `id, actor_id, action, resource_type, resource_id, before, after, ip, request_id, created_at`
+
+type pgsqlAuditRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlAuditRepository will create an object that represent the audit Repository interface
+func NewPgsqlAuditRepository(conn *pgxpool.Pool) domain.AuditRepository {
+	return &pgsqlAuditRepository{Conn: conn}
+}
+
+func (ar *pgsqlAuditRepository) Fetch(filter domain.AuditFilter, perPage, page int) (entries []domain.AuditEntry, totalCount, pageCount, currentPage int, err error) {
+	where, args := auditFilterCondition(filter)
+
+	err = ar.Conn.QueryRow(context.Background(), "SELECT COUNT(*) FROM audit_log WHERE "+where, args...).Scan(&totalCount)
+	if err != nil {
+		return
+	}
+
+	pageCount = (totalCount + perPage - 1) / perPage
+	if page > pageCount {
+		page = pageCount
+	}
+	if page < 1 {
+		page = 1
+	}
+
+	offset := perPage * (page - 1)
+	qStr := `SELECT ` + auditColumns + ` FROM audit_log WHERE ` + where +
+		` ORDER BY created_at DESC, id DESC LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+	rows, err := ar.Conn.Query(context.Background(), qStr, append(args, perPage, offset)...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e domain.AuditEntry
+		err = rows.Scan(&e.ID, &e.ActorID, &e.Action, &e.ResourceType, &e.ResourceID, &e.Before, &e.After, &e.IP, &e.RequestID, &e.CreatedAt)
+		if err != nil {
+			return
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, totalCount, pageCount, page, rows.Err()
+}
+
+// auditFilterCondition builds the WHERE condition and its arguments for the filter.
+func auditFilterCondition(filter domain.AuditFilter) (where string, args []interface{}) {
+	where = "TRUE"
+
+	if filter.ActorID != uuid.Nil {
+		args = append(args, filter.ActorID)
+		where += " AND actor_id = $" + strconv.Itoa(len(args))
+	}
+	if filter.ResourceType != "" {
+		args = append(args, filter.ResourceType)
+		where += " AND resource_type = $" + strconv.Itoa(len(args))
+	}
+	if filter.ResourceID != "" {
+		args = append(args, filter.ResourceID)
+		where += " AND resource_id = $" + strconv.Itoa(len(args))
+	}
+	if filter.From > 0 {
+		args = append(args, filter.From)
+		where += " AND created_at >= $" + strconv.Itoa(len(args))
+	}
+	if filter.To > 0 {
+		args = append(args, filter.To)
+		where += " AND created_at <= $" + strconv.Itoa(len(args))
+	}
+
+	return
+}
+
+// recordAudit writes an audit entry in the transaction of the change, so the change and its record
+// are committed or rolled back together. before and after are reduced to the fields that differ.
+func recordAudit(tx pgx.Tx, ac domain.AuditContext, action, resourceType, resourceID string, before, after interface{}) (err error) {
+	b, a, err := utils.AuditDiff(before, after)
+	if err != nil {
+		return
+	}
+
+	var actorID *uuid.UUID
+	if ac.ActorID != uuid.Nil {
+		actorID = &ac.ActorID
+	}
+
+	// json.RawMessage akan di-encode sebagai JSON null, []byte nil menjadi SQL NULL
+	qStr := `INSERT INTO audit_log (actor_id, action, resource_type, resource_id, before, after, ip, request_id, created_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)`
+	_, err = tx.Exec(context.Background(), qStr, actorID, action, resourceType, resourceID, []byte(b), []byte(a), ac.IP, ac.RequestID, time.Now().Unix())
+
+	return
+}
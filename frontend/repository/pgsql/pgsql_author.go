@@ -0,0 +1,206 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const authorColumns = `id, name, bio, created_at, updated_at`
+
+type pgsqlAuthorRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlAuthorRepository will create an object that represent the author Repository interface
+func NewPgsqlAuthorRepository(conn *pgxpool.Pool) domain.AuthorRepository {
+	return &pgsqlAuthorRepository{Conn: conn}
+}
+
+func (ar *pgsqlAuthorRepository) Create(organizationID uuid.UUID, af *domain.AuthorForm, ac domain.AuditContext) (a domain.Author, err error) {
+	err = inOrganization(ar.Conn, organizationID, func(tx pgx.Tx) error {
+		nameKey, err := authorNameKeyAvailable(tx, organizationID, af.Name, 0)
+		if err != nil {
+			return err
+		}
+
+		ts := time.Now().Unix()
+		qStr := `INSERT INTO authors (organization_id, name, name_key, bio, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$5) returning ` + authorColumns
+		a, err = scanAuthor(tx.QueryRow(context.Background(), qStr, organizationID, strings.TrimSpace(af.Name), nameKey, af.Bio, ts))
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditAuthorCreate, domain.ConstAuditResourceAuthor, strconv.Itoa(a.ID), nil, a)
+	})
+
+	return
+}
+
+func (ar *pgsqlAuthorRepository) Fetch(organizationID uuid.UUID, name string, perPage, page int) (authors []domain.Author, totalCount, pageCount, currentPage int, err error) {
+	where := "organization_id = $1"
+	args := []interface{}{organizationID}
+	if name != "" {
+		args = append(args, "%"+utils.AuthorNameKey(name)+"%")
+		where += " AND name_key LIKE $2"
+	}
+
+	err = inOrganization(ar.Conn, organizationID, func(tx pgx.Tx) error {
+		err := tx.QueryRow(context.Background(), "SELECT COUNT(*) FROM authors WHERE "+where, args...).Scan(&totalCount)
+		if err != nil {
+			return err
+		}
+
+		pageCount = (totalCount + perPage - 1) / perPage
+		if page > pageCount {
+			page = pageCount
+		}
+		if page < 1 {
+			page = 1
+		}
+
+		offset := perPage * (page - 1)
+		qStr := `SELECT ` + authorColumns + ` FROM authors WHERE ` + where +
+			` ORDER BY name LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+		rows, err := tx.Query(context.Background(), qStr, append(args, perPage, offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var a domain.Author
+			a, err = scanAuthor(rows)
+			if err != nil {
+				return err
+			}
+			authors = append(authors, a)
+		}
+
+		return rows.Err()
+	})
+
+	return authors, totalCount, pageCount, page, err
+}
+
+func (ar *pgsqlAuthorRepository) GetByID(organizationID uuid.UUID, id int) (a domain.Author, err error) {
+	err = inOrganization(ar.Conn, organizationID, func(tx pgx.Tx) error {
+		a, err = getAuthorByID(tx, organizationID, id)
+		return err
+	})
+
+	return
+}
+
+func (ar *pgsqlAuthorRepository) Update(organizationID uuid.UUID, id int, af *domain.AuthorForm, ac domain.AuditContext) (a domain.Author, err error) {
+	err = inOrganization(ar.Conn, organizationID, func(tx pgx.Tx) error {
+		before, err := getAuthorByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		nameKey, err := authorNameKeyAvailable(tx, organizationID, af.Name, id)
+		if err != nil {
+			return err
+		}
+
+		qStr := `UPDATE authors SET name = $1, name_key = $2, bio = $3, updated_at = $4 WHERE id = $5 AND organization_id = $6 returning ` + authorColumns
+		a, err = scanAuthor(tx.QueryRow(context.Background(), qStr, strings.TrimSpace(af.Name), nameKey, af.Bio, time.Now().Unix(), id, organizationID))
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditAuthorUpdate, domain.ConstAuditResourceAuthor, strconv.Itoa(id), before, a)
+	})
+
+	return
+}
+
+func (ar *pgsqlAuthorRepository) Delete(organizationID uuid.UUID, id int, ac domain.AuditContext) (err error) {
+	err = inOrganization(ar.Conn, organizationID, func(tx pgx.Tx) error {
+		var credited bool
+
+		before, err := getAuthorByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		// byline buku tetap ada, tapi buku tidak boleh kehilangan penulisnya tanpa disadari
+		err = tx.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM book_authors WHERE author_id = $1)`, id).Scan(&credited)
+		if err != nil {
+			return err
+		}
+		if credited {
+			return domain.DataValidationError{Field: "id", Message: "author is still credited on a book"}
+		}
+
+		_, err = tx.Exec(context.Background(), `DELETE FROM authors WHERE id = $1 AND organization_id = $2`, id, organizationID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditAuthorDelete, domain.ConstAuditResourceAuthor, strconv.Itoa(id), before, nil)
+	})
+
+	return
+}
+
+func getAuthorByID(tx pgx.Tx, organizationID uuid.UUID, id int) (domain.Author, error) {
+	a, err := scanAuthor(tx.QueryRow(context.Background(), `SELECT `+authorColumns+` FROM authors WHERE id = $1 AND organization_id = $2`, id, organizationID))
+	if err == pgx.ErrNoRows {
+		return a, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "author not found"}
+	}
+
+	return a, err
+}
+
+// authorNameKeyAvailable returns the name key of the name, or a DataValidationError when another author
+// of the organization has the same key.
+func authorNameKeyAvailable(tx pgx.Tx, organizationID uuid.UUID, name string, exceptID int) (nameKey string, err error) {
+	var exists bool
+
+	nameKey = utils.AuthorNameKey(name)
+	if nameKey == "" {
+		return "", domain.DataValidationError{Field: "name", Message: "name must contain letters or digits"}
+	}
+
+	qExist := `SELECT EXISTS(SELECT 1 FROM authors WHERE organization_id = $1 AND name_key = $2 AND id <> $3)`
+	err = tx.QueryRow(context.Background(), qExist, organizationID, nameKey, exceptID).Scan(&exists)
+	if err != nil {
+		return
+	}
+	if exists {
+		return "", domain.DataValidationError{Field: "name", Message: "author already exists"}
+	}
+
+	return
+}
+
+// findOrCreateAuthor returns the ID of the author with the name key of name, creating the author when unknown.
+func findOrCreateAuthor(tx pgx.Tx, organizationID uuid.UUID, name string) (id int, err error) {
+	nameKey := utils.AuthorNameKey(name)
+	if nameKey == "" {
+		return 0, domain.DataValidationError{Field: "author", Message: "author must contain letters or digits"}
+	}
+
+	// DO UPDATE tanpa perubahan agar RETURNING juga mengembalikan id penulis yang sudah ada
+	ts := time.Now().Unix()
+	qStr := `INSERT INTO authors (organization_id, name, name_key, created_at, updated_at) VALUES ($1,$2,$3,$4,$4)
+		ON CONFLICT ON CONSTRAINT authors_organization_id_name_key_key DO UPDATE SET name_key = EXCLUDED.name_key returning id`
+	err = tx.QueryRow(context.Background(), qStr, organizationID, strings.TrimSpace(name), nameKey, ts).Scan(&id)
+
+	return
+}
+
+// scanAuthor scans a row selected with authorColumns.
+func scanAuthor(row pgx.Row) (a domain.Author, err error) {
+	err = row.Scan(&a.ID, &a.Name, &a.Bio, &a.CreatedAt, &a.UpdatedAt)
+	return
+}
@@ -2,11 +2,38 @@ package pgsql
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"log"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// bookColumns columns selected by scanBook, books table is aliased as b and the owner as o
+const bookColumns = `b.id, b.title, b.author, b.summary, b.price, b.currency, b.isbn, b.created_at, b.updated_at, b.status, b.publish_at, b.published_at, b.rating_average, b.rating_count, b.cover, b.created_by, b.updated_by, o.username, b.organization_id`
+
+// bookFrom table expression used together with bookColumns
+const bookFrom = `books b LEFT JOIN "user" o ON o.id = b.created_by`
+
+// bookCoverRecord the cover of a book as stored in books.cover, unlike domain.BookCover it keeps the blob keys
+type bookCoverRecord struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails"`
+	Keys       []string          `json:"keys"`
+}
+
+// bookRevisionColumns columns selected by scanBookRevision
+const bookRevisionColumns = `book_id, revision, title, author, summary, price, currency, created_by, created_at, chapters, authors`
+
 type pgsqlBookRepository struct {
 	Conn *pgxpool.Pool
 }
@@ -16,135 +43,923 @@ func NewPgsqlBookRepository(conn *pgxpool.Pool) domain.BookRepository {
 	return &pgsqlBookRepository{Conn: conn}
 }
 
-func (m *pgsqlBookRepository) Create(b *domain.BookForm) (domain.Book, error) {
-	var book domain.Book
-	var id int
+func (m *pgsqlBookRepository) Create(organizationID uuid.UUID, b *domain.BookForm, ac domain.AuditContext) (book domain.Book, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		var id int
+
+		price, prices, err := b.PriceList()
+		if err != nil {
+			return err
+		}
+
+		credits, byline, err := resolveBookAuthors(tx, organizationID, b)
+		if err != nil {
+			return err
+		}
+
+		ts := time.Now().Unix()
+		qStr := `insert into books (title, summary, author, price, currency, created_at, updated_at, created_by, updated_by, organization_id) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$8,$9) returning id`
+		err = tx.QueryRow(context.Background(), qStr, b.Title, b.Summary, byline, price.Amount, price.Currency, ts, ts, ac.ActorID, organizationID).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		err = setBookPrices(tx, organizationID, id, price, prices)
+		if err != nil {
+			return err
+		}
+
+		err = setBookAuthors(tx, organizationID, id, credits)
+		if err != nil {
+			return err
+		}
+
+		err = setBookISBN(tx, organizationID, id, b.ISBN)
+		if err != nil {
+			return err
+		}
+
+		err = setBookTaxonomy(tx, organizationID, id, b)
+		if err != nil {
+			return err
+		}
+
+		book, err = getBookByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		err = saveBookRevision(tx, book)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditBookCreate, domain.ConstAuditResourceBook, strconv.Itoa(id), nil, book)
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) Fetch(organizationID uuid.UUID, filter domain.BookFilter, perPage, page int) (books []domain.Book, totalCount, pageCount, currentPage int, err error) {
+	where, args := bookFilterCondition(organizationID, filter)
+
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		err := tx.QueryRow(context.Background(), "SELECT COUNT(*) FROM books b WHERE "+where, args...).Scan(&totalCount)
+		if err != nil {
+			return err
+		}
+
+		pageCount = (totalCount + perPage - 1) / perPage
+		if page > pageCount {
+			page = pageCount
+		}
+		if page < 1 {
+			page = 1
+		}
+
+		offset := perPage * (page - 1)
+		qStr := `SELECT ` + bookColumns + ` FROM ` + bookFrom + ` WHERE ` + where +
+			` ORDER BY b.created_at DESC LIMIT $` + strconv.Itoa(len(args)+1) + ` OFFSET $` + strconv.Itoa(len(args)+2)
+		rows, err := tx.Query(context.Background(), qStr, append(args, perPage, offset)...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var b domain.Book
+			b, err = scanBook(rows)
+			if err != nil {
+				return err
+			}
+			books = append(books, b)
+		}
+		if err = rows.Err(); err != nil {
+			return err
+		}
+
+		return loadBookRelations(tx, books)
+	})
+
+	return books, totalCount, pageCount, page, err
+}
+
+func (m *pgsqlBookRepository) GetByID(organizationID uuid.UUID, bookId int) (b domain.Book, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		b, err = getBookByID(tx, organizationID, bookId)
+		return err
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) GetByISBN(organizationID uuid.UUID, isbn string) (b domain.Book, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		var id int
+
+		qStr := `SELECT id FROM books WHERE isbn=$1 AND organization_id=$2 LIMIT 1`
+		err := tx.QueryRow(context.Background(), qStr, isbn, organizationID).Scan(&id)
+		if err == pgx.ErrNoRows {
+			return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+		}
+		if err != nil {
+			return err
+		}
+
+		b, err = getBookByID(tx, organizationID, id)
+		return err
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) Update(organizationID uuid.UUID, bookId int, b *domain.BookForm, ac domain.AuditContext) (book domain.Book, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		before, err := getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
+
+		price, prices, err := b.PriceList()
+		if err != nil {
+			return err
+		}
+
+		// byline yang tidak berubah tanpa daftar penulis mempertahankan penulis yang sudah terhubung
+		credits, byline := []domain.BookAuthorForm(nil), before.Author
+		relink := len(b.Authors) > 0 || strings.TrimSpace(b.Author) != before.Author
+		if b.KeepAuthors {
+			relink, byline = false, strings.TrimSpace(b.Author)
+		}
+		if relink {
+			credits, byline, err = resolveBookAuthors(tx, organizationID, b)
+			if err != nil {
+				return err
+			}
+		}
+
+		qCmd := `UPDATE books SET title=$1, author=$2, summary=$3, price=$4, currency=$5, updated_at=$6, updated_by=$7 WHERE id=$8 AND organization_id=$9`
+		res, err := tx.Exec(context.Background(), qCmd, b.Title, byline, b.Summary, price.Amount, price.Currency, time.Now().Unix(), ac.ActorID, bookId, organizationID)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+		}
+
+		err = setBookPrices(tx, organizationID, bookId, price, prices)
+		if err != nil {
+			return err
+		}
+
+		if relink {
+			err = setBookAuthors(tx, organizationID, bookId, credits)
+			if err != nil {
+				return err
+			}
+		}
+
+		err = setBookISBN(tx, organizationID, bookId, b.ISBN)
+		if err != nil {
+			return err
+		}
+
+		err = setBookTaxonomy(tx, organizationID, bookId, b)
+		if err != nil {
+			return err
+		}
+
+		chaptersChanged, err := setBookChapters(tx, organizationID, bookId, b.Chapters, ac)
+		if err != nil {
+			return err
+		}
+
+		book, err = getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
 
-	ts := time.Now().Unix()
-	qStr := `insert into books (title, content, author, price, rating, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7) returning id`
-	err := m.Conn.QueryRow(context.Background(), qStr, b.Title, b.Content, b.Author, b.Price, b.Rating, ts, ts).Scan(&id)
+		// updated_at/updated_by saja yang berubah, tidak perlu revisi baru
+		if book.Title != before.Title || book.Author != before.Author || book.Summary != before.Summary || book.Price != before.Price ||
+			chaptersChanged || !equalBookCredits(before.Authors, book.Authors) {
+			err = saveBookRevision(tx, book)
+			if err != nil {
+				return err
+			}
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditBookUpdate, domain.ConstAuditResourceBook, strconv.Itoa(bookId), before, book)
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) Delete(organizationID uuid.UUID, id int, ac domain.AuditContext) (rowsAffected int64, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		before, err := getBookByID(tx, organizationID, id)
+		if _, ok := err.(domain.NotFoundError); ok {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		res, err := tx.Exec(context.Background(), `DELETE FROM books WHERE id=$1 AND organization_id=$2`, id, organizationID)
+		if err != nil {
+			return err
+		}
+
+		rowsAffected = res.RowsAffected()
+		if rowsAffected == 0 {
+			return nil
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditBookDelete, domain.ConstAuditResourceBook, strconv.Itoa(id), before, nil)
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) SetStatus(organizationID uuid.UUID, bookId int, from, to string, publishAt *int, ac domain.AuditContext) (book domain.Book, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		before, err := getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
+
+		ts := time.Now().Unix()
+		qCmd := `UPDATE books SET status=$1, publish_at=$2, published_at=CASE WHEN $1 = $3 AND status <> $1 THEN $4 ELSE published_at END,
+			updated_at=$4, updated_by=$5 WHERE id=$6 AND organization_id=$7 AND status=$8`
+		res, err := tx.Exec(context.Background(), qCmd, to, publishAt, domain.ConstBookStatusPublished, ts, ac.ActorID, bookId, organizationID, from)
+		if err != nil {
+			return err
+		}
+		if res.RowsAffected() < 1 {
+			return domain.ConflictError{Field: "status", Message: "the status of the book has changed, reload it and try again"}
+		}
+
+		book, err = getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditBookSetStatus, domain.ConstAuditResourceBook, strconv.Itoa(bookId), before, book)
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) PublishDue(now int) (published int64, err error) {
+	var organizationIDs []uuid.UUID
+
+	rows, err := m.Conn.Query(context.Background(), `SELECT id FROM organizations`)
 	if err != nil {
-		return book, err
+		return
+	}
+	for rows.Next() {
+		var id uuid.UUID
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return
+		}
+		organizationIDs = append(organizationIDs, id)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
 	}
 
-	book.ID = id
-	book.Title = b.Title
-	book.Author = b.Author
-	book.Content = b.Content
-	book.Price = b.Price
-	book.CreatedAt = int(ts)
-	book.UpdatedAt = int(ts)
-	book.Rating = b.Rating
+	// row level security hanya memperlihatkan buku satu organisasi per transaksi,
+	// kegagalan satu organisasi tidak menahan publikasi organisasi lainnya
+	failed := 0
+	for _, organizationID := range organizationIDs {
+		var ids []int
+		err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+			ids = nil
+
+			qStr := `SELECT id FROM books WHERE organization_id=$1 AND publish_at <= $2 AND status IN ($3, $4) FOR UPDATE SKIP LOCKED`
+			rows, err := tx.Query(context.Background(), qStr, organizationID, now, domain.ConstBookStatusDraft, domain.ConstBookStatusInReview)
+			if err != nil {
+				return err
+			}
+			for rows.Next() {
+				var id int
+				if err = rows.Scan(&id); err != nil {
+					rows.Close()
+					return err
+				}
+				ids = append(ids, id)
+			}
+			rows.Close()
+			if err = rows.Err(); err != nil {
+				return err
+			}
+
+			for _, id := range ids {
+				before, err := getBookByID(tx, organizationID, id)
+				if err != nil {
+					return err
+				}
+
+				qCmd := `UPDATE books SET status=$1, published_at=publish_at, publish_at=NULL, updated_at=$2 WHERE id=$3 AND organization_id=$4`
+				_, err = tx.Exec(context.Background(), qCmd, domain.ConstBookStatusPublished, now, id, organizationID)
+				if err != nil {
+					return err
+				}
+
+				after, err := getBookByID(tx, organizationID, id)
+				if err != nil {
+					return err
+				}
+
+				// dipublikasikan oleh penjadwal, tidak ada aktor
+				err = recordAudit(tx, domain.AuditContext{}, domain.ConstAuditBookSetStatus, domain.ConstAuditResourceBook, strconv.Itoa(id), before, after)
+				if err != nil {
+					return err
+				}
+			}
+
+			return nil
+		})
+		if err != nil {
+			log.Printf("Unable to publish the due books of organization %s: %v", organizationID, err)
+			failed++
+			continue
+		}
 
-	return book, nil
+		published += int64(len(ids))
+	}
+
+	err = nil
+	if failed > 0 {
+		err = fmt.Errorf("publishing due books failed in %d of %d organizations", failed, len(organizationIDs))
+	}
+
+	return
+}
+
+func (m *pgsqlBookRepository) SetCover(organizationID uuid.UUID, bookId int, cover *domain.BookCover, ac domain.AuditContext) (book domain.Book, previous *domain.BookCover, err error) {
+	var record []byte
+	if cover != nil {
+		record, err = json.Marshal(bookCoverRecord{URL: cover.URL, Thumbnails: cover.Thumbnails, Keys: cover.Keys})
+		if err != nil {
+			return
+		}
+	}
+
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		before, err := getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
+		previous = before.Cover
+
+		qCmd := `UPDATE books SET cover=$1, updated_at=$2, updated_by=$3 WHERE id=$4 AND organization_id=$5`
+		_, err = tx.Exec(context.Background(), qCmd, record, time.Now().Unix(), ac.ActorID, bookId, organizationID)
+		if err != nil {
+			return err
+		}
+
+		book, err = getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditBookUpdateCover, domain.ConstAuditResourceBook, strconv.Itoa(bookId), before, book)
+	})
+
+	return
 }
 
-func (m *pgsqlBookRepository) Fetch(perPage, page int) (books []domain.Book, totalCount, pageCount, currentPage int, err error) {
-	err = m.Conn.QueryRow(context.Background(),"SELECT COUNT(*) FROM books").Scan(&totalCount)
+func (m *pgsqlBookRepository) FetchRevisions(organizationID uuid.UUID, bookId int) (revisions []domain.BookRevision, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		// buku yang tidak ada (atau milik organisasi lain) harus 404, bukan daftar kosong
+		_, err := getBookByID(tx, organizationID, bookId)
+		if err != nil {
+			return err
+		}
+
+		rows, err := tx.Query(context.Background(), `SELECT `+bookRevisionColumns+` FROM book_revisions WHERE book_id = $1 AND organization_id = $2 ORDER BY revision DESC`, bookId, organizationID)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var r domain.BookRevision
+			r, err = scanBookRevision(rows)
+			if err != nil {
+				return err
+			}
+			revisions = append(revisions, r)
+		}
+
+		return rows.Err()
+	})
+
+	return
+}
+
+func (m *pgsqlBookRepository) GetRevision(organizationID uuid.UUID, bookId, revision int) (r domain.BookRevision, err error) {
+	err = inOrganization(m.Conn, organizationID, func(tx pgx.Tx) error {
+		qStr := `SELECT ` + bookRevisionColumns + ` FROM book_revisions WHERE book_id = $1 AND revision = $2 AND organization_id = $3`
+		r, err = scanBookRevision(tx.QueryRow(context.Background(), qStr, bookId, revision, organizationID))
+		if err == pgx.ErrNoRows {
+			return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book revision not found"}
+		}
+
+		return err
+	})
+
+	return
+}
+
+// inOrganization runs fn in a transaction scoped to the organization: the row level security policy
+// of the books table hides the books of every other organization. The explicit organization_id
+// conditions in the queries only help the planner use the index.
+func inOrganization(conn *pgxpool.Pool, organizationID uuid.UUID, fn func(tx pgx.Tx) error) (err error) {
+	tx, err := conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), `SELECT set_config('app.current_organization', $1, true)`, organizationID.String())
+	if err != nil {
+		return
+	}
+
+	err = fn(tx)
 	if err != nil {
 		return
 	}
 
-	pageCount = totalCount / perPage
-	if page > pageCount {
-		page = pageCount
+	return tx.Commit(context.Background())
+}
+
+func getBookByID(tx pgx.Tx, organizationID uuid.UUID, bookId int) (domain.Book, error) {
+	qStr := `SELECT ` + bookColumns + ` FROM ` + bookFrom + ` WHERE b.id=$1 AND b.organization_id=$2 LIMIT 1`
+	b, err := scanBook(tx.QueryRow(context.Background(), qStr, bookId, organizationID))
+	if err == pgx.ErrNoRows {
+		return b, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+	}
+	if err != nil {
+		return b, err
 	}
 
-	offset := perPage * (page-1)
-	qStr := `SELECT id,title,author,content,price,created_at,updated_at,rating FROM books ORDER BY $1 DESC LIMIT $2 OFFSET $3`
-	rows, err := m.Conn.Query(context.Background(), qStr, "created_at", perPage, offset)
+	books := []domain.Book{b}
+	err = loadBookRelations(tx, books)
 	if err != nil {
+		return books[0], err
+	}
+
+	books[0].Chapters, err = fetchBookChapterHeadings(tx, bookId)
+
+	return books[0], err
+}
+
+// setBookISBN saves the normalized ISBN of the book, nil keeps it and empty removes it.
+// An ISBN is unique within the organization.
+func setBookISBN(tx pgx.Tx, organizationID uuid.UUID, bookId int, isbn *string) (err error) {
+	if isbn == nil {
 		return
 	}
 
+	var value *string
+	if *isbn != "" {
+		var exists bool
+		qStr := `SELECT EXISTS(SELECT 1 FROM books WHERE organization_id=$1 AND isbn=$2 AND id<>$3)`
+		err = tx.QueryRow(context.Background(), qStr, organizationID, *isbn, bookId).Scan(&exists)
+		if err != nil {
+			return
+		}
+		if exists {
+			return domain.ConflictError{Field: "isbn", Message: "a book with this ISBN already exists"}
+		}
+
+		value = isbn
+	}
+
+	_, err = tx.Exec(context.Background(), `UPDATE books SET isbn=$1 WHERE id=$2 AND organization_id=$3`, value, bookId, organizationID)
+
+	// buku lain dengan ISBN yang sama bisa tersimpan bersamaan setelah pengecekan di atas
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "books_organization_id_isbn_key" {
+		return domain.ConflictError{Field: "isbn", Message: "a book with this ISBN already exists"}
+	}
+	return
+}
+
+// resolveBookAuthors returns the authors to credit on the book and its byline. Without authors in the form
+// the byline is credited to the author of that name, who is created when unknown.
+func resolveBookAuthors(tx pgx.Tx, organizationID uuid.UUID, bf *domain.BookForm) (credits []domain.BookAuthorForm, byline string, err error) {
+	byline = strings.TrimSpace(bf.Author)
+
+	if len(bf.Authors) == 0 {
+		id, err := findOrCreateAuthor(tx, organizationID, byline)
+		if err != nil {
+			return nil, "", err
+		}
+
+		return []domain.BookAuthorForm{{AuthorID: id, Role: domain.ConstAuthorRoleAuthor}}, byline, nil
+	}
+
+	ids := make([]int, 0, len(bf.Authors))
+	for _, c := range bf.Authors {
+		ids = append(ids, c.AuthorID)
+	}
+
+	rows, err := tx.Query(context.Background(), `SELECT id, name FROM authors WHERE id = ANY($1) AND organization_id = $2`, ids, organizationID)
+	if err != nil {
+		return
+	}
+	names := make(map[int]string)
 	for rows.Next() {
-		var b domain.Book
-		var title, author, content string
-		var id, rating, createdAt, updatedAt int
-		var price float64
-		err = rows.Scan(&id, &title, &author, &content, &price, &createdAt, &updatedAt, &rating)
+		var id int
+		var name string
+		if err = rows.Scan(&id, &name); err != nil {
+			rows.Close()
+			return
+		}
+		names[id] = name
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	var authorNames, otherNames []string
+	seen := make(map[domain.BookAuthorForm]bool)
+	for _, c := range bf.Authors {
+		if c.Role == "" {
+			c.Role = domain.ConstAuthorRoleAuthor
+		}
+
+		name, ok := names[c.AuthorID]
+		if !ok {
+			return nil, "", domain.DataValidationError{Field: "authors", Message: "author " + strconv.Itoa(c.AuthorID) + " not found"}
+		}
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		credits = append(credits, c)
+
+		if c.Role == domain.ConstAuthorRoleAuthor {
+			authorNames = append(authorNames, name)
+		} else {
+			otherNames = append(otherNames, name)
+		}
+	}
+
+	if byline == "" {
+		if len(authorNames) == 0 {
+			authorNames = otherNames
+		}
+		byline = strings.Join(authorNames, ", ")
+	}
+
+	return
+}
+
+// setBookAuthors replaces the authors credited on the book, in the order given.
+func setBookAuthors(tx pgx.Tx, organizationID uuid.UUID, bookId int, credits []domain.BookAuthorForm) (err error) {
+	_, err = tx.Exec(context.Background(), `DELETE FROM book_authors WHERE book_id = $1`, bookId)
+	if err != nil {
+		return
+	}
+
+	for i, c := range credits {
+		qStr := `INSERT INTO book_authors (book_id, author_id, role, position, organization_id) VALUES ($1,$2,$3,$4,$5)`
+		_, err = tx.Exec(context.Background(), qStr, bookId, c.AuthorID, c.Role, i, organizationID)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+// setBookTaxonomy replaces the categories and tags of the book, those left out of the form are kept.
+func setBookTaxonomy(tx pgx.Tx, organizationID uuid.UUID, bookId int, bf *domain.BookForm) (err error) {
+	if bf.CategoryIDs != nil {
+		var found int
+
+		// foreign key tidak memeriksa row level security, pastikan kategori milik organisasi ini
+		err = tx.QueryRow(context.Background(), `SELECT COUNT(*) FROM categories WHERE id = ANY($1) AND organization_id = $2`, bf.CategoryIDs, organizationID).Scan(&found)
+		if err != nil {
+			return
+		}
+		if found != len(uniqueInts(bf.CategoryIDs)) {
+			return domain.DataValidationError{Field: "category_ids", Message: "category not found"}
+		}
+
+		_, err = tx.Exec(context.Background(), `DELETE FROM book_categories WHERE book_id = $1`, bookId)
+		if err != nil {
+			return
+		}
+
+		qStr := `INSERT INTO book_categories (book_id, category_id, organization_id) SELECT $1, unnest($2::int[]), $3 ON CONFLICT DO NOTHING`
+		_, err = tx.Exec(context.Background(), qStr, bookId, bf.CategoryIDs, organizationID)
+		if err != nil {
+			return
+		}
+	}
+
+	if bf.Tags != nil {
+		_, err = tx.Exec(context.Background(), `DELETE FROM book_tags WHERE book_id = $1`, bookId)
 		if err != nil {
 			return
 		}
 
-		b.ID = id
-		b.Title = title
-		b.Author = author
-		b.Content = content
-		b.Price = price
-		b.CreatedAt = createdAt
-		b.UpdatedAt = updatedAt
-		b.Rating = rating
-		books = append(books, b)
+		for _, tag := range bf.Tags {
+			var tagID int
+
+			tag = normalizeTag(tag)
+			if tag == "" {
+				continue
+			}
+
+			// DO UPDATE tanpa perubahan agar RETURNING juga mengembalikan id tag yang sudah ada
+			qTag := `INSERT INTO tags (organization_id, name, created_at) VALUES ($1,$2,$3)
+				ON CONFLICT ON CONSTRAINT tags_organization_id_name_key DO UPDATE SET name = EXCLUDED.name returning id`
+			err = tx.QueryRow(context.Background(), qTag, organizationID, tag, time.Now().Unix()).Scan(&tagID)
+			if err != nil {
+				return
+			}
+
+			qStr := `INSERT INTO book_tags (book_id, tag_id, organization_id) VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`
+			_, err = tx.Exec(context.Background(), qStr, bookId, tagID, organizationID)
+			if err != nil {
+				return
+			}
+		}
 	}
 
-	return books, totalCount, pageCount, page, nil
+	return
 }
 
-func (m *pgsqlBookRepository) GetByID(bookId int) (domain.Book, error) {
-	b := domain.Book{}
-	var title, author, content string
-	var id, rating, createdAt, updatedAt int
-	var price float64
-	err := m.Conn.QueryRow(context.Background(), "SELECT id,title,author,content,price,created_at,updated_at,rating FROM books WHERE id=$1 LIMIT 1", bookId).Scan(&id, &title, &author, &content, &price, &createdAt, &updatedAt, &rating)
+// setBookPrices replaces the price list of the book when prices is not nil. A listed price in the currency
+// of the book itself is always removed, the book price takes its place.
+func setBookPrices(tx pgx.Tx, organizationID uuid.UUID, bookId int, price domain.Money, prices []domain.Money) (err error) {
+	if prices == nil {
+		_, err = tx.Exec(context.Background(), `DELETE FROM book_prices WHERE book_id = $1 AND currency = $2`, bookId, price.Currency)
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM book_prices WHERE book_id = $1`, bookId)
 	if err != nil {
-		return b, err
+		return
 	}
 
-	b.ID = id
-	b.Title = title
-	b.Author = author
-	b.Content = content
-	b.Price = price
-	b.CreatedAt = createdAt
-	b.UpdatedAt = updatedAt
-	b.Rating = rating
+	for _, p := range prices {
+		qStr := `INSERT INTO book_prices (book_id, currency, amount, organization_id) VALUES ($1,$2,$3,$4)`
+		_, err = tx.Exec(context.Background(), qStr, bookId, p.Currency, p.Amount, organizationID)
+		if err != nil {
+			return
+		}
+	}
 
-	return b, nil
+	return
 }
 
-func (m *pgsqlBookRepository) Update(bookId int, b *domain.BookForm) (book domain.Book, err error) {
-	var title, author, content string
-	var id, rating, createdAt, updatedAt int
-	var price float64
+// loadBookRelations fills the authors, categories, tags and price lists of the books.
+func loadBookRelations(tx pgx.Tx, books []domain.Book) (err error) {
+	if len(books) == 0 {
+		return
+	}
+
+	err = loadBookAuthors(tx, books)
+	if err != nil {
+		return
+	}
+
+	index := make(map[int]int, len(books))
+	ids := make([]int, 0, len(books))
+	for i := range books {
+		books[i].Categories = []domain.BookCategory{}
+		books[i].Tags = []string{}
+		books[i].Prices = []domain.Money{}
+		index[books[i].ID] = i
+		ids = append(ids, books[i].ID)
+	}
+
+	rows, err := tx.Query(context.Background(), `SELECT bc.book_id, c.id, c.name, c.path FROM book_categories bc JOIN categories c ON c.id = bc.category_id
+		WHERE bc.book_id = ANY($1) ORDER BY bc.book_id, c.path`, ids)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		var bookId int
+		var c domain.BookCategory
+		if err = rows.Scan(&bookId, &c.ID, &c.Name, &c.Path); err != nil {
+			rows.Close()
+			return
+		}
+		i := index[bookId]
+		books[i].Categories = append(books[i].Categories, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	rows, err = tx.Query(context.Background(), `SELECT bt.book_id, t.name FROM book_tags bt JOIN tags t ON t.id = bt.tag_id
+		WHERE bt.book_id = ANY($1) ORDER BY bt.book_id, t.name`, ids)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		var bookId int
+		var tag string
+		if err = rows.Scan(&bookId, &tag); err != nil {
+			rows.Close()
+			return
+		}
+		i := index[bookId]
+		books[i].Tags = append(books[i].Tags, tag)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
 
-	err = m.Conn.QueryRow(context.Background(), "SELECT id,title,author,content,price,created_at,updated_at,rating FROM books WHERE id=$1 LIMIT 1", bookId).Scan(&id, &title, &author, &content, &price, &createdAt, &updatedAt, &rating)
+	rows, err = tx.Query(context.Background(), `SELECT book_id, amount, currency FROM book_prices WHERE book_id = ANY($1) ORDER BY book_id, currency`, ids)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookId int
+		var p domain.Money
+		if err = rows.Scan(&bookId, &p.Amount, &p.Currency); err != nil {
+			return
+		}
+		i := index[bookId]
+		books[i].Prices = append(books[i].Prices, p)
+	}
+
+	return rows.Err()
+}
+
+// normalizeTag trims, lowercases and collapses the whitespace of a tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+func uniqueInts(values []int) map[int]bool {
+	set := make(map[int]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+
+	return set
+}
 
-	book = domain.Book{
-		id,
-		title,
-		author,
-		content,
-		price,
-		createdAt,
-		updatedAt,
-		rating,
+// loadBookAuthors fills the authors credited on the books.
+func loadBookAuthors(tx pgx.Tx, books []domain.Book) (err error) {
+	if len(books) == 0 {
+		return
 	}
 
-	book.Title = b.Title
-	book.Author = b.Author
-	book.Content = b.Content
-	book.Price = b.Price
-	book.Rating = b.Rating
+	index := make(map[int]int, len(books))
+	ids := make([]int, 0, len(books))
+	for i := range books {
+		books[i].Authors = []domain.BookAuthor{}
+		index[books[i].ID] = i
+		ids = append(ids, books[i].ID)
+	}
 
-	qCmd := `UPDATE books SET title=$1, author=$2, content=$3, price=$4, rating=$5 WHERE id=$6`
-	_, err = m.Conn.Exec(context.Background(), qCmd, book.Title, book.Author, book.Content, book.Price, book.Rating, book.ID)
+	qStr := `SELECT ba.book_id, a.id, a.name, ba.role FROM book_authors ba JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = ANY($1) ORDER BY ba.book_id, ba.position`
+	rows, err := tx.Query(context.Background(), qStr, ids)
 	if err != nil {
 		return
 	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookId int
+		var a domain.BookAuthor
+		if err = rows.Scan(&bookId, &a.ID, &a.Name, &a.Role); err != nil {
+			return
+		}
+		i := index[bookId]
+		books[i].Authors = append(books[i].Authors, a)
+	}
+
+	return rows.Err()
+}
+
+// equalBookCredits reports whether both credit the same authors in the same roles and order.
+func equalBookCredits(a, b []domain.BookAuthor) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID || a[i].Role != b[i].Role {
+			return false
+		}
+	}
+
+	return true
+}
+
+// saveBookRevision stores the current version of the book, its chapters and its credits as its next revision. The row
+// of the book is locked by the insert or update that precedes, so concurrent writers can not pick the same number.
+func saveBookRevision(tx pgx.Tx, b domain.Book) (err error) {
+	qStr := `INSERT INTO book_revisions (book_id, revision, organization_id, title, author, summary, price, currency, created_by, created_at, chapters, authors)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4, $5, $6, $7, $8, $9, (
+			SELECT COALESCE(jsonb_agg(jsonb_build_object('position', position, 'title', title, 'content', content) ORDER BY position), '[]')
+			FROM book_chapters WHERE book_id = $1
+		), (
+			SELECT COALESCE(jsonb_agg(jsonb_build_object('author_id', author_id, 'role', role, 'position', position) ORDER BY position), '[]')
+			FROM book_authors WHERE book_id = $1
+		) FROM book_revisions WHERE book_id = $1`
+	_, err = tx.Exec(context.Background(), qStr, b.ID, b.OrganizationID, b.Title, b.Author, b.Summary, b.Price.Amount, b.Price.Currency, b.UpdatedBy, b.UpdatedAt)
 
 	return
 }
 
-func (m *pgsqlBookRepository) Delete(id int) (rowsAffected int64, err error) {
-	qCmd := `DELETE FROM books WHERE id=$1`
-	res, err := m.Conn.Exec(context.Background(), qCmd, id)
+// scanBookRevision scans a row selected with bookRevisionColumns.
+func scanBookRevision(row pgx.Row) (r domain.BookRevision, err error) {
+	var chapters, authors []byte
+
+	err = row.Scan(&r.BookID, &r.Revision, &r.Title, &r.Author, &r.Summary, &r.Price.Amount, &r.Price.Currency, &r.CreatedBy, &r.CreatedAt, &chapters, &authors)
 	if err != nil {
-		return 0, err
+		return
 	}
 
-	return res.RowsAffected(), nil
+	err = json.Unmarshal(chapters, &r.Chapters)
+	if err != nil || authors == nil {
+		return
+	}
+
+	err = json.Unmarshal(authors, &r.Authors)
+	return
+}
+
+// bookFilterCondition builds the WHERE condition and its arguments for the filter.
+func bookFilterCondition(organizationID uuid.UUID, filter domain.BookFilter) (where string, args []interface{}) {
+	args = append(args, organizationID)
+	where = "b.organization_id = $1"
+
+	if filter.Owner != uuid.Nil {
+		args = append(args, filter.Owner)
+		where += " AND b.created_by = $" + strconv.Itoa(len(args))
+	}
+
+	if filter.CategoryID != 0 {
+		args = append(args, filter.CategoryID)
+		where += " AND EXISTS(SELECT 1 FROM book_categories bc JOIN categories c ON c.id = bc.category_id WHERE bc.book_id = b.id" +
+			" AND c.path LIKE (SELECT path FROM categories WHERE id = $" + strconv.Itoa(len(args)) + ") || '%')"
+	}
+
+	if filter.Tag != "" {
+		args = append(args, normalizeTag(filter.Tag))
+		where += " AND EXISTS(SELECT 1 FROM book_tags bt JOIN tags t ON t.id = bt.tag_id WHERE bt.book_id = b.id AND t.name = $" + strconv.Itoa(len(args)) + ")"
+	}
+
+	if filter.AuthorID != 0 {
+		args = append(args, filter.AuthorID)
+		where += " AND EXISTS(SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = $" + strconv.Itoa(len(args)) + ")"
+	}
+
+	if filter.Status != "" {
+		args = append(args, filter.Status)
+		where += " AND b.status = $" + strconv.Itoa(len(args))
+	}
+
+	return
+}
+
+// scanBook scans a row selected with bookColumns.
+func scanBook(row pgx.Row) (b domain.Book, err error) {
+	var ownerUsername *string
+	var cover []byte
+
+	err = row.Scan(&b.ID, &b.Title, &b.Author, &b.Summary, &b.Price.Amount, &b.Price.Currency, &b.ISBN, &b.CreatedAt, &b.UpdatedAt, &b.Status, &b.PublishAt, &b.PublishedAt, &b.Rating, &b.RatingCount, &cover, &b.CreatedBy, &b.UpdatedBy, &ownerUsername, &b.OrganizationID)
+	if err != nil {
+		return
+	}
+
+	if b.ISBN != nil {
+		b.ISBN10 = utils.ISBN10(*b.ISBN)
+	}
+
+	if cover != nil {
+		var record bookCoverRecord
+		err = json.Unmarshal(cover, &record)
+		if err != nil {
+			return
+		}
+		b.Cover = &domain.BookCover{URL: record.URL, Thumbnails: record.Thumbnails, Keys: record.Keys}
+	}
+
+	if b.CreatedBy != nil && ownerUsername != nil {
+		b.Owner = &domain.BookOwner{ID: *b.CreatedBy, Username: *ownerUsername}
+	}
+
+	return
 }
@@ -0,0 +1,292 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"time"
+)
+
+// bookChapterColumns columns selected by scanBookChapter
+const bookChapterColumns = `id, position, title, book_id, content, created_at, updated_at, created_by, updated_by`
+
+type pgsqlBookChapterRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlBookChapterRepository will create an object that represent the domain.BookChapterRepository interface
+func NewPgsqlBookChapterRepository(conn *pgxpool.Pool) domain.BookChapterRepository {
+	return &pgsqlBookChapterRepository{Conn: conn}
+}
+
+func (cr *pgsqlBookChapterRepository) GetByID(organizationID uuid.UUID, bookID, id int) (chapter domain.BookChapter, err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		chapter, err = getBookChapter(tx, bookID, id)
+		return err
+	})
+
+	return
+}
+
+func (cr *pgsqlBookChapterRepository) Create(organizationID uuid.UUID, bookID int, cf *domain.BookChapterForm, ac domain.AuditContext) (chapter domain.BookChapter, err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		var id, count int
+
+		err := lockBookForChapters(tx, organizationID, bookID)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(context.Background(), `SELECT COUNT(*) FROM book_chapters WHERE book_id = $1`, bookID).Scan(&count)
+		if err != nil {
+			return err
+		}
+
+		// bab baru di posisi yang sudah terisi menggeser bab berikutnya ke bawah
+		position := count + 1
+		if cf.Position != nil && *cf.Position <= count {
+			position = *cf.Position
+
+			_, err = tx.Exec(context.Background(), `UPDATE book_chapters SET position = position + 1 WHERE book_id = $1 AND position >= $2`, bookID, position)
+			if err != nil {
+				return err
+			}
+		}
+
+		ts := time.Now().Unix()
+		qStr := `INSERT INTO book_chapters (book_id, organization_id, position, title, content, created_at, updated_at, created_by, updated_by)
+			VALUES ($1,$2,$3,$4,$5,$6,$6,$7,$7) returning id`
+		err = tx.QueryRow(context.Background(), qStr, bookID, organizationID, position, cf.Title, cf.Content, ts, ac.ActorID).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		chapter, err = getBookChapter(tx, bookID, id)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(tx, ac, domain.ConstAuditChapterCreate, domain.ConstAuditResourceChapter, strconv.Itoa(id), nil, chapter)
+		if err != nil {
+			return err
+		}
+
+		return saveChapterRevision(tx, organizationID, bookID, ac)
+	})
+
+	return
+}
+
+func (cr *pgsqlBookChapterRepository) Update(organizationID uuid.UUID, bookID, id int, cf *domain.BookChapterForm, ac domain.AuditContext) (chapter domain.BookChapter, err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		err := lockBookForChapters(tx, organizationID, bookID)
+		if err != nil {
+			return err
+		}
+
+		before, err := getBookChapter(tx, bookID, id)
+		if err != nil {
+			return err
+		}
+
+		position := before.Position
+		if cf.Position != nil && *cf.Position != before.Position {
+			var count int
+
+			err = tx.QueryRow(context.Background(), `SELECT COUNT(*) FROM book_chapters WHERE book_id = $1`, bookID).Scan(&count)
+			if err != nil {
+				return err
+			}
+
+			position = *cf.Position
+			if position > count {
+				position = count
+			}
+
+			// bab di antara posisi lama dan posisi baru bergeser satu ke arah posisi lama
+			qCmd := `UPDATE book_chapters SET position = position + 1 WHERE book_id = $1 AND position >= $2 AND position < $3`
+			if position > before.Position {
+				qCmd = `UPDATE book_chapters SET position = position - 1 WHERE book_id = $1 AND position <= $2 AND position > $3`
+			}
+			_, err = tx.Exec(context.Background(), qCmd, bookID, position, before.Position)
+			if err != nil {
+				return err
+			}
+		}
+
+		qCmd := `UPDATE book_chapters SET title = $1, content = $2, position = $3, updated_at = $4, updated_by = $5 WHERE id = $6`
+		_, err = tx.Exec(context.Background(), qCmd, cf.Title, cf.Content, position, time.Now().Unix(), ac.ActorID, id)
+		if err != nil {
+			return err
+		}
+
+		chapter, err = getBookChapter(tx, bookID, id)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(tx, ac, domain.ConstAuditChapterUpdate, domain.ConstAuditResourceChapter, strconv.Itoa(id), before, chapter)
+		if err != nil {
+			return err
+		}
+
+		// simpan tanpa perubahan, tidak perlu revisi baru
+		if chapter.Title == before.Title && chapter.Content == before.Content && chapter.Position == before.Position {
+			return nil
+		}
+
+		return saveChapterRevision(tx, organizationID, bookID, ac)
+	})
+
+	return
+}
+
+func (cr *pgsqlBookChapterRepository) Delete(organizationID uuid.UUID, bookID, id int, ac domain.AuditContext) (err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		err := lockBookForChapters(tx, organizationID, bookID)
+		if err != nil {
+			return err
+		}
+
+		before, err := getBookChapter(tx, bookID, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(), `DELETE FROM book_chapters WHERE id = $1`, id)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(), `UPDATE book_chapters SET position = position - 1 WHERE book_id = $1 AND position > $2`, bookID, before.Position)
+		if err != nil {
+			return err
+		}
+
+		err = recordAudit(tx, ac, domain.ConstAuditChapterDelete, domain.ConstAuditResourceChapter, strconv.Itoa(id), before, nil)
+		if err != nil {
+			return err
+		}
+
+		return saveChapterRevision(tx, organizationID, bookID, ac)
+	})
+
+	return
+}
+
+// lockBookForChapters locks the book until the transaction ends, so concurrent chapter writes renumber positions one after another.
+func lockBookForChapters(tx pgx.Tx, organizationID uuid.UUID, bookID int) (err error) {
+	var id int
+
+	err = tx.QueryRow(context.Background(), `SELECT id FROM books WHERE id = $1 AND organization_id = $2 FOR UPDATE`, bookID, organizationID).Scan(&id)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+	}
+
+	return
+}
+
+// saveChapterRevision marks the book as updated by the chapter write and stores it as its next revision,
+// the book is locked by lockBookForChapters.
+func saveChapterRevision(tx pgx.Tx, organizationID uuid.UUID, bookID int, ac domain.AuditContext) (err error) {
+	qCmd := `UPDATE books SET updated_at = $1, updated_by = $2 WHERE id = $3 AND organization_id = $4`
+	_, err = tx.Exec(context.Background(), qCmd, time.Now().Unix(), ac.ActorID, bookID, organizationID)
+	if err != nil {
+		return
+	}
+
+	book, err := getBookByID(tx, organizationID, bookID)
+	if err != nil {
+		return
+	}
+
+	return saveBookRevision(tx, book)
+}
+
+// setBookChapters replaces the chapters of the book when chapters is not nil, as a restored revision does.
+// It reports whether they changed, chapters are numbered in the order given.
+func setBookChapters(tx pgx.Tx, organizationID uuid.UUID, bookID int, chapters []domain.BookRevisionChapter, ac domain.AuditContext) (changed bool, err error) {
+	if chapters == nil {
+		return
+	}
+
+	var current []domain.BookRevisionChapter
+
+	rows, err := tx.Query(context.Background(), `SELECT position, title, content FROM book_chapters WHERE book_id = $1 ORDER BY position`, bookID)
+	if err != nil {
+		return
+	}
+	for rows.Next() {
+		var c domain.BookRevisionChapter
+		if err = rows.Scan(&c.Position, &c.Title, &c.Content); err != nil {
+			rows.Close()
+			return
+		}
+		current = append(current, c)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	if domain.EqualBookChapters(current, chapters) {
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM book_chapters WHERE book_id = $1`, bookID)
+	if err != nil {
+		return
+	}
+
+	ts := time.Now().Unix()
+	qStr := `INSERT INTO book_chapters (book_id, organization_id, position, title, content, created_at, updated_at, created_by, updated_by)
+		VALUES ($1,$2,$3,$4,$5,$6,$6,$7,$7)`
+	for i, c := range chapters {
+		_, err = tx.Exec(context.Background(), qStr, bookID, organizationID, i+1, c.Title, c.Content, ts, ac.ActorID)
+		if err != nil {
+			return
+		}
+	}
+
+	return true, nil
+}
+
+// fetchBookChapterHeadings returns the table of contents of the book, without the content of the chapters.
+func fetchBookChapterHeadings(tx pgx.Tx, bookID int) (chapters []domain.BookChapterHeading, err error) {
+	chapters = []domain.BookChapterHeading{}
+
+	rows, err := tx.Query(context.Background(), `SELECT id, position, title FROM book_chapters WHERE book_id = $1 ORDER BY position`, bookID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c domain.BookChapterHeading
+		if err = rows.Scan(&c.ID, &c.Position, &c.Title); err != nil {
+			return
+		}
+		chapters = append(chapters, c)
+	}
+
+	return chapters, rows.Err()
+}
+
+func getBookChapter(tx pgx.Tx, bookID, id int) (domain.BookChapter, error) {
+	c, err := scanBookChapter(tx.QueryRow(context.Background(), `SELECT `+bookChapterColumns+` FROM book_chapters WHERE id = $1 AND book_id = $2`, id, bookID))
+	if err == pgx.ErrNoRows {
+		return c, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "chapter not found"}
+	}
+
+	return c, err
+}
+
+// scanBookChapter scans a row selected with bookChapterColumns.
+func scanBookChapter(row pgx.Row) (c domain.BookChapter, err error) {
+	err = row.Scan(&c.ID, &c.Position, &c.Title, &c.BookID, &c.Content, &c.CreatedAt, &c.UpdatedAt, &c.CreatedBy, &c.UpdatedBy)
+	return
+}
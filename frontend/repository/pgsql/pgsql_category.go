@@ -0,0 +1,217 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const categoryColumns = `id, parent_id, name, path, created_at, updated_at`
+
+type pgsqlCategoryRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlCategoryRepository will create an object that represent the category Repository interface
+func NewPgsqlCategoryRepository(conn *pgxpool.Pool) domain.CategoryRepository {
+	return &pgsqlCategoryRepository{Conn: conn}
+}
+
+func (cr *pgsqlCategoryRepository) Create(organizationID uuid.UUID, cf *domain.CategoryForm, ac domain.AuditContext) (c domain.Category, err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		var id int
+
+		parentPath, err := categoryParentPath(tx, organizationID, cf.ParentID)
+		if err != nil {
+			return err
+		}
+
+		err = checkCategoryNameAvailable(tx, organizationID, cf, 0)
+		if err != nil {
+			return err
+		}
+
+		ts := time.Now().Unix()
+		qStr := `INSERT INTO categories (organization_id, parent_id, name, created_at, updated_at) VALUES ($1,$2,$3,$4,$4) returning id`
+		err = tx.QueryRow(context.Background(), qStr, organizationID, nullableCategoryID(cf.ParentID), strings.TrimSpace(cf.Name), ts).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		// path baru bisa disusun setelah id diketahui
+		_, err = tx.Exec(context.Background(), `UPDATE categories SET path = $1 WHERE id = $2`, parentPath+strconv.Itoa(id)+"/", id)
+		if err != nil {
+			return err
+		}
+
+		c, err = getCategoryByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditCategoryCreate, domain.ConstAuditResourceCategory, strconv.Itoa(id), nil, c)
+	})
+
+	return
+}
+
+func (cr *pgsqlCategoryRepository) Fetch(organizationID uuid.UUID) (categories []domain.Category, err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		// jumlah buku (hanya yang sudah terbit) dihitung dari seluruh subtree, buku di beberapa kategori turunan dihitung sekali
+		qStr := `SELECT ` + categoryColumns + `,
+			(SELECT COUNT(DISTINCT bc.book_id) FROM book_categories bc JOIN categories d ON d.id = bc.category_id
+				JOIN books b ON b.id = bc.book_id AND b.status = $2
+				WHERE d.organization_id = c.organization_id AND d.path LIKE c.path || '%')
+			FROM categories c WHERE organization_id = $1 ORDER BY path`
+		rows, err := tx.Query(context.Background(), qStr, organizationID, domain.ConstBookStatusPublished)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var c domain.Category
+			err = rows.Scan(&c.ID, &c.ParentID, &c.Name, &c.Path, &c.CreatedAt, &c.UpdatedAt, &c.BookCount)
+			if err != nil {
+				return err
+			}
+			categories = append(categories, c)
+		}
+
+		return rows.Err()
+	})
+
+	return
+}
+
+func (cr *pgsqlCategoryRepository) Update(organizationID uuid.UUID, id int, cf *domain.CategoryForm, ac domain.AuditContext) (c domain.Category, err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		before, err := getCategoryByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		parentPath, err := categoryParentPath(tx, organizationID, cf.ParentID)
+		if err != nil {
+			return err
+		}
+
+		// kategori tidak boleh dipindah ke dalam subtree-nya sendiri
+		if strings.HasPrefix(parentPath, before.Path) {
+			return domain.DataValidationError{Field: "parent_id", Message: "a category can not be moved below itself"}
+		}
+
+		err = checkCategoryNameAvailable(tx, organizationID, cf, id)
+		if err != nil {
+			return err
+		}
+
+		qCmd := `UPDATE categories SET parent_id = $1, name = $2, updated_at = $3 WHERE id = $4 AND organization_id = $5`
+		_, err = tx.Exec(context.Background(), qCmd, nullableCategoryID(cf.ParentID), strings.TrimSpace(cf.Name), time.Now().Unix(), id, organizationID)
+		if err != nil {
+			return err
+		}
+
+		newPath := parentPath + strconv.Itoa(id) + "/"
+		if newPath != before.Path {
+			qMove := `UPDATE categories SET path = $1 || substr(path, $2) WHERE organization_id = $3 AND path LIKE $4 || '%'`
+			_, err = tx.Exec(context.Background(), qMove, newPath, len(before.Path)+1, organizationID, before.Path)
+			if err != nil {
+				return err
+			}
+		}
+
+		c, err = getCategoryByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditCategoryUpdate, domain.ConstAuditResourceCategory, strconv.Itoa(id), before, c)
+	})
+
+	return
+}
+
+func (cr *pgsqlCategoryRepository) Delete(organizationID uuid.UUID, id int, ac domain.AuditContext) (err error) {
+	err = inOrganization(cr.Conn, organizationID, func(tx pgx.Tx) error {
+		var hasChildren bool
+
+		before, err := getCategoryByID(tx, organizationID, id)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM categories WHERE parent_id = $1)`, id).Scan(&hasChildren)
+		if err != nil {
+			return err
+		}
+		if hasChildren {
+			return domain.DataValidationError{Field: "id", Message: "category still has subcategories"}
+		}
+
+		// buku di kategori ini ikut terlepas (on delete cascade)
+		_, err = tx.Exec(context.Background(), `DELETE FROM categories WHERE id = $1 AND organization_id = $2`, id, organizationID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditCategoryDelete, domain.ConstAuditResourceCategory, strconv.Itoa(id), before, nil)
+	})
+
+	return
+}
+
+func getCategoryByID(tx pgx.Tx, organizationID uuid.UUID, id int) (c domain.Category, err error) {
+	qStr := `SELECT ` + categoryColumns + ` FROM categories WHERE id = $1 AND organization_id = $2`
+	err = tx.QueryRow(context.Background(), qStr, id, organizationID).Scan(&c.ID, &c.ParentID, &c.Name, &c.Path, &c.CreatedAt, &c.UpdatedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "category not found"}
+	}
+
+	return
+}
+
+// categoryParentPath returns the path of the parent category, "/" for a root category.
+func categoryParentPath(tx pgx.Tx, organizationID uuid.UUID, parentID int) (string, error) {
+	if parentID == 0 {
+		return "/", nil
+	}
+
+	parent, err := getCategoryByID(tx, organizationID, parentID)
+	if _, ok := err.(domain.NotFoundError); ok {
+		return "", domain.DataValidationError{Field: "parent_id", Message: "parent category not found"}
+	}
+
+	return parent.Path, err
+}
+
+// checkCategoryNameAvailable returns a DataValidationError when a sibling category has the same name.
+func checkCategoryNameAvailable(tx pgx.Tx, organizationID uuid.UUID, cf *domain.CategoryForm, exceptID int) (err error) {
+	var exists bool
+
+	qExist := `SELECT EXISTS(SELECT 1 FROM categories WHERE organization_id = $1 AND parent_id IS NOT DISTINCT FROM $2 AND LOWER(name) = LOWER($3) AND id <> $4)`
+	err = tx.QueryRow(context.Background(), qExist, organizationID, nullableCategoryID(cf.ParentID), strings.TrimSpace(cf.Name), exceptID).Scan(&exists)
+	if err != nil {
+		return
+	}
+	if exists {
+		return domain.DataValidationError{Field: "name", Message: "category already exists"}
+	}
+
+	return
+}
+
+// nullableCategoryID maps the root parent 0 to NULL.
+func nullableCategoryID(id int) *int {
+	if id == 0 {
+		return nil
+	}
+
+	return &id
+}
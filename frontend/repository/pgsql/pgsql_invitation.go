@@ -0,0 +1,71 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+const invitationColumns = `id, email, role, COALESCE(invited_by, '00000000-0000-0000-0000-000000000000'), expires_at, accepted_at, revoked_at, created_at`
+
+type pgsqlInvitationRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlInvitationRepository will create an object that represent the invitation Repository interface
+func NewPgsqlInvitationRepository(conn *pgxpool.Pool) domain.InvitationRepository {
+	return &pgsqlInvitationRepository{Conn: conn}
+}
+
+func (ir *pgsqlInvitationRepository) Create(i *domain.Invitation, tokenHash string) (err error) {
+	qStr := `INSERT INTO user_invitations (email, role, token_hash, invited_by, expires_at, created_at) VALUES ($1,$2,$3,$4,$5,$6) returning id`
+	return ir.Conn.QueryRow(context.Background(), qStr, i.Email, i.Role, tokenHash, i.InvitedBy, i.ExpiresAt, i.CreatedAt).Scan(&i.ID)
+}
+
+func (ir *pgsqlInvitationRepository) Fetch() (invitations []domain.Invitation, err error) {
+	rows, err := ir.Conn.Query(context.Background(), `SELECT `+invitationColumns+` FROM user_invitations ORDER BY created_at DESC`)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i domain.Invitation
+		i, err = scanInvitation(rows)
+		if err != nil {
+			return
+		}
+		invitations = append(invitations, i)
+	}
+
+	return invitations, rows.Err()
+}
+
+func (ir *pgsqlInvitationRepository) GetByTokenHash(tokenHash string) (i domain.Invitation, err error) {
+	i, err = scanInvitation(ir.Conn.QueryRow(context.Background(), `SELECT `+invitationColumns+` FROM user_invitations WHERE token_hash = $1`, tokenHash))
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "invitation not found"}
+	}
+
+	return
+}
+
+func (ir *pgsqlInvitationRepository) Revoke(id uuid.UUID) (rowsAffected int64, err error) {
+	qCmd := `UPDATE user_invitations SET revoked_at = $1 WHERE id = $2 AND revoked_at = 0 AND accepted_at = 0`
+	commandTag, err := ir.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), id)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+// scanInvitation scans a row selected with invitationColumns.
+func scanInvitation(row pgx.Row) (i domain.Invitation, err error) {
+	err = row.Scan(&i.ID, &i.Email, &i.Role, &i.InvitedBy, &i.ExpiresAt, &i.AcceptedAt, &i.RevokedAt, &i.CreatedAt)
+	return
+}
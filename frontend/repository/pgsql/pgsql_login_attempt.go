@@ -0,0 +1,39 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgsqlLoginAttemptRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlLoginAttemptRepository will create an object that represent the login attempt Repository interface
+func NewPgsqlLoginAttemptRepository(conn *pgxpool.Pool) domain.LoginAttemptRepository {
+	return &pgsqlLoginAttemptRepository{Conn: conn}
+}
+
+func (lr *pgsqlLoginAttemptRepository) Record(a *domain.LoginAttempt) (err error) {
+	a.CreatedAt = int(time.Now().Unix())
+
+	qStr := `INSERT INTO login_attempts (user_id, email, ip, success, reason, created_at) VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`
+	return lr.Conn.QueryRow(context.Background(), qStr, a.UserID, a.Email, a.IP, a.Success, a.Reason, a.CreatedAt).Scan(&a.ID)
+}
+
+func (lr *pgsqlLoginAttemptRepository) CountFailuresByEmail(email string, since int64) (count int, lastAt int64, err error) {
+	qStr := `SELECT COUNT(*), COALESCE(MAX(created_at), 0) FROM login_attempts
+		WHERE email = $1 AND reason = $2 AND created_at >= GREATEST($3, (SELECT COALESCE(MAX(created_at), 0) FROM login_attempts WHERE email = $1 AND success))`
+	err = lr.Conn.QueryRow(context.Background(), qStr, email, domain.ConstLoginAttemptInvalidCredentials, since).Scan(&count, &lastAt)
+
+	return
+}
+
+func (lr *pgsqlLoginAttemptRepository) CountFailuresByIP(ip string, since int64) (count int, firstAt int64, err error) {
+	qStr := `SELECT COUNT(*), COALESCE(MIN(created_at), 0) FROM login_attempts WHERE ip = $1 AND reason = $2 AND created_at >= $3`
+	err = lr.Conn.QueryRow(context.Background(), qStr, ip, domain.ConstLoginAttemptInvalidCredentials, since).Scan(&count, &firstAt)
+
+	return
+}
@@ -0,0 +1,151 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgsqlMfaRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlMfaRepository will create an object that represent the mfa Repository interface
+func NewPgsqlMfaRepository(conn *pgxpool.Pool) domain.MfaRepository {
+	return &pgsqlMfaRepository{Conn: conn}
+}
+
+func (mr *pgsqlMfaRepository) Get(userID uuid.UUID) (m domain.UserMfa, err error) {
+	qStr := `SELECT user_id, secret, enabled, last_used_counter, failed_attempts, last_failed_at, created_at, confirmed_at FROM user_mfa WHERE user_id = $1`
+	err = mr.Conn.QueryRow(context.Background(), qStr, userID).Scan(&m.UserID, &m.Secret, &m.Enabled, &m.LastUsedCounter, &m.FailedAttempts, &m.LastFailedAt, &m.CreatedAt, &m.ConfirmedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "two-factor authentication is not configured"}
+	}
+
+	return
+}
+
+func (mr *pgsqlMfaRepository) SavePending(userID uuid.UUID, secret string) (err error) {
+	// enrollment yang sudah aktif tidak boleh ditimpa
+	qStr := `INSERT INTO user_mfa (user_id, secret, enabled, created_at) VALUES ($1, $2, false, $3)
+		ON CONFLICT ON CONSTRAINT user_mfa_pkey DO UPDATE SET secret = EXCLUDED.secret, created_at = EXCLUDED.created_at, failed_attempts = 0
+		WHERE user_mfa.enabled = false`
+	commandTag, err := mr.Conn.Exec(context.Background(), qStr, userID, secret, time.Now().Unix())
+	if err != nil {
+		return
+	}
+	if commandTag.RowsAffected() != 1 {
+		return domain.DataValidationError{Field: "mfa", Message: "two-factor authentication is already enabled"}
+	}
+
+	return
+}
+
+func (mr *pgsqlMfaRepository) Enable(userID uuid.UUID, counter int64, recoveryCodeHashes []string) (err error) {
+	tx, err := mr.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	qCmd := `UPDATE user_mfa SET enabled = true, confirmed_at = $1, last_used_counter = $2, failed_attempts = 0 WHERE user_id = $3 AND enabled = false`
+	commandTag, err := tx.Exec(context.Background(), qCmd, time.Now().Unix(), counter, userID)
+	if err != nil {
+		return
+	}
+	if commandTag.RowsAffected() != 1 {
+		return domain.DataValidationError{Field: "mfa", Message: "no pending two-factor authentication enrollment"}
+	}
+
+	err = replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (mr *pgsqlMfaRepository) ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) (err error) {
+	tx, err := mr.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	err = replaceRecoveryCodes(tx, userID, recoveryCodeHashes)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (mr *pgsqlMfaRepository) UseRecoveryCode(userID uuid.UUID, codeHash string) (used bool, err error) {
+	qCmd := `UPDATE user_mfa_recovery_codes SET used_at = $1 WHERE user_id = $2 AND code_hash = $3 AND used_at = 0`
+	commandTag, err := mr.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), userID, codeHash)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (mr *pgsqlMfaRepository) MarkCodeUsed(userID uuid.UUID, counter int64) (accepted bool, err error) {
+	// kode TOTP hanya bisa dipakai sekali, time step harus lebih baru dari yang terakhir dipakai
+	qCmd := `UPDATE user_mfa SET last_used_counter = $1, failed_attempts = 0 WHERE user_id = $2 AND last_used_counter < $1`
+	commandTag, err := mr.Conn.Exec(context.Background(), qCmd, counter, userID)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected() == 1, nil
+}
+
+func (mr *pgsqlMfaRepository) RecordFailure(userID uuid.UUID) (err error) {
+	qCmd := `UPDATE user_mfa SET failed_attempts = failed_attempts + 1, last_failed_at = $1 WHERE user_id = $2`
+	_, err = mr.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), userID)
+
+	return
+}
+
+func (mr *pgsqlMfaRepository) Delete(userID uuid.UUID) (err error) {
+	tx, err := mr.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM user_mfa_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM user_mfa WHERE user_id = $1`, userID)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func replaceRecoveryCodes(tx pgx.Tx, userID uuid.UUID, recoveryCodeHashes []string) (err error) {
+	_, err = tx.Exec(context.Background(), `DELETE FROM user_mfa_recovery_codes WHERE user_id = $1`, userID)
+	if err != nil {
+		return
+	}
+
+	now := time.Now().Unix()
+	for _, codeHash := range recoveryCodeHashes {
+		qCmd := `INSERT INTO user_mfa_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, $3)`
+		_, err = tx.Exec(context.Background(), qCmd, userID, codeHash, now)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
@@ -0,0 +1,62 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+// oidcStateRetention seconds after which abandoned pending logins are deleted
+const oidcStateRetention = 24 * 60 * 60
+
+type pgsqlOidcRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlOidcRepository will create an object that represent the oidc Repository interface
+func NewPgsqlOidcRepository(conn *pgxpool.Pool) domain.OidcRepository {
+	return &pgsqlOidcRepository{Conn: conn}
+}
+
+func (or *pgsqlOidcRepository) SaveState(s *domain.OidcLoginState) (err error) {
+	// sekalian bersihkan login yang tidak pernah diselesaikan
+	_, err = or.Conn.Exec(context.Background(), `DELETE FROM oidc_login_states WHERE created_at < $1`, time.Now().Unix()-oidcStateRetention)
+	if err != nil {
+		return
+	}
+
+	qStr := `INSERT INTO oidc_login_states (state, provider, code_verifier, nonce, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err = or.Conn.Exec(context.Background(), qStr, s.State, s.Provider, s.CodeVerifier, s.Nonce, s.CreatedAt)
+
+	return
+}
+
+func (or *pgsqlOidcRepository) ConsumeState(state string) (s domain.OidcLoginState, err error) {
+	qStr := `DELETE FROM oidc_login_states WHERE state = $1 RETURNING state, provider, code_verifier, nonce, created_at`
+	err = or.Conn.QueryRow(context.Background(), qStr, state).Scan(&s.State, &s.Provider, &s.CodeVerifier, &s.Nonce, &s.CreatedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "login state not found"}
+	}
+
+	return
+}
+
+func (or *pgsqlOidcRepository) GetIdentity(provider, subject string) (i domain.UserIdentity, err error) {
+	qStr := `SELECT provider, subject, user_id, email, created_at FROM user_identities WHERE provider = $1 AND subject = $2`
+	err = or.Conn.QueryRow(context.Background(), qStr, provider, subject).Scan(&i.Provider, &i.Subject, &i.UserID, &i.Email, &i.CreatedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "identity not linked"}
+	}
+
+	return
+}
+
+func (or *pgsqlOidcRepository) LinkIdentity(i *domain.UserIdentity) (err error) {
+	qStr := `INSERT INTO user_identities (provider, subject, user_id, email, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err = or.Conn.Exec(context.Background(), qStr, i.Provider, i.Subject, i.UserID, i.Email, i.CreatedAt)
+
+	return
+}
@@ -0,0 +1,200 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgsqlOrganizationRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlOrganizationRepository will create an object that represent the organization Repository interface
+func NewPgsqlOrganizationRepository(conn *pgxpool.Pool) domain.OrganizationRepository {
+	return &pgsqlOrganizationRepository{Conn: conn}
+}
+
+func (or *pgsqlOrganizationRepository) Create(o *domain.Organization, ownerID uuid.UUID, ownerRole string) (err error) {
+	var slugExists bool
+
+	err = or.Conn.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM organizations WHERE slug = $1)`, o.Slug).Scan(&slugExists)
+	if err != nil {
+		return
+	}
+	if slugExists {
+		return domain.DataValidationError{Field: "slug", Message: "slug already taken"}
+	}
+
+	tx, err := or.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	qStr := `INSERT INTO organizations (slug, name, created_by, created_at, updated_at) VALUES ($1,$2,$3,$4,$4) returning id`
+	err = tx.QueryRow(context.Background(), qStr, o.Slug, o.Name, ownerID, o.CreatedAt).Scan(&o.ID)
+	if err != nil {
+		return
+	}
+
+	qMember := `INSERT INTO organization_members (organization_id, user_id, role_name, created_at) VALUES ($1,$2,NULLIF($3, ''),$4)`
+	_, err = tx.Exec(context.Background(), qMember, o.ID, ownerID, ownerRole, o.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (or *pgsqlOrganizationRepository) GetBySlug(slug string) (o domain.Organization, err error) {
+	qStr := `SELECT id, slug, name, created_at FROM organizations WHERE slug = $1`
+	err = or.Conn.QueryRow(context.Background(), qStr, slug).Scan(&o.ID, &o.Slug, &o.Name, &o.CreatedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "organization not found"}
+	}
+
+	return
+}
+
+func (or *pgsqlOrganizationRepository) FetchMemberships(userID uuid.UUID) (memberships []domain.Membership, err error) {
+	qStr := `SELECT o.id, o.slug, o.name, o.created_at, COALESCE(m.role_name, '')
+		FROM organization_members m JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1 ORDER BY m.created_at, o.slug`
+	rows, err := or.Conn.Query(context.Background(), qStr, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m domain.Membership
+		err = rows.Scan(&m.ID, &m.Slug, &m.Name, &m.CreatedAt, &m.Role)
+		if err != nil {
+			return
+		}
+		memberships = append(memberships, m)
+	}
+
+	return memberships, rows.Err()
+}
+
+func (or *pgsqlOrganizationRepository) GetMembership(userID, organizationID uuid.UUID) (m domain.Membership, err error) {
+	qStr := `SELECT o.id, o.slug, o.name, o.created_at, COALESCE(m.role_name, '')
+		FROM organization_members m JOIN organizations o ON o.id = m.organization_id
+		WHERE m.user_id = $1 AND m.organization_id = $2`
+	err = or.Conn.QueryRow(context.Background(), qStr, userID, organizationID).Scan(&m.ID, &m.Slug, &m.Name, &m.CreatedAt, &m.Role)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "organization not found"}
+	}
+
+	return
+}
+
+func (or *pgsqlOrganizationRepository) FetchMembers(organizationID uuid.UUID) (members []domain.OrganizationMember, err error) {
+	qStr := `SELECT u.id, u.username, u.email, COALESCE(m.role_name, ''), m.created_at
+		FROM organization_members m JOIN "user" u ON u.id = m.user_id
+		WHERE m.organization_id = $1 ORDER BY u.username`
+	rows, err := or.Conn.Query(context.Background(), qStr, organizationID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var member domain.OrganizationMember
+		err = rows.Scan(&member.UserID, &member.Username, &member.Email, &member.Role, &member.CreatedAt)
+		if err != nil {
+			return
+		}
+		members = append(members, member)
+	}
+
+	return members, rows.Err()
+}
+
+func (or *pgsqlOrganizationRepository) GetMember(organizationID, userID uuid.UUID) (member domain.OrganizationMember, err error) {
+	qStr := `SELECT u.id, u.username, u.email, COALESCE(m.role_name, ''), m.created_at
+		FROM organization_members m JOIN "user" u ON u.id = m.user_id
+		WHERE m.organization_id = $1 AND m.user_id = $2`
+	err = or.Conn.QueryRow(context.Background(), qStr, organizationID, userID).Scan(&member.UserID, &member.Username, &member.Email, &member.Role, &member.CreatedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "member not found"}
+	}
+
+	return
+}
+
+func (or *pgsqlOrganizationRepository) UpsertMember(organizationID, userID uuid.UUID, role string) (err error) {
+	tx, err := or.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	err = keepOrganizationManager(tx, organizationID, userID, role)
+	if err != nil {
+		return
+	}
+
+	qCmd := `INSERT INTO organization_members (organization_id, user_id, role_name, created_at) VALUES ($1,$2,NULLIF($3, ''),$4)
+		ON CONFLICT (organization_id, user_id) DO UPDATE SET role_name = EXCLUDED.role_name`
+	_, err = tx.Exec(context.Background(), qCmd, organizationID, userID, role, time.Now().Unix())
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (or *pgsqlOrganizationRepository) RemoveMember(organizationID, userID uuid.UUID) (rowsAffected int64, err error) {
+	tx, err := or.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	err = keepOrganizationManager(tx, organizationID, userID, "")
+	if err != nil {
+		return
+	}
+
+	commandTag, err := tx.Exec(context.Background(), `DELETE FROM organization_members WHERE organization_id = $1 AND user_id = $2`, organizationID, userID)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected(), tx.Commit(context.Background())
+}
+
+// keepOrganizationManager refuses to leave the organization without a member granted role:manage when the member
+// is removed or given the role, "" for none. The organization row stays locked until the transaction ends,
+// concurrent changes of its members are checked one after the other.
+func keepOrganizationManager(tx pgx.Tx, organizationID, userID uuid.UUID, role string) (err error) {
+	_, err = tx.Exec(context.Background(), `SELECT 1 FROM organizations WHERE id = $1 FOR UPDATE`, organizationID)
+	if err != nil {
+		return
+	}
+
+	var isManager, staysManager, otherManagers bool
+	qStr := `SELECT
+		EXISTS(SELECT 1 FROM organization_members m JOIN role_permissions rp ON rp.role_name = m.role_name
+			WHERE m.organization_id = $1 AND m.user_id = $2 AND rp.permission_name = $4),
+		EXISTS(SELECT 1 FROM role_permissions WHERE role_name = $3 AND permission_name = $4),
+		EXISTS(SELECT 1 FROM organization_members m JOIN role_permissions rp ON rp.role_name = m.role_name
+			WHERE m.organization_id = $1 AND m.user_id <> $2 AND rp.permission_name = $4)`
+	err = tx.QueryRow(context.Background(), qStr, organizationID, userID, role, domain.ConstPermissionRoleManage).Scan(&isManager, &staysManager, &otherManagers)
+	if err != nil {
+		return
+	}
+
+	if isManager && !staysManager && !otherManagers {
+		return domain.ConflictError{Field: "role", Message: "the organization must keep a member with the " + domain.ConstPermissionRoleManage + " permission, grant it to another member first"}
+	}
+
+	return
+}
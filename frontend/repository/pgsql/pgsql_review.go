@@ -0,0 +1,224 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"strconv"
+	"time"
+)
+
+// reviewColumns columns selected by scanReview, book_reviews is aliased as r and the reviewer as u
+const reviewColumns = `r.id, r.book_id, r.user_id, u.username, r.rating, r.body, r.created_at, r.updated_at`
+
+// reviewFrom table expression used together with reviewColumns
+const reviewFrom = `book_reviews r JOIN "user" u ON u.id = r.user_id`
+
+type pgsqlReviewRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlReviewRepository will create an object that represent the review Repository interface
+func NewPgsqlReviewRepository(conn *pgxpool.Pool) domain.ReviewRepository {
+	return &pgsqlReviewRepository{Conn: conn}
+}
+
+func (rr *pgsqlReviewRepository) Fetch(organizationID uuid.UUID, bookID, perPage, page int) (reviews []domain.Review, totalCount, pageCount, currentPage int, err error) {
+	err = inOrganization(rr.Conn, organizationID, func(tx pgx.Tx) error {
+		var exists bool
+
+		err := tx.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM books WHERE id = $1 AND organization_id = $2 AND status = $3)`, bookID, organizationID, domain.ConstBookStatusPublished).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+		}
+
+		err = tx.QueryRow(context.Background(), `SELECT COUNT(*) FROM book_reviews WHERE book_id = $1`, bookID).Scan(&totalCount)
+		if err != nil {
+			return err
+		}
+
+		pageCount = (totalCount + perPage - 1) / perPage
+		if page > pageCount {
+			page = pageCount
+		}
+		if page < 1 {
+			page = 1
+		}
+
+		qStr := `SELECT ` + reviewColumns + ` FROM ` + reviewFrom + ` WHERE r.book_id = $1 ORDER BY r.created_at DESC, r.id DESC LIMIT $2 OFFSET $3`
+		rows, err := tx.Query(context.Background(), qStr, bookID, perPage, perPage*(page-1))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var r domain.Review
+			r, err = scanReview(rows)
+			if err != nil {
+				return err
+			}
+			reviews = append(reviews, r)
+		}
+
+		return rows.Err()
+	})
+
+	return reviews, totalCount, pageCount, page, err
+}
+
+func (rr *pgsqlReviewRepository) Create(organizationID uuid.UUID, bookID int, rf *domain.ReviewForm, ac domain.AuditContext) (r domain.Review, err error) {
+	err = inOrganization(rr.Conn, organizationID, func(tx pgx.Tx) error {
+		var id int
+		var exists bool
+
+		err := lockBookForRating(tx, organizationID, bookID, true)
+		if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM book_reviews WHERE book_id = $1 AND user_id = $2)`, bookID, ac.ActorID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return domain.DataValidationError{Field: "book_id", Message: "you have already reviewed this book"}
+		}
+
+		ts := time.Now().Unix()
+		qStr := `INSERT INTO book_reviews (book_id, user_id, organization_id, rating, body, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$6) returning id`
+		err = tx.QueryRow(context.Background(), qStr, bookID, ac.ActorID, organizationID, rf.Rating, rf.Body, ts).Scan(&id)
+		if err != nil {
+			return err
+		}
+
+		err = refreshBookRating(tx, bookID)
+		if err != nil {
+			return err
+		}
+
+		r, err = getReview(tx, bookID, ac.ActorID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditReviewCreate, domain.ConstAuditResourceReview, strconv.Itoa(id), nil, r)
+	})
+
+	return
+}
+
+func (rr *pgsqlReviewRepository) Update(organizationID uuid.UUID, bookID int, rf *domain.ReviewForm, ac domain.AuditContext) (r domain.Review, err error) {
+	err = inOrganization(rr.Conn, organizationID, func(tx pgx.Tx) error {
+		err := lockBookForRating(tx, organizationID, bookID, true)
+		if err != nil {
+			return err
+		}
+
+		before, err := getReview(tx, bookID, ac.ActorID)
+		if err != nil {
+			return err
+		}
+
+		qCmd := `UPDATE book_reviews SET rating = $1, body = $2, updated_at = $3 WHERE id = $4`
+		_, err = tx.Exec(context.Background(), qCmd, rf.Rating, rf.Body, time.Now().Unix(), before.ID)
+		if err != nil {
+			return err
+		}
+
+		err = refreshBookRating(tx, bookID)
+		if err != nil {
+			return err
+		}
+
+		r, err = getReview(tx, bookID, ac.ActorID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditReviewUpdate, domain.ConstAuditResourceReview, strconv.Itoa(r.ID), before, r)
+	})
+
+	return
+}
+
+func (rr *pgsqlReviewRepository) Delete(organizationID uuid.UUID, bookID int, ac domain.AuditContext) (err error) {
+	err = inOrganization(rr.Conn, organizationID, func(tx pgx.Tx) error {
+		err := lockBookForRating(tx, organizationID, bookID, false)
+		if err != nil {
+			return err
+		}
+
+		before, err := getReview(tx, bookID, ac.ActorID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(context.Background(), `DELETE FROM book_reviews WHERE id = $1`, before.ID)
+		if err != nil {
+			return err
+		}
+
+		err = refreshBookRating(tx, bookID)
+		if err != nil {
+			return err
+		}
+
+		return recordAudit(tx, ac, domain.ConstAuditReviewDelete, domain.ConstAuditResourceReview, strconv.Itoa(before.ID), before, nil)
+	})
+
+	return
+}
+
+// lockBookForRating locks the book until the transaction ends, so concurrent review writes recompute its rating one after another.
+// With publishedOnly other books are not found, so only published books are reviewed. A review is still deleted after its
+// book was unpublished.
+func lockBookForRating(tx pgx.Tx, organizationID uuid.UUID, bookID int, publishedOnly bool) (err error) {
+	var id int
+
+	qStr := `SELECT id FROM books WHERE id = $1 AND organization_id = $2 FOR UPDATE`
+	args := []interface{}{bookID, organizationID}
+	if publishedOnly {
+		qStr = `SELECT id FROM books WHERE id = $1 AND organization_id = $2 AND status = $3 FOR UPDATE`
+		args = append(args, domain.ConstBookStatusPublished)
+	}
+
+	err = tx.QueryRow(context.Background(), qStr, args...).Scan(&id)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+	}
+
+	return
+}
+
+// refreshBookRating recomputes the denormalized rating of the book from its reviews.
+func refreshBookRating(tx pgx.Tx, bookID int) (err error) {
+	qCmd := `UPDATE books SET
+		rating_average = COALESCE((SELECT ROUND(AVG(rating), 2) FROM book_reviews WHERE book_id = $1), 0),
+		rating_count = (SELECT COUNT(*) FROM book_reviews WHERE book_id = $1)
+		WHERE id = $1`
+	_, err = tx.Exec(context.Background(), qCmd, bookID)
+
+	return
+}
+
+func getReview(tx pgx.Tx, bookID int, userID uuid.UUID) (domain.Review, error) {
+	r, err := scanReview(tx.QueryRow(context.Background(), `SELECT `+reviewColumns+` FROM `+reviewFrom+` WHERE r.book_id = $1 AND r.user_id = $2`, bookID, userID))
+	if err == pgx.ErrNoRows {
+		return r, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "review not found"}
+	}
+
+	return r, err
+}
+
+// scanReview scans a row selected with reviewColumns.
+func scanReview(row pgx.Row) (r domain.Review, err error) {
+	err = row.Scan(&r.ID, &r.BookID, &r.UserID, &r.Username, &r.Rating, &r.Body, &r.CreatedAt, &r.UpdatedAt)
+	return
+}
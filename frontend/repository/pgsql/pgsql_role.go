@@ -0,0 +1,108 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+type pgsqlRoleRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlRoleRepository will create an object that represent the role Repository interface
+func NewPgsqlRoleRepository(conn *pgxpool.Pool) domain.RoleRepository {
+	return &pgsqlRoleRepository{Conn: conn}
+}
+
+func (rr *pgsqlRoleRepository) Fetch() (roles []domain.Role, err error) {
+	qStr := `SELECT r.name, r.description, COALESCE(array_agg(rp.permission_name ORDER BY rp.permission_name) FILTER (WHERE rp.permission_name IS NOT NULL), '{}')
+		FROM roles r LEFT JOIN role_permissions rp ON rp.role_name = r.name
+		GROUP BY r.name, r.description ORDER BY r.name`
+	rows, err := rr.Conn.Query(context.Background(), qStr)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r domain.Role
+		err = rows.Scan(&r.Name, &r.Description, &r.Permissions)
+		if err != nil {
+			return
+		}
+		roles = append(roles, r)
+	}
+
+	return roles, rows.Err()
+}
+
+func (rr *pgsqlRoleRepository) GetUserRoles(userID uuid.UUID) (roles []string, err error) {
+	qStr := `SELECT role_name FROM user_roles WHERE user_id = $1 ORDER BY role_name`
+	rows, err := rr.Conn.Query(context.Background(), qStr, userID)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var role string
+		err = rows.Scan(&role)
+		if err != nil {
+			return
+		}
+		roles = append(roles, role)
+	}
+
+	return roles, rows.Err()
+}
+
+func (rr *pgsqlRoleRepository) Grant(userID uuid.UUID, role string) (err error) {
+	var roleExists, userExists bool
+
+	err = rr.Conn.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM roles WHERE name = $1)`, role).Scan(&roleExists)
+	if err != nil {
+		return
+	}
+	if !roleExists {
+		return domain.DataValidationError{Field: "role", Message: "unknown role"}
+	}
+
+	err = rr.Conn.QueryRow(context.Background(), `SELECT EXISTS(SELECT 1 FROM "user" WHERE id = $1)`, userID).Scan(&userExists)
+	if err != nil {
+		return
+	}
+	if !userExists {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+
+	qCmd := `INSERT INTO user_roles (user_id, role_name, created_at) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING`
+	_, err = rr.Conn.Exec(context.Background(), qCmd, userID, role, time.Now().Unix())
+
+	return
+}
+
+func (rr *pgsqlRoleRepository) GrantByEmail(email, role string) (rowsAffected int64, err error) {
+	qCmd := `INSERT INTO user_roles (user_id, role_name, created_at)
+		SELECT id, $2, $3 FROM "user" WHERE LOWER(email) = LOWER($1) AND status = $4
+		ON CONFLICT DO NOTHING`
+	res, err := rr.Conn.Exec(context.Background(), qCmd, email, role, time.Now().Unix(), domain.ConstUserStatusActive)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (rr *pgsqlRoleRepository) Revoke(userID uuid.UUID, role string) (rowsAffected int64, err error) {
+	qCmd := `DELETE FROM user_roles WHERE user_id = $1 AND role_name = $2`
+	res, err := rr.Conn.Exec(context.Background(), qCmd, userID, role)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
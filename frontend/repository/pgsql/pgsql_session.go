@@ -0,0 +1,98 @@
+package pgsql
+
+import (
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"time"
+)
+
+// sessionTouchInterval minimum seconds between two updates of last_seen_at of the same session
+const sessionTouchInterval = 60
+
+const sessionColumns = `id, user_id, user_agent, ip, created_at, last_seen_at, expires_at, revoked_at`
+
+type pgsqlSessionRepository struct {
+	Conn *pgxpool.Pool
+}
+
+// NewPgsqlSessionRepository will create an object that represent the session Repository interface
+func NewPgsqlSessionRepository(conn *pgxpool.Pool) domain.SessionRepository {
+	return &pgsqlSessionRepository{Conn: conn}
+}
+
+func (sr *pgsqlSessionRepository) Create(s *domain.Session) (err error) {
+	qStr := `INSERT INTO user_sessions (user_id, user_agent, ip, created_at, last_seen_at, expires_at) VALUES ($1,$2,$3,$4,$5,$6) returning id`
+	return sr.Conn.QueryRow(context.Background(), qStr, s.UserID, s.UserAgent, s.IP, s.CreatedAt, s.LastSeenAt, s.ExpiresAt).Scan(&s.ID)
+}
+
+func (sr *pgsqlSessionRepository) Fetch(userID uuid.UUID) (sessions []domain.Session, err error) {
+	qStr := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE user_id = $1 AND revoked_at = 0 AND expires_at > $2 ORDER BY last_seen_at DESC`
+	rows, err := sr.Conn.Query(context.Background(), qStr, userID, time.Now().Unix())
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var s domain.Session
+		s, err = scanSession(rows)
+		if err != nil {
+			return
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (sr *pgsqlSessionRepository) GetByID(id uuid.UUID) (s domain.Session, err error) {
+	qStr := `SELECT ` + sessionColumns + ` FROM user_sessions WHERE id = $1`
+	s, err = scanSession(sr.Conn.QueryRow(context.Background(), qStr, id))
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "session not found"}
+	}
+
+	return
+}
+
+func (sr *pgsqlSessionRepository) Revoke(userID, id uuid.UUID) (rowsAffected int64, err error) {
+	qCmd := `UPDATE user_sessions SET revoked_at = $1 WHERE id = $2 AND user_id = $3 AND revoked_at = 0`
+	commandTag, err := sr.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), id, userID)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (sr *pgsqlSessionRepository) RevokeAll(userID uuid.UUID) (err error) {
+	qCmd := `UPDATE user_sessions SET revoked_at = $1 WHERE user_id = $2 AND revoked_at = 0`
+	_, err = sr.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), userID)
+
+	return
+}
+
+func (sr *pgsqlSessionRepository) RevokeAllExcept(userID, keepID uuid.UUID) (err error) {
+	qCmd := `UPDATE user_sessions SET revoked_at = $1 WHERE user_id = $2 AND id <> $3 AND revoked_at = 0`
+	_, err = sr.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), userID, keepID)
+
+	return
+}
+
+func (sr *pgsqlSessionRepository) Touch(id uuid.UUID, ip string) (err error) {
+	ts := time.Now().Unix()
+	qCmd := `UPDATE user_sessions SET last_seen_at = $1, ip = $2 WHERE id = $3 AND (last_seen_at < $4 OR ip <> $2)`
+	_, err = sr.Conn.Exec(context.Background(), qCmd, ts, ip, id, ts-sessionTouchInterval)
+
+	return
+}
+
+// scanSession scans a row selected with sessionColumns.
+func scanSession(row pgx.Row) (s domain.Session, err error) {
+	err = row.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &s.RevokedAt)
+	return
+}
@@ -2,16 +2,29 @@ package pgsql
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/cooljar/go-postgres-fiber/domain"
 	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 	"math/rand"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// emailChangeSecretLifetime how long (in seconds) an email change secret code stays valid
+const emailChangeSecretLifetime = 30 * 60
+
+// deletedEmailDomain reserved domain used for placeholder email of deleted accounts
+const deletedEmailDomain = "@deleted.invalid"
+
+// releasedIdentitySet replaces username and email of a deleted account with unique placeholders
+const releasedIdentitySet = `username = 'deleted_' || replace(id::text, '-', ''), username_skeleton = 'deleted_' || replace(id::text, '-', ''), email = replace(id::text, '-', '') || '` + deletedEmailDomain + `'`
+
 type pgsqlUserRepository struct {
 	Conn *pgxpool.Pool
 }
@@ -46,29 +59,27 @@ func (ur *pgsqlUserRepository) RequestSecret(email string) (secret string, err e
 	return
 }
 
-func (ur *pgsqlUserRepository) Signup(sf *domain.SignupForm) (err error) {
-	var secretCodeExist, usernameExists, emailExists bool
-	var verificationToken, passwordResetToken, authKey, passwordHash string
+func (ur *pgsqlUserRepository) Signup(sf *domain.SignupForm, passwordHash string, ac domain.AuditContext) (err error) {
+	var secretCodeExist, emailExists bool
+	var verificationToken, passwordResetToken, authKey string
 
-	// periksa apakah secret code email validation valid
-	qSecret := `SELECT EXISTS(SELECT 1 FROM email_validation WHERE email = $1 AND secret_code = $2)`
-	err = ur.Conn.QueryRow(context.Background(), qSecret, sf.Email, sf.EmailSecretCode).Scan(&secretCodeExist)
-	if err != nil {
-		return
-	}
-	if !secretCodeExist {
-		err = domain.DataValidationError{Field: "email_secret_code", Message: "Invalid secret code"}
-		return
+	// periksa apakah secret code email validation valid, email undangan sudah terbukti dari token undangan
+	if sf.InvitationID == uuid.Nil {
+		qSecret := `SELECT EXISTS(SELECT 1 FROM email_validation WHERE email = $1 AND secret_code = $2)`
+		err = ur.Conn.QueryRow(context.Background(), qSecret, sf.Email, sf.EmailSecretCode).Scan(&secretCodeExist)
+		if err != nil {
+			return
+		}
+		if !secretCodeExist {
+			err = domain.DataValidationError{Field: "email_secret_code", Message: "Invalid secret code"}
+			return
+		}
 	}
 
-	usernameExists, err = isExistByUsername(ur.Conn, sf.Username)
+	err = checkUsernameAvailable(ur.Conn, sf.Username, uuid.Nil)
 	if err != nil {
 		return
 	}
-	if usernameExists {
-		err = domain.DataValidationError{Field: "username", Message: "username already taken"}
-		return
-	}
 
 	emailExists, err = isExistByEmail(ur.Conn, sf.Email)
 	if err != nil {
@@ -82,11 +93,6 @@ func (ur *pgsqlUserRepository) Signup(sf *domain.SignupForm) (err error) {
 	ts := time.Now().Unix()
 	now := int(ts)
 
-	passwordHash, err = generatePasswordHash(sf.Password)
-	if err != nil {
-		return
-	}
-
 	verificationToken, err = utils.GenerateRandomString(16)
 	if err != nil {
 		return
@@ -106,34 +112,72 @@ func (ur *pgsqlUserRepository) Signup(sf *domain.SignupForm) (err error) {
 	passwordResetToken = passwordResetToken + "_" + strconv.Itoa(now)
 
 	user := domain.User{
-		uuid.New(),
-		sf.Username,
-		sf.FullName,
-		authKey,
-		passwordHash,
-		passwordResetToken,
-		verificationToken,
-		sf.Email,
-		domain.ConstUserStatusActive,
-		now,
-		now,
+		ID:                 uuid.New(),
+		Username:           sf.Username,
+		FullName:           sf.FullName,
+		AuthKey:            authKey,
+		PasswordHash:       passwordHash,
+		PasswordResetToken: passwordResetToken,
+		VerificationToken:  verificationToken,
+		Email:              sf.Email,
+		Status:             domain.ConstUserStatusActive,
+		CreatedAt:          now,
+		UpdatedAt:          now,
 	}
 
-	qStr := `insert into "user" (id, username, full_name, auth_key, password_hash, password_reset_token, verification_token, email, status, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
-	_, err = ur.Conn.Exec(context.Background(), qStr, user.ID, user.Username, user.FullName, user.AuthKey, user.PasswordHash, user.PasswordResetToken, user.VerificationToken, user.Email, user.Status, user.CreatedAt, user.UpdatedAt)
+	qStr := `insert into "user" (id, username, username_skeleton, full_name, auth_key, password_hash, password_reset_token, verification_token, email, status, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) returning id`
+
+	tx, err := ur.Conn.Begin(context.Background())
 	if err != nil {
 		return
 	}
+	defer tx.Rollback(context.Background())
 
-	return
+	_, err = tx.Exec(context.Background(), qStr, user.ID, user.Username, utils.UsernameSkeleton(user.Username), user.FullName, user.AuthKey, user.PasswordHash, user.PasswordResetToken, user.VerificationToken, user.Email, user.Status, user.CreatedAt, user.UpdatedAt)
+	if err != nil {
+		return
+	}
+
+	if sf.InvitationID != uuid.Nil {
+		// undangan hanya bisa dipakai sekali
+		qAccept := `UPDATE user_invitations SET accepted_at = $1, accepted_by = $2 WHERE id = $3 AND accepted_at = 0 AND revoked_at = 0`
+		commandTag, err := tx.Exec(context.Background(), qAccept, now, user.ID, sf.InvitationID)
+		if err != nil {
+			return err
+		}
+		if commandTag.RowsAffected() != 1 {
+			return domain.ErrInvitationInvalid
+		}
+
+		// role dari undangan diberikan di transaksi yang sama, akun tidak pernah ada tanpa role-nya
+		qGrant := `INSERT INTO user_roles (user_id, role_name, created_at)
+			SELECT $1, role, $2 FROM user_invitations WHERE id = $3 AND role <> ''
+			ON CONFLICT DO NOTHING`
+		_, err = tx.Exec(context.Background(), qGrant, user.ID, now, sf.InvitationID)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = recordAccountCreated(tx, ac, &user)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
 }
 
 func (ur *pgsqlUserRepository) Login(lf *domain.LoginForm) (u domain.User, err error) {
 	var id uuid.UUID
 	var username, email, fullName, passwordHash string
+	var status, lockedUntil int
 
-	qStr := `SELECT id, username, email, full_name, password_hash FROM "user" WHERE email = $1 AND status = $2`
-	err = ur.Conn.QueryRow(context.Background(), qStr, lf.Email, domain.ConstUserStatusActive).Scan(&id, &username, &email, &fullName, &passwordHash)
+	// status diperiksa di usecase agar akun nonaktif/terhapus mendapat pesan error yang jelas
+	qStr := `SELECT id, username, email, full_name, password_hash, status, locked_until FROM "user" WHERE email = $1`
+	err = ur.Conn.QueryRow(context.Background(), qStr, lf.Email).Scan(&id, &username, &email, &fullName, &passwordHash, &status, &lockedUntil)
+	if err == pgx.ErrNoRows {
+		return u, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
 	if err != nil {
 		return
 	}
@@ -143,10 +187,450 @@ func (ur *pgsqlUserRepository) Login(lf *domain.LoginForm) (u domain.User, err e
 	u.Email = email
 	u.FullName = fullName
 	u.PasswordHash = passwordHash
+	u.Status = status
+	u.LockedUntil = lockedUntil
 
 	return u, nil
 }
 
+func (ur *pgsqlUserRepository) GetByID(userId uuid.UUID) (u domain.User, err error) {
+	qStr := `SELECT id, username, full_name, auth_key, password_hash, email, status, created_at, updated_at, deleted_at FROM "user" WHERE id = $1`
+	err = ur.Conn.QueryRow(context.Background(), qStr, userId).Scan(&u.ID, &u.Username, &u.FullName, &u.AuthKey, &u.PasswordHash, &u.Email, &u.Status, &u.CreatedAt, &u.UpdatedAt, &u.DeletedAt)
+	if err == pgx.ErrNoRows {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+
+	return
+}
+
+func (ur *pgsqlUserRepository) UpdateProfile(userId uuid.UUID, pf *domain.UpdateProfileForm, ac domain.AuditContext) (err error) {
+	var currentUsername, currentFullName string
+
+	err = ur.Conn.QueryRow(context.Background(), `SELECT username, full_name FROM "user" WHERE id = $1`, userId).Scan(&currentUsername, &currentFullName)
+	if err != nil {
+		return
+	}
+
+	if pf.Username != currentUsername {
+		err = checkUsernameAvailable(ur.Conn, pf.Username, userId)
+		if err != nil {
+			return
+		}
+	}
+
+	tx, err := ur.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	qCmd := `UPDATE "user" SET username = $1, username_skeleton = $2, full_name = $3, updated_at = $4 WHERE id = $5`
+	_, err = tx.Exec(context.Background(), qCmd, pf.Username, utils.UsernameSkeleton(pf.Username), pf.FullName, time.Now().Unix(), userId)
+	if err != nil {
+		return
+	}
+
+	before := map[string]interface{}{"username": currentUsername, "full_name": currentFullName}
+	after := map[string]interface{}{"username": pf.Username, "full_name": pf.FullName}
+	err = recordAudit(tx, ac, domain.ConstAuditUserUpdateProfile, domain.ConstAuditResourceUser, userId.String(), before, after)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (ur *pgsqlUserRepository) UpdatePassword(userId uuid.UUID, passwordHash string, ac *domain.AuditContext) (err error) {
+	tx, err := ur.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	qCmd := `UPDATE "user" SET password_hash = $1, updated_at = $2 WHERE id = $3`
+	_, err = tx.Exec(context.Background(), qCmd, passwordHash, time.Now().Unix(), userId)
+	if err != nil {
+		return
+	}
+
+	// hash tidak pernah dicatat, cukup bahwa password diganti
+	if ac != nil {
+		err = recordAudit(tx, *ac, domain.ConstAuditUserChangePassword, domain.ConstAuditResourceUser, userId.String(), nil, nil)
+		if err != nil {
+			return
+		}
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (ur *pgsqlUserRepository) RequestEmailChange(userId uuid.UUID, email string) (secret string, err error) {
+	var emailExist bool
+	emailExist, err = isExistByEmail(ur.Conn, email)
+	if err != nil {
+		return
+	}
+	if emailExist {
+		return secret, domain.DataValidationError{Field: "email", Message: "email address already taken"}
+	}
+
+	secret, err = utils.GenerateRandomNumericString(6)
+	if err != nil {
+		return
+	}
+
+	qStr := `INSERT INTO email_change (user_id,new_email,secret_code,created_at) VALUES($1,$2,$3,$4) ON CONFLICT ON CONSTRAINT email_change_pkey DO UPDATE SET new_email = EXCLUDED.new_email, secret_code = EXCLUDED.secret_code, created_at = EXCLUDED.created_at`
+	commandTag, err := ur.Conn.Exec(context.Background(), qStr, userId, email, secret, time.Now().Unix())
+	if err != nil {
+		return
+	}
+	if commandTag.RowsAffected() != 1 {
+		return secret, domain.ErrSqlNoRowFoundToUpsert
+	}
+
+	return
+}
+
+func (ur *pgsqlUserRepository) ConfirmEmailChange(userId uuid.UUID, secret string, ac domain.AuditContext) (oldEmail, newEmail string, err error) {
+	var emailExist bool
+
+	tx, err := ur.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// secret code hanya berlaku selama emailChangeSecretLifetime
+	qSecret := `SELECT new_email FROM email_change WHERE user_id = $1 AND secret_code = $2 AND created_at >= $3 FOR UPDATE`
+	err = tx.QueryRow(context.Background(), qSecret, userId, secret, time.Now().Unix()-emailChangeSecretLifetime).Scan(&newEmail)
+	if err == pgx.ErrNoRows {
+		err = domain.DataValidationError{Field: "secret_code", Message: "Invalid or expired secret code"}
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	qExist := `SELECT EXISTS(SELECT 1 FROM "user" WHERE LOWER(email) = LOWER($1) AND id <> $2)`
+	err = tx.QueryRow(context.Background(), qExist, newEmail, userId).Scan(&emailExist)
+	if err != nil {
+		return
+	}
+	if emailExist {
+		err = domain.DataValidationError{Field: "email", Message: "email address already taken"}
+		return
+	}
+
+	err = tx.QueryRow(context.Background(), `SELECT email FROM "user" WHERE id = $1 FOR UPDATE`, userId).Scan(&oldEmail)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), `UPDATE "user" SET email = $1, updated_at = $2 WHERE id = $3`, newEmail, time.Now().Unix(), userId)
+	if err != nil {
+		return
+	}
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM email_change WHERE user_id = $1`, userId)
+	if err != nil {
+		return
+	}
+
+	err = recordAudit(tx, ac, domain.ConstAuditUserChangeEmail, domain.ConstAuditResourceUser, userId.String(), map[string]interface{}{"email": oldEmail}, map[string]interface{}{"email": newEmail})
+	if err != nil {
+		return
+	}
+
+	err = tx.Commit(context.Background())
+
+	return
+}
+
+func (ur *pgsqlUserRepository) MarkDeleted(userId uuid.UUID, releaseIdentity bool, ac domain.AuditContext) (err error) {
+	var status int
+
+	tx, err := ur.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	err = tx.QueryRow(context.Background(), `SELECT status FROM "user" WHERE id = $1 FOR UPDATE`, userId).Scan(&status)
+	if err == pgx.ErrNoRows {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+	if err != nil {
+		return
+	}
+
+	now := time.Now().Unix()
+	qCmd := `UPDATE "user" SET status = $1, deleted_at = $2, updated_at = $2 WHERE id = $3 AND status <> $1`
+	commandTag, err := tx.Exec(context.Background(), qCmd, domain.ConstUserStatusDeleted, now, userId)
+	if err != nil {
+		return
+	}
+	if commandTag.RowsAffected() != 1 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+
+	if releaseIdentity {
+		_, err = tx.Exec(context.Background(), `UPDATE "user" SET `+releasedIdentitySet+` WHERE id = $1`, userId)
+		if err != nil {
+			return
+		}
+	}
+
+	_, err = tx.Exec(context.Background(), `DELETE FROM email_change WHERE user_id = $1`, userId)
+	if err != nil {
+		return
+	}
+
+	err = recordAudit(tx, ac, domain.ConstAuditUserDelete, domain.ConstAuditResourceUser, userId.String(), map[string]interface{}{"status": status}, map[string]interface{}{"status": domain.ConstUserStatusDeleted})
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (ur *pgsqlUserRepository) AnonymizeDeleted(deletedBefore int64, releaseIdentity bool) (count int64, err error) {
+	// username & email dibebaskan, atau tetap dicadangkan (email disimpan sebagai digest)
+	identitySet := `email = encode(sha256(convert_to(LOWER(email), 'UTF8')), 'hex') || '` + deletedEmailDomain + `'`
+	if releaseIdentity {
+		identitySet = releasedIdentitySet
+	}
+
+	qCmd := `UPDATE "user" SET
+		full_name = '',
+		auth_key = '',
+		password_hash = '',
+		password_reset_token = 'deleted_prt_' || id::text,
+		verification_token = 'deleted_vt_' || id::text,
+		anonymized_at = $1,
+		` + identitySet + `
+		WHERE status = $2 AND deleted_at > 0 AND deleted_at <= $3 AND anonymized_at = 0`
+	commandTag, err := ur.Conn.Exec(context.Background(), qCmd, time.Now().Unix(), domain.ConstUserStatusDeleted, deletedBefore)
+	if err != nil {
+		return
+	}
+
+	// audit log tidak boleh menyimpan data pribadi akun yang sudah dianonimkan
+	qAudit := `UPDATE audit_log a SET before = NULL, after = NULL FROM "user" u
+		WHERE a.resource_type = $1 AND a.resource_id = u.id::text AND u.anonymized_at > 0 AND (a.before IS NOT NULL OR a.after IS NOT NULL)`
+	_, err = ur.Conn.Exec(context.Background(), qAudit, domain.ConstAuditResourceUser)
+	if err != nil {
+		return
+	}
+
+	// akun di identity provider tidak boleh lagi terhubung ke akun yang sudah dianonimkan
+	_, err = ur.Conn.Exec(context.Background(), `DELETE FROM user_identities ui USING "user" u WHERE u.id = ui.user_id AND u.anonymized_at > 0`)
+	if err != nil {
+		return
+	}
+
+	return commandTag.RowsAffected(), nil
+}
+
+func (ur *pgsqlUserRepository) UpdateStatus(userId uuid.UUID, status int, ac domain.AuditContext) (rowsAffected int64, err error) {
+	var currentStatus int
+
+	tx, err := ur.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// akun yang sudah dihapus tidak bisa diaktifkan kembali
+	qStatus := `SELECT status FROM "user" WHERE id = $1 AND status <> $2 FOR UPDATE`
+	err = tx.QueryRow(context.Background(), qStatus, userId, domain.ConstUserStatusDeleted).Scan(&currentStatus)
+	if err == pgx.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return
+	}
+
+	qCmd := `UPDATE "user" SET status = $1, updated_at = $2 WHERE id = $3`
+	res, err := tx.Exec(context.Background(), qCmd, status, time.Now().Unix(), userId)
+	if err != nil {
+		return 0, err
+	}
+
+	err = recordAudit(tx, ac, domain.ConstAuditUserSetStatus, domain.ConstAuditResourceUser, userId.String(), map[string]interface{}{"status": currentStatus}, map[string]interface{}{"status": status})
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(context.Background())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected(), nil
+}
+
+func (ur *pgsqlUserRepository) Lock(userId uuid.UUID, until int64) (locked bool, err error) {
+	// login gagal yang bersamaan hanya mengunci (dan mengirim email) sekali
+	qCmd := `UPDATE "user" SET locked_until = $1 WHERE id = $2 AND locked_until < $3`
+	res, err := ur.Conn.Exec(context.Background(), qCmd, until, userId, time.Now().Unix())
+	if err != nil {
+		return false, err
+	}
+
+	return res.RowsAffected() > 0, nil
+}
+
+func (ur *pgsqlUserRepository) GetByEmail(email string) (u domain.User, err error) {
+	qStr := `SELECT id FROM "user" WHERE LOWER(email) = LOWER($1)`
+	err = ur.Conn.QueryRow(context.Background(), qStr, email).Scan(&u.ID)
+	if err == pgx.ErrNoRows {
+		return u, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+	if err != nil {
+		return
+	}
+
+	return ur.GetByID(u.ID)
+}
+
+func (ur *pgsqlUserRepository) CreateExternal(u *domain.User, ac domain.AuditContext) (err error) {
+	var emailExists bool
+
+	emailExists, err = isExistByEmail(ur.Conn, u.Email)
+	if err != nil {
+		return
+	}
+	if emailExists {
+		return domain.DataValidationError{Field: "email", Message: "email address already taken"}
+	}
+
+	// username dari identity provider bisa bentrok, tambahkan angka di belakangnya
+	baseUsername := u.Username
+	for i := 2; ; i++ {
+		err = checkUsernameAvailable(ur.Conn, u.Username, uuid.Nil)
+		if err == nil {
+			break
+		}
+		if err != domain.ErrUsernameTaken && err != domain.ErrUsernameSimilar {
+			return
+		}
+		u.Username = baseUsername + strconv.Itoa(i)
+	}
+
+	ts := int(time.Now().Unix())
+
+	u.AuthKey, err = utils.GenerateRandomString(16)
+	if err != nil {
+		return
+	}
+
+	u.PasswordResetToken, err = utils.GenerateRandomString(16)
+	if err != nil {
+		return
+	}
+
+	u.VerificationToken, err = utils.GenerateRandomString(16)
+	if err != nil {
+		return
+	}
+
+	u.ID = uuid.New()
+	u.PasswordResetToken = u.PasswordResetToken + "_" + strconv.Itoa(ts)
+	u.VerificationToken = u.VerificationToken + "_" + strconv.Itoa(ts)
+	u.Status = domain.ConstUserStatusActive
+	u.CreatedAt = ts
+	u.UpdatedAt = ts
+
+	tx, err := ur.Conn.Begin(context.Background())
+	if err != nil {
+		return
+	}
+	defer tx.Rollback(context.Background())
+
+	// password_hash kosong tidak pernah cocok dengan password apapun
+	qStr := `insert into "user" (id, username, username_skeleton, full_name, auth_key, password_hash, password_reset_token, verification_token, email, status, created_at, updated_at) VALUES ($1,$2,$3,$4,$5,'',$6,$7,$8,$9,$10,$11)`
+	_, err = tx.Exec(context.Background(), qStr, u.ID, u.Username, utils.UsernameSkeleton(u.Username), u.FullName, u.AuthKey, u.PasswordResetToken, u.VerificationToken, u.Email, u.Status, u.CreatedAt, u.UpdatedAt)
+	if err != nil {
+		return
+	}
+
+	err = recordAccountCreated(tx, ac, u)
+	if err != nil {
+		return
+	}
+
+	return tx.Commit(context.Background())
+}
+
+func (ur *pgsqlUserRepository) CheckUsernameAvailable(username string) (err error) {
+	return checkUsernameAvailable(ur.Conn, username, uuid.Nil)
+}
+
+func (ur *pgsqlUserRepository) BackfillUsernameSkeletons() (count int64, err error) {
+	rows, err := ur.Conn.Query(context.Background(), `SELECT id, username FROM "user" WHERE username_skeleton = ''`)
+	if err != nil {
+		return
+	}
+
+	usernames := make(map[uuid.UUID]string)
+	for rows.Next() {
+		var id uuid.UUID
+		var username string
+		if err = rows.Scan(&id, &username); err != nil {
+			rows.Close()
+			return
+		}
+		usernames[id] = username
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return
+	}
+
+	for id, username := range usernames {
+		_, err = ur.Conn.Exec(context.Background(), `UPDATE "user" SET username_skeleton = $1 WHERE id = $2`, utils.UsernameSkeleton(username), id)
+		if err != nil {
+			return
+		}
+		count++
+	}
+
+	return
+}
+
+// checkUsernameAvailable rejects a username already taken or looking like the username of another account than exceptID.
+// recordAccountCreated records the signup of the account, an anonymous signup is attributed to the new account itself.
+func recordAccountCreated(tx pgx.Tx, ac domain.AuditContext, u *domain.User) error {
+	if ac.ActorID == uuid.Nil {
+		ac.ActorID = u.ID
+	}
+
+	after := map[string]interface{}{"username": u.Username, "full_name": u.FullName, "email": u.Email, "status": u.Status}
+	return recordAudit(tx, ac, domain.ConstAuditUserSignup, domain.ConstAuditResourceUser, u.ID.String(), nil, after)
+}
+
+func checkUsernameAvailable(conn *pgxpool.Pool, username string, exceptID uuid.UUID) (err error) {
+	usernameExists, err := isExistByUsername(conn, username)
+	if err != nil {
+		return
+	}
+	if usernameExists {
+		return domain.ErrUsernameTaken
+	}
+
+	var similarExists bool
+	qStr := `SELECT EXISTS(SELECT 1 FROM "user" WHERE username_skeleton = $1 AND id <> $2)`
+	err = conn.QueryRow(context.Background(), qStr, utils.UsernameSkeleton(username), exceptID).Scan(&similarExists)
+	if err != nil {
+		return
+	}
+	if similarExists {
+		return domain.ErrUsernameSimilar
+	}
+
+	return
+}
+
 func isExistByUsername(conn *pgxpool.Pool, username string) (exist bool, err error) {
 	qStr := `SELECT EXISTS(SELECT 1 FROM "user" WHERE username = $1)`
 	err = conn.QueryRow(context.Background(), qStr, username).Scan(&exist)
@@ -158,8 +642,8 @@ func isExistByUsername(conn *pgxpool.Pool, username string) (exist bool, err err
 }
 
 func isExistByEmail(conn *pgxpool.Pool, email string) (exist bool, err error) {
-	qStr := `SELECT EXISTS(SELECT 1 FROM "user" WHERE email = $1)`
-	err = conn.QueryRow(context.Background(), qStr, email).Scan(&exist)
+	qStr := `SELECT EXISTS(SELECT 1 FROM "user" WHERE email = $1 OR email = $2)`
+	err = conn.QueryRow(context.Background(), qStr, email, reservedEmailPlaceholder(email)).Scan(&exist)
 	if err != nil {
 		return false, err
 	}
@@ -167,7 +651,9 @@ func isExistByEmail(conn *pgxpool.Pool, email string) (exist bool, err error) {
 	return
 }
 
-func generatePasswordHash(password string) (string, error) {
-	bytesPwd, err := bcrypt.GenerateFromPassword([]byte(password), 14)
-	return string(bytesPwd), err
+// reservedEmailPlaceholder returns the value stored in place of the email address
+// of an anonymized account whose identity is kept reserved.
+func reservedEmailPlaceholder(email string) string {
+	digest := sha256.Sum256([]byte(strings.ToLower(email)))
+	return hex.EncodeToString(digest[:]) + deletedEmailDomain
 }
@@ -0,0 +1,250 @@
+package usecase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	// apiKeyPrefixLength length of the public part of a key, used for lookup
+	apiKeyPrefixLength = 8
+	// apiKeySecretLength length of the secret part of a key
+	apiKeySecretLength = 32
+	// apiKeyDefaultLifetimeDays lifetime of a key created without expires_in_days
+	apiKeyDefaultLifetimeDays = 90
+)
+
+type apiKeyUsecase struct {
+	apiKeyRepo     domain.APIKeyRepository
+	userRepo       domain.UserRepository
+	roleRepo       domain.RoleRepository
+	orgRepo        domain.OrganizationRepository
+	contextTimeout time.Duration
+}
+
+// NewAPIKeyUsecase will create new an apiKeyUsecase object representation of domain.APIKeyUsecase interface
+func NewAPIKeyUsecase(a domain.APIKeyRepository, u domain.UserRepository, r domain.RoleRepository, o domain.OrganizationRepository, timeout time.Duration) domain.APIKeyUsecase {
+	return &apiKeyUsecase{
+		apiKeyRepo:     a,
+		userRepo:       u,
+		roleRepo:       r,
+		orgRepo:        o,
+		contextTimeout: timeout,
+	}
+}
+
+func (au *apiKeyUsecase) Create(userID uuid.UUID, f *domain.APIKeyForm) (key domain.NewAPIKey, err error) {
+	u, err := au.userRepo.GetByID(userID)
+	if err != nil {
+		return
+	}
+	if u.AuthKey == "" {
+		return key, domain.DataValidationError{Field: "auth_key", Message: "account can not own API keys"}
+	}
+
+	// key tidak boleh memberi akses melebihi role pemiliknya
+	var organizationRole string
+	if f.OrganizationID != uuid.Nil {
+		var membership domain.Membership
+		membership, err = au.orgRepo.GetMembership(userID, f.OrganizationID)
+		if err != nil {
+			return
+		}
+		organizationRole = membership.Role
+	}
+
+	permissions, err := au.userPermissions(userID, organizationRole)
+	if err != nil {
+		return
+	}
+	for _, scope := range domain.AccountScopes {
+		permissions[scope] = true
+	}
+	for _, scope := range f.Scopes {
+		if !permissions[scope] {
+			return key, domain.DataValidationError{Field: "scopes", Message: "scope " + scope + " is not granted to your account"}
+		}
+	}
+
+	prefix, err := utils.GenerateRandomString(apiKeyPrefixLength)
+	if err != nil {
+		return
+	}
+	secret, err := utils.GenerateRandomString(apiKeySecretLength)
+	if err != nil {
+		return
+	}
+
+	lifetimeDays := f.ExpiresInDays
+	if lifetimeDays == 0 {
+		lifetimeDays = apiKeyDefaultLifetimeDays
+	}
+
+	now := time.Now()
+	key.APIKey = domain.APIKey{
+		Name:      f.Name,
+		Prefix:    prefix,
+		Scopes:    uniqueStrings(f.Scopes),
+		ExpiresAt: int(now.AddDate(0, 0, lifetimeDays).Unix()),
+		CreatedAt: int(now.Unix()),
+	}
+	if f.OrganizationID != uuid.Nil {
+		key.OrganizationID = &f.OrganizationID
+	}
+
+	err = au.apiKeyRepo.Create(userID, &key.APIKey, hashAPIKeySecret(u.AuthKey, secret))
+	if err != nil {
+		return
+	}
+
+	key.Key = domain.ConstAPIKeyPrefix + "_" + prefix + "_" + secret
+
+	return
+}
+
+func (au *apiKeyUsecase) Fetch(userID uuid.UUID) (keys []domain.APIKey, err error) {
+	keys, err = au.apiKeyRepo.Fetch(userID)
+	if keys == nil {
+		keys = []domain.APIKey{}
+	}
+
+	return
+}
+
+func (au *apiKeyUsecase) Revoke(userID, keyID uuid.UUID) (err error) {
+	rowsAffected, err := au.apiKeyRepo.Revoke(userID, keyID)
+	if err != nil {
+		return
+	}
+	if rowsAffected < 1 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "api key not found"}
+	}
+
+	return
+}
+
+// RevokeAll revokes every key at once by rotating the auth_key the key hashes are bound to.
+func (au *apiKeyUsecase) RevokeAll(userID uuid.UUID) (err error) {
+	authKey, err := utils.GenerateRandomString(16)
+	if err != nil {
+		return
+	}
+
+	return au.apiKeyRepo.RevokeAll(userID, authKey)
+}
+
+func (au *apiKeyUsecase) Authenticate(key, ip string) (u domain.User, apiKey domain.APIKey, err error) {
+	parts := strings.SplitN(key, "_", 3)
+	if len(parts) != 3 || parts[0] != domain.ConstAPIKeyPrefix || len(parts[1]) != apiKeyPrefixLength || len(parts[2]) != apiKeySecretLength {
+		return u, apiKey, domain.ErrInvalidAPIKey
+	}
+
+	apiKey, userID, keyHash, err := au.apiKeyRepo.GetByPrefix(parts[1])
+	if _, ok := err.(domain.NotFoundError); ok {
+		return u, apiKey, domain.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return
+	}
+
+	u, err = au.userRepo.GetByID(userID)
+	if err != nil {
+		return
+	}
+
+	expected := hashAPIKeySecret(u.AuthKey, parts[2])
+	if u.AuthKey == "" || !hmac.Equal([]byte(expected), []byte(keyHash)) {
+		return u, apiKey, domain.ErrInvalidAPIKey
+	}
+	if apiKey.RevokedAt != 0 || (apiKey.ExpiresAt != 0 && int64(apiKey.ExpiresAt) <= time.Now().Unix()) {
+		return u, apiKey, domain.ErrInvalidAPIKey
+	}
+
+	switch u.Status {
+	case domain.ConstUserStatusActive:
+	case domain.ConstUserStatusDeleted:
+		return u, apiKey, domain.ErrUserDeleted
+	default:
+		return u, apiKey, domain.ErrUserInactive
+	}
+
+	u.Roles, err = au.roleRepo.GetUserRoles(u.ID)
+	if err != nil {
+		return
+	}
+
+	// key dari organisasi yang sudah ditinggalkan pemiliknya tidak lagi bertindak di organisasi itu
+	if apiKey.OrganizationID != nil {
+		var membership domain.Membership
+		membership, err = au.orgRepo.GetMembership(u.ID, *apiKey.OrganizationID)
+		if err == nil {
+			u.Organization = &membership
+		} else if _, ok := err.(domain.NotFoundError); !ok {
+			return
+		}
+	}
+
+	err = au.apiKeyRepo.Touch(apiKey.ID, ip)
+
+	return
+}
+
+// userPermissions returns the permissions granted by the roles of the user and the role within the organization.
+func (au *apiKeyUsecase) userPermissions(userID uuid.UUID, organizationRole string) (permissions map[string]bool, err error) {
+	userRoles, err := au.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return
+	}
+	if organizationRole != "" {
+		userRoles = append(userRoles, organizationRole)
+	}
+
+	roles, err := au.roleRepo.Fetch()
+	if err != nil {
+		return
+	}
+
+	permissions = map[string]bool{}
+	for _, r := range roles {
+		if !containsString(userRoles, r.Name) {
+			continue
+		}
+		for _, p := range r.Permissions {
+			permissions[p] = true
+		}
+	}
+
+	return
+}
+
+// hashAPIKeySecret binds the key to the auth_key of its owner, rotating auth_key invalidates every key.
+func hashAPIKeySecret(authKey, secret string) string {
+	mac := hmac.New(sha256.New, []byte(authKey))
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueStrings(list []string) (unique []string) {
+	for _, v := range list {
+		if !containsString(unique, v) {
+			unique = append(unique, v)
+		}
+	}
+	return
+}
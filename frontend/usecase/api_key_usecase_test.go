@@ -0,0 +1,109 @@
+package usecase
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyUsecase(t *testing.T) {
+	organizationID := uuid.New()
+	editor := domain.User{ID: uuid.New(), Email: "jane@example.com", AuthKey: "jane-auth-key", Status: domain.ConstUserStatusActive}
+	member := domain.User{ID: uuid.New(), Email: "bob@example.com", AuthKey: "bob-auth-key", Status: domain.ConstUserStatusActive}
+	external := domain.User{ID: uuid.New(), Email: "ann@example.com", Status: domain.ConstUserStatusActive}
+
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{editor.ID: editor, member.ID: member, external.ID: external}}
+	roles := &fakeRoleRepo{grants: map[uuid.UUID]map[string]bool{
+		editor.ID:   {domain.ConstRoleEditor: true},
+		external.ID: {domain.ConstRoleEditor: true},
+	}}
+	orgs := &fakeOrganizationRepo{members: map[uuid.UUID]map[uuid.UUID]string{organizationID: {member.ID: domain.ConstRoleEditor}}}
+	apiKeys := &fakeAPIKeyRepo{users: users, keys: map[string]domain.APIKey{}, owners: map[string]uuid.UUID{}, hashes: map[string]string{}}
+	au := NewAPIKeyUsecase(apiKeys, users, roles, orgs, time.Second)
+
+	create := func(userID uuid.UUID, scopes ...string) (domain.NewAPIKey, error) {
+		return au.Create(userID, &domain.APIKeyForm{Name: "ci", Scopes: scopes})
+	}
+
+	t.Run("scopes are limited to the permissions of the owner", func(t *testing.T) {
+		_, err := create(editor.ID, domain.ConstPermissionRoleManage)
+		assert.IsType(t, domain.DataValidationError{}, err)
+
+		_, err = create(member.ID, domain.ConstPermissionBookWrite)
+		assert.IsType(t, domain.DataValidationError{}, err, "the organization role counts only for keys of the organization")
+
+		_, err = au.Create(member.ID, &domain.APIKeyForm{Name: "ci", Scopes: []string{domain.ConstPermissionBookWrite}, OrganizationID: organizationID})
+		assert.NoError(t, err)
+
+		_, err = create(member.ID, domain.ConstScopeReviewWrite)
+		assert.NoError(t, err, "account scopes are granted to any account")
+
+		_, err = create(external.ID, domain.ConstScopeReviewWrite)
+		assert.IsType(t, domain.DataValidationError{}, err, "accounts without auth_key own no keys")
+	})
+
+	t.Run("authenticate", func(t *testing.T) {
+		key, err := create(editor.ID, domain.ConstPermissionBookWrite, domain.ConstPermissionBookWrite)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(key.Key, domain.ConstAPIKeyPrefix+"_"+key.Prefix+"_"))
+		assert.Equal(t, []string{domain.ConstPermissionBookWrite}, key.Scopes)
+		assert.NotContains(t, apiKeys.hashes[key.Prefix], strings.TrimPrefix(key.Key, domain.ConstAPIKeyPrefix+"_"+key.Prefix+"_"))
+
+		u, apiKey, err := au.Authenticate(key.Key, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Equal(t, editor.ID, u.ID)
+		assert.Equal(t, []string{domain.ConstRoleEditor}, u.Roles)
+		assert.Equal(t, key.ID, apiKey.ID)
+
+		for _, invalid := range []string{"", key.Key[:len(key.Key)-1], key.Key[:len(key.Key)-1] + "x", "xyz" + key.Key[len(domain.ConstAPIKeyPrefix):]} {
+			_, _, err = au.Authenticate(invalid, "192.0.2.1")
+			assert.Equal(t, domain.ErrInvalidAPIKey, err, invalid)
+		}
+	})
+
+	t.Run("revoked and expired keys are rejected", func(t *testing.T) {
+		revoked, err := create(editor.ID, domain.ConstPermissionBookWrite)
+		require.NoError(t, err)
+		require.NoError(t, au.Revoke(editor.ID, revoked.ID))
+		_, _, err = au.Authenticate(revoked.Key, "192.0.2.1")
+		assert.Equal(t, domain.ErrInvalidAPIKey, err)
+		assert.IsType(t, domain.NotFoundError{}, au.Revoke(member.ID, revoked.ID))
+
+		expired, err := create(editor.ID, domain.ConstPermissionBookWrite)
+		require.NoError(t, err)
+		k := apiKeys.keys[expired.Prefix]
+		k.ExpiresAt = int(time.Now().Add(-time.Minute).Unix())
+		apiKeys.keys[expired.Prefix] = k
+		_, _, err = au.Authenticate(expired.Key, "192.0.2.1")
+		assert.Equal(t, domain.ErrInvalidAPIKey, err)
+	})
+
+	t.Run("revoke all rotates the auth key", func(t *testing.T) {
+		key, err := create(editor.ID, domain.ConstPermissionBookWrite)
+		require.NoError(t, err)
+
+		require.NoError(t, au.RevokeAll(editor.ID))
+		_, _, err = au.Authenticate(key.Key, "192.0.2.1")
+		assert.Equal(t, domain.ErrInvalidAPIKey, err)
+	})
+
+	t.Run("keys of a left organization lose it", func(t *testing.T) {
+		key, err := au.Create(member.ID, &domain.APIKeyForm{Name: "ci", Scopes: []string{domain.ConstPermissionBookWrite}, OrganizationID: organizationID})
+		require.NoError(t, err)
+
+		u, _, err := au.Authenticate(key.Key, "192.0.2.1")
+		require.NoError(t, err)
+		require.NotNil(t, u.Organization)
+		assert.Equal(t, domain.ConstRoleEditor, u.Organization.Role)
+
+		delete(orgs.members[organizationID], member.ID)
+		u, _, err = au.Authenticate(key.Key, "192.0.2.1")
+		require.NoError(t, err)
+		assert.Nil(t, u.Organization)
+	})
+}
@@ -0,0 +1,28 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"time"
+)
+
+type auditUsecase struct {
+	auditRepo      domain.AuditRepository
+	contextTimeout time.Duration
+}
+
+// NewAuditUsecase will create new an auditUsecase object representation of domain.AuditUsecase interface
+func NewAuditUsecase(a domain.AuditRepository, timeout time.Duration) domain.AuditUsecase {
+	return &auditUsecase{
+		auditRepo:      a,
+		contextTimeout: timeout,
+	}
+}
+
+func (a *auditUsecase) Fetch(filter domain.AuditFilter, perPage, page int) (entries []domain.AuditEntry, totalCount, pageCount, currentPage int, err error) {
+	if filter.From > 0 && filter.To > 0 && filter.From > filter.To {
+		err = domain.DataValidationError{Field: "from", Message: "from must not be after to"}
+		return
+	}
+
+	return a.auditRepo.Fetch(filter, perPage, page)
+}
@@ -0,0 +1,54 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+type authorUsecase struct {
+	authorRepo     domain.AuthorRepository
+	contextTimeout time.Duration
+}
+
+// NewAuthorUsecase will create new an authorUsecase object representation of domain.AuthorUsecase interface
+func NewAuthorUsecase(a domain.AuthorRepository, timeout time.Duration) domain.AuthorUsecase {
+	return &authorUsecase{
+		authorRepo:     a,
+		contextTimeout: timeout,
+	}
+}
+
+func (au *authorUsecase) Create(actor domain.Actor, af *domain.AuthorForm) (a domain.Author, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	return au.authorRepo.Create(actor.OrganizationID, af, actor.AuditContext())
+}
+
+func (au *authorUsecase) Fetch(organizationID uuid.UUID, name string, perPage, page int) (authors []domain.Author, totalCount, pageCount, currentPage int, err error) {
+	return au.authorRepo.Fetch(organizationID, name, perPage, page)
+}
+
+func (au *authorUsecase) GetByID(organizationID uuid.UUID, id int) (a domain.Author, err error) {
+	return au.authorRepo.GetByID(organizationID, id)
+}
+
+func (au *authorUsecase) Update(actor domain.Actor, id int, af *domain.AuthorForm) (a domain.Author, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	return au.authorRepo.Update(actor.OrganizationID, id, af, actor.AuditContext())
+}
+
+func (au *authorUsecase) Delete(actor domain.Actor, id int) (err error) {
+	if actor.OrganizationID == uuid.Nil {
+		return domain.ErrNoActiveOrganization
+	}
+
+	return au.authorRepo.Delete(actor.OrganizationID, id, actor.AuditContext())
+}
@@ -0,0 +1,129 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/google/uuid"
+	"time"
+)
+
+type bookChapterUsecase struct {
+	chapterRepo    domain.BookChapterRepository
+	bookRepo       domain.BookRepository
+	contextTimeout time.Duration
+}
+
+// NewBookChapterUsecase will create new an bookChapterUsecase object representation of domain.BookChapterUsecase interface
+func NewBookChapterUsecase(c domain.BookChapterRepository, b domain.BookRepository, timeout time.Duration) domain.BookChapterUsecase {
+	return &bookChapterUsecase{
+		chapterRepo:    c,
+		bookRepo:       b,
+		contextTimeout: timeout,
+	}
+}
+
+func (cu *bookChapterUsecase) Fetch(organizationID uuid.UUID, bookID int) (chapters []domain.BookChapterHeading, err error) {
+	book, err := cu.bookRepo.GetByID(organizationID, bookID)
+	if err == nil {
+		book, err = published(book)
+	}
+	if err != nil {
+		return
+	}
+
+	return book.Chapters, nil
+}
+
+func (cu *bookChapterUsecase) GetByID(organizationID uuid.UUID, bookID, id int) (chapter domain.BookChapter, err error) {
+	book, err := cu.bookRepo.GetByID(organizationID, bookID)
+	if err == nil {
+		_, err = published(book)
+	}
+	if err != nil {
+		return
+	}
+
+	chapter, err = cu.chapterRepo.GetByID(organizationID, bookID, id)
+	return rendered(chapter, err)
+}
+
+func (cu *bookChapterUsecase) FetchForEditing(actor domain.Actor, bookID int) (chapters []domain.BookChapterHeading, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	book, err := cu.bookRepo.GetByID(actor.OrganizationID, bookID)
+	if err != nil {
+		return
+	}
+
+	return book.Chapters, nil
+}
+
+func (cu *bookChapterUsecase) GetForEditing(actor domain.Actor, bookID, id int) (chapter domain.BookChapter, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	chapter, err = cu.chapterRepo.GetByID(actor.OrganizationID, bookID, id)
+	return rendered(chapter, err)
+}
+
+func (cu *bookChapterUsecase) Create(actor domain.Actor, bookID int, cf *domain.BookChapterForm) (chapter domain.BookChapter, err error) {
+	err = cu.authorize(actor, bookID, "add chapters to")
+	if err != nil {
+		return
+	}
+
+	chapter, err = cu.chapterRepo.Create(actor.OrganizationID, bookID, cf, actor.AuditContext())
+	return rendered(chapter, err)
+}
+
+func (cu *bookChapterUsecase) Update(actor domain.Actor, bookID, id int, cf *domain.BookChapterForm) (chapter domain.BookChapter, err error) {
+	err = cu.authorize(actor, bookID, "update the chapters of")
+	if err != nil {
+		return
+	}
+
+	chapter, err = cu.chapterRepo.Update(actor.OrganizationID, bookID, id, cf, actor.AuditContext())
+	return rendered(chapter, err)
+}
+
+func (cu *bookChapterUsecase) Delete(actor domain.Actor, bookID, id int) (err error) {
+	err = cu.authorize(actor, bookID, "delete the chapters of")
+	if err != nil {
+		return
+	}
+
+	return cu.chapterRepo.Delete(actor.OrganizationID, bookID, id, actor.AuditContext())
+}
+
+// authorize checks the actor may modify the book, the action completes the message of the ForbiddenError.
+func (cu *bookChapterUsecase) authorize(actor domain.Actor, bookID int, action string) error {
+	if actor.OrganizationID == uuid.Nil {
+		return domain.ErrNoActiveOrganization
+	}
+
+	book, err := cu.bookRepo.GetByID(actor.OrganizationID, bookID)
+	if err != nil {
+		return err
+	}
+
+	if !canModifyBook(actor, book) {
+		return domain.ForbiddenError{Message: "only the owner or an admin may " + action + " this book"}
+	}
+
+	return nil
+}
+
+// rendered sets the HTML of the chapter from its Markdown content.
+func rendered(chapter domain.BookChapter, err error) (domain.BookChapter, error) {
+	if err != nil {
+		return chapter, err
+	}
+
+	chapter.HTML = utils.RenderMarkdown(chapter.Content)
+	return chapter, nil
+}
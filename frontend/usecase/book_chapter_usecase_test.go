@@ -0,0 +1,52 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookChapterUsecase(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: uuid.New()}
+	toc := []domain.BookChapterHeading{{ID: 1, Position: 1, Title: "One"}}
+	books := &fakeBookRepo{books: map[int]domain.Book{
+		1: {ID: 1, CreatedBy: &owner.UserID, OrganizationID: owner.OrganizationID, Status: domain.ConstBookStatusDraft, Chapters: toc},
+	}}
+	chapters := &fakeBookChapterRepo{chapters: map[int]domain.BookChapter{
+		1: {BookChapterHeading: toc[0], BookID: 1, Content: "# One\n\n<script>x</script> *hi*"},
+	}}
+	cu := NewBookChapterUsecase(chapters, books, time.Second)
+
+	_, err := cu.Fetch(owner.OrganizationID, 1)
+	assert.IsType(t, domain.NotFoundError{}, err, "chapters of a draft are not in the catalog")
+	_, err = cu.GetByID(owner.OrganizationID, 1, 1)
+	assert.IsType(t, domain.NotFoundError{}, err)
+
+	chapter, err := cu.GetForEditing(owner, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "<h1>One</h1>\n<p>&lt;script&gt;x&lt;/script&gt; <em>hi</em></p>\n", chapter.HTML)
+
+	book := books.books[1]
+	book.Status = domain.ConstBookStatusPublished
+	books.books[1] = book
+
+	headings, err := cu.Fetch(owner.OrganizationID, 1)
+	require.NoError(t, err)
+	assert.Equal(t, toc, headings)
+
+	chapter, err = cu.GetByID(owner.OrganizationID, 1, 1)
+	require.NoError(t, err)
+	assert.Contains(t, chapter.HTML, "<em>hi</em>")
+
+	stranger := domain.Actor{UserID: uuid.New(), OrganizationID: owner.OrganizationID}
+	_, err = cu.Create(stranger, 1, &domain.BookChapterForm{Title: "Two"})
+	assert.IsType(t, domain.ForbiddenError{}, err)
+
+	chapter, err = cu.Create(owner, 1, &domain.BookChapterForm{Title: "Two", Content: "[x](javascript:alert(1))"})
+	require.NoError(t, err)
+	assert.Equal(t, "<p>x</p>\n", chapter.HTML)
+}
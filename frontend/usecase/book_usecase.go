@@ -2,23 +2,32 @@ package usecase
 
 import (
 	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"log"
+	"strconv"
 	"time"
 )
 
 type bookUsecase struct {
-	bookRepo       domain.BookRepository
-	contextTimeout time.Duration
+	bookRepo          domain.BookRepository
+	blobStore         domain.BlobStore
+	currencyConverter domain.CurrencyConverter
+	contextTimeout    time.Duration
 }
 
 // NewBookUsecase will create new an bookUsecase object representation of domain.BookUsecase interface
-func NewBookUsecase(b domain.BookRepository, timeout time.Duration) domain.BookUsecase {
+func NewBookUsecase(b domain.BookRepository, blobStore domain.BlobStore, currencyConverter domain.CurrencyConverter, timeout time.Duration) domain.BookUsecase {
 	return &bookUsecase{
-		bookRepo:       b,
-		contextTimeout: timeout,
+		bookRepo:          b,
+		blobStore:         blobStore,
+		currencyConverter: currencyConverter,
+		contextTimeout:    timeout,
 	}
 }
 
-func (b *bookUsecase) Create(bd *domain.BookForm) (book domain.Book, err error) {
+func (b *bookUsecase) Create(actor domain.Actor, bd *domain.BookForm) (book domain.Book, err error) {
 	/*ctx, cancel := context.WithTimeout(c, b.contextTimeout)
 	defer cancel()*/
 
@@ -28,12 +37,22 @@ func (b *bookUsecase) Create(bd *domain.BookForm) (book domain.Book, err error)
 		return domain.ErrConflict
 	}*/
 
-	book, err = b.bookRepo.Create(bd)
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	err = normalizeBookISBN(bd)
+	if err != nil {
+		return
+	}
+
+	book, err = b.bookRepo.Create(actor.OrganizationID, bd, actor.AuditContext())
 	return
 }
 
-func (b *bookUsecase) Fetch(perPage, page int) (books []domain.Book, totalCount, pageCount, currentPage int, err error) {
-	books, totalCount, pageCount, currentPage, err = b.bookRepo.Fetch(perPage, page)
+func (b *bookUsecase) Fetch(organizationID uuid.UUID, filter domain.BookFilter, perPage, page int) (books []domain.Book, totalCount, pageCount, currentPage int, err error) {
+	books, totalCount, pageCount, currentPage, err = b.bookRepo.Fetch(organizationID, filter, perPage, page)
 	if err != nil {
 		return
 	}
@@ -41,16 +60,345 @@ func (b *bookUsecase) Fetch(perPage, page int) (books []domain.Book, totalCount,
 	return
 }
 
-func (b *bookUsecase) GetByID(id int) (book domain.Book, err error) {
-	book, err = b.bookRepo.GetByID(id)
+func (b *bookUsecase) GetByID(organizationID uuid.UUID, id int) (book domain.Book, err error) {
+	book, err = b.bookRepo.GetByID(organizationID, id)
+	if err != nil {
+		return
+	}
+
+	return published(book)
+}
+
+func (b *bookUsecase) GetByISBN(organizationID uuid.UUID, isbn string) (book domain.Book, err error) {
+	isbn13, ok := utils.NormalizeISBN(isbn)
+	if !ok {
+		err = domain.DataValidationError{Field: "isbn", Message: "invalid ISBN"}
+		return
+	}
+
+	book, err = b.bookRepo.GetByISBN(organizationID, isbn13)
+	if err != nil {
+		return
+	}
+
+	return published(book)
+}
+
+func (b *bookUsecase) GetForEditing(actor domain.Actor, id int) (book domain.Book, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	return b.bookRepo.GetByID(actor.OrganizationID, id)
+}
+
+func (b *bookUsecase) SubmitForReview(actor domain.Actor, id int) (book domain.Book, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	book, err = b.bookRepo.GetByID(actor.OrganizationID, id)
+	if err != nil {
+		return
+	}
+
+	if !canModifyBook(actor, book) {
+		err = domain.ForbiddenError{Message: "only the owner or an admin may submit this book for review"}
+		return
+	}
+
+	if book.Status != domain.ConstBookStatusDraft {
+		err = domain.ConflictError{Field: "status", Message: "only a draft can be submitted for review"}
+		return
+	}
+
+	return b.bookRepo.SetStatus(actor.OrganizationID, id, book.Status, domain.ConstBookStatusInReview, nil, actor.AuditContext())
+}
+
+func (b *bookUsecase) SetStatus(actor domain.Actor, id int, sf *domain.BookStatusForm) (book domain.Book, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	if sf.PublishAt != nil && sf.Status != domain.ConstBookStatusPublished {
+		err = domain.DataValidationError{Field: "publish_at", Message: "publish_at is only allowed when publishing"}
+		return
+	}
+
+	book, err = b.bookRepo.GetByID(actor.OrganizationID, id)
+	if err != nil {
+		return
+	}
+
+	if !domain.CanTransitionBook(book.Status, sf.Status) {
+		err = domain.ConflictError{Field: "status", Message: "a book can not move from " + book.Status + " to " + sf.Status}
+		return
+	}
+
+	// publikasi terjadwal: status tetap sampai waktunya tiba, lalu dipublikasikan oleh PublishDue
+	if sf.PublishAt != nil && int64(*sf.PublishAt) > time.Now().Unix() {
+		return b.bookRepo.SetStatus(actor.OrganizationID, id, book.Status, book.Status, sf.PublishAt, actor.AuditContext())
+	}
+
+	return b.bookRepo.SetStatus(actor.OrganizationID, id, book.Status, sf.Status, nil, actor.AuditContext())
+}
+
+func (b *bookUsecase) PublishDue() (int64, error) {
+	return b.bookRepo.PublishDue(int(time.Now().Unix()))
+}
+
+func (b *bookUsecase) Update(actor domain.Actor, id int, bf *domain.BookForm) (book domain.Book, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	book, err = b.bookRepo.GetByID(actor.OrganizationID, id)
+	if err != nil {
+		return
+	}
+
+	if !canModifyBook(actor, book) {
+		err = domain.ForbiddenError{Message: "only the owner or an admin may update this book"}
+		return
+	}
+
+	err = normalizeBookISBN(bf)
+	if err != nil {
+		return
+	}
+
+	book, err = b.bookRepo.Update(actor.OrganizationID, id, bf, actor.AuditContext())
 	return
 }
 
-func (b *bookUsecase) Update(id int, bf *domain.BookForm) (book domain.Book, err error) {
-	book, err = b.bookRepo.Update(id, bf)
+func (b *bookUsecase) Delete(actor domain.Actor, id int) (rowsAffected int64, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	book, err := b.bookRepo.GetByID(actor.OrganizationID, id)
+	if err != nil {
+		return
+	}
+
+	if !canModifyBook(actor, book) {
+		err = domain.ForbiddenError{Message: "only the owner or an admin may delete this book"}
+		return
+	}
+
+	rowsAffected, err = b.bookRepo.Delete(actor.OrganizationID, id, actor.AuditContext())
+	if err != nil {
+		return
+	}
+
+	b.deleteCoverBlobs(book.Cover)
 	return
 }
 
-func (b *bookUsecase) Delete(id int) (rowsAffected int64, err error) {
-	return b.bookRepo.Delete(id)
+func (b *bookUsecase) Revisions(organizationID uuid.UUID, id int) (revisions []domain.BookRevision, err error) {
+	_, err = b.GetByID(organizationID, id)
+	if err != nil {
+		return
+	}
+
+	return b.bookRepo.FetchRevisions(organizationID, id)
+}
+
+func (b *bookUsecase) DiffRevisions(organizationID uuid.UUID, id, from, to int) (diff domain.BookRevisionDiff, err error) {
+	_, err = b.GetByID(organizationID, id)
+	if err != nil {
+		return
+	}
+
+	fromRevision, err := b.bookRepo.GetRevision(organizationID, id, from)
+	if err != nil {
+		return
+	}
+
+	toRevision, err := b.bookRepo.GetRevision(organizationID, id, to)
+	if err != nil {
+		return
+	}
+
+	return fromRevision.Diff(toRevision), nil
+}
+
+func (b *bookUsecase) RestoreRevision(actor domain.Actor, id, revision int) (book domain.Book, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	book, err = b.bookRepo.GetByID(actor.OrganizationID, id)
+	if err != nil {
+		return
+	}
+
+	if !canModifyBook(actor, book) {
+		err = domain.ForbiddenError{Message: "only the owner or an admin may restore this book"}
+		return
+	}
+
+	r, err := b.bookRepo.GetRevision(actor.OrganizationID, id, revision)
+	if err != nil {
+		return
+	}
+
+	form := r.Form()
+	book, err = b.bookRepo.Update(actor.OrganizationID, id, &form, actor.AuditContext())
+	return
+}
+
+func (b *bookUsecase) UploadCover(actor domain.Actor, id int, data []byte) (book domain.Book, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	if len(data) > domain.ConstBookCoverMaxBytes {
+		err = domain.ErrBookCoverTooLarge
+		return
+	}
+
+	contentType, ok := utils.SniffImage(data)
+	if !ok {
+		err = domain.ErrBookCoverUnsupported
+		return
+	}
+
+	// ukuran dicek sebelum decode, gambar kecil bisa mengembang menjadi bitmap yang sangat besar
+	width, height, err := utils.ImageDimensions(data)
+	if err != nil {
+		err = domain.ErrBookCoverUnsupported
+		return
+	}
+	if width*height > domain.ConstBookCoverMaxPixels {
+		err = domain.ErrBookCoverDimensions
+		return
+	}
+
+	book, err = b.bookRepo.GetByID(actor.OrganizationID, id)
+	if err != nil {
+		return
+	}
+
+	if !canModifyBook(actor, book) {
+		err = domain.ForbiddenError{Message: "only the owner or an admin may change the cover of this book"}
+		return
+	}
+
+	img, err := utils.DecodeImage(data)
+	if err != nil {
+		err = domain.ErrBookCoverUnsupported
+		return
+	}
+
+	// setiap upload memakai prefix baru, URL lama yang mungkin masih di-cache tidak menunjuk ke gambar baru
+	prefix := "covers/" + actor.OrganizationID.String() + "/" + strconv.Itoa(id) + "/" + uuid.New().String() + "/"
+	originalKey := prefix + "original" + coverExtensions[contentType]
+	cover := &domain.BookCover{URL: b.blobStore.URL(originalKey), Thumbnails: map[string]string{}, Keys: []string{originalKey}}
+
+	err = b.blobStore.Put(originalKey, contentType, data)
+	if err != nil {
+		return
+	}
+
+	for name, width := range domain.BookCoverThumbnailWidths {
+		key := prefix + name + ".jpg"
+		cover.Keys = append(cover.Keys, key)
+		cover.Thumbnails[name] = b.blobStore.URL(key)
+
+		var thumbnail []byte
+		thumbnail, err = utils.EncodeJPEG(utils.Thumbnail(img, width))
+		if err == nil {
+			err = b.blobStore.Put(key, "image/jpeg", thumbnail)
+		}
+		if err != nil {
+			b.deleteCoverBlobs(cover)
+			return
+		}
+	}
+
+	book, previous, err := b.bookRepo.SetCover(actor.OrganizationID, id, cover, actor.AuditContext())
+	if err != nil {
+		b.deleteCoverBlobs(cover)
+		return
+	}
+
+	b.deleteCoverBlobs(previous)
+	return
+}
+
+func (b *bookUsecase) DisplayPrices(books []domain.Book, currency string) error {
+	if !domain.IsCurrency(currency) {
+		return domain.DataValidationError{Field: "currency", Message: "unknown currency " + currency}
+	}
+
+	for i := range books {
+		price, err := books[i].PriceIn(currency, b.currencyConverter)
+		if err != nil {
+			return err
+		}
+		books[i].DisplayPrice = &price
+	}
+
+	return nil
+}
+
+// deleteCoverBlobs removes the files of a cover that is no longer used. Failures only leave unused files behind.
+func (b *bookUsecase) deleteCoverBlobs(cover *domain.BookCover) {
+	if cover == nil {
+		return
+	}
+
+	for _, key := range cover.Keys {
+		if err := b.blobStore.Delete(key); err != nil {
+			log.Printf("Unable to delete cover blob %s: %v", key, err)
+		}
+	}
+}
+
+// coverExtensions file extensions of the accepted cover content types
+var coverExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// normalizeBookISBN converts the ISBN of the form to ISBN-13, an empty ISBN is kept to remove it.
+func normalizeBookISBN(bf *domain.BookForm) error {
+	if bf.ISBN == nil || *bf.ISBN == "" {
+		return nil
+	}
+
+	isbn13, ok := utils.NormalizeISBN(*bf.ISBN)
+	if !ok {
+		return domain.DataValidationError{Field: "isbn", Message: "invalid ISBN"}
+	}
+	bf.ISBN = &isbn13
+
+	return nil
+}
+
+// published returns the book when it is published, other books are not found outside of editing.
+func published(book domain.Book) (domain.Book, error) {
+	if book.Status != domain.ConstBookStatusPublished {
+		return domain.Book{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+	}
+
+	return book, nil
+}
+
+// canModifyBook reports whether the actor owns the book or is an admin, globally or of the organization.
+func canModifyBook(actor domain.Actor, book domain.Book) bool {
+	if actor.HasRoleInOrganization(domain.ConstRoleAdmin) {
+		return true
+	}
+
+	return book.CreatedBy != nil && *book.CreatedBy == actor.UserID
 }
@@ -0,0 +1,337 @@
+package usecase
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBookUsecase_Ownership(t *testing.T) {
+	organizationID := uuid.New()
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID, OrganizationRole: domain.ConstRoleEditor}
+	editor := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID, OrganizationRole: domain.ConstRoleEditor}
+	admin := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID, OrganizationRole: domain.ConstRoleAdmin}
+	outsider := domain.Actor{UserID: uuid.New(), Roles: []string{domain.ConstRoleAdmin}, OrganizationID: uuid.New(), OrganizationRole: domain.ConstRoleAdmin}
+	repo := &fakeBookRepo{books: map[int]domain.Book{
+		1: {ID: 1, Title: "Mine", CreatedBy: &owner.UserID, OrganizationID: organizationID},
+		2: {ID: 2, Title: "Also mine", CreatedBy: &owner.UserID, OrganizationID: organizationID},
+	}}
+	bu := NewBookUsecase(repo, &fakeBlobStore{}, nil, time.Second)
+	form := &domain.BookForm{Title: "Changed", Author: "Jane"}
+
+	t.Run("only the owner or an admin updates", func(t *testing.T) {
+		_, err := bu.Update(editor, 1, form)
+		assert.IsType(t, domain.ForbiddenError{}, err)
+		assert.Equal(t, "Mine", repo.books[1].Title)
+
+		book, err := bu.Update(owner, 1, form)
+		require.NoError(t, err)
+		assert.Equal(t, "Changed", book.Title)
+		assert.Equal(t, &owner.UserID, book.UpdatedBy)
+
+		book, err = bu.Update(admin, 2, form)
+		require.NoError(t, err)
+		assert.Equal(t, &owner.UserID, book.CreatedBy, "the owner stays")
+	})
+
+	t.Run("books of other organizations are not found", func(t *testing.T) {
+		_, err := bu.Update(outsider, 1, form)
+		assert.IsType(t, domain.NotFoundError{}, err)
+
+		_, err = bu.Delete(outsider, 1)
+		assert.IsType(t, domain.NotFoundError{}, err)
+
+		_, err = bu.Delete(domain.Actor{UserID: owner.UserID}, 1)
+		assert.Equal(t, domain.ErrNoActiveOrganization, err)
+	})
+
+	t.Run("only the owner or an admin deletes", func(t *testing.T) {
+		_, err := bu.Delete(editor, 1)
+		assert.IsType(t, domain.ForbiddenError{}, err)
+		assert.Contains(t, repo.books, 1)
+
+		rowsAffected, err := bu.Delete(admin, 1)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+
+		rowsAffected, err = bu.Delete(owner, 2)
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), rowsAffected)
+
+		_, err = bu.Delete(owner, 2)
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+}
+
+func TestBookUsecase_Revisions(t *testing.T) {
+	organizationID := uuid.New()
+	price := domain.Money{Amount: 1999, Currency: "USD"}
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID, OrganizationRole: domain.ConstRoleEditor}
+	editor := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID, OrganizationRole: domain.ConstRoleEditor}
+	repo := &fakeBookRepo{
+		books: map[int]domain.Book{
+			1: {ID: 1, Title: "Title", Author: "Jane", CreatedBy: &owner.UserID, OrganizationID: organizationID, Status: domain.ConstBookStatusPublished},
+			2: {ID: 2, Title: "Draft", CreatedBy: &owner.UserID, OrganizationID: organizationID, Status: domain.ConstBookStatusDraft},
+		},
+		revisions: map[int][]domain.BookRevision{
+			1: {
+				{BookID: 1, Revision: 1, Title: "Old title", Author: "Jane", Price: price},
+				{BookID: 1, Revision: 2, Title: "Title", Author: "Jane", Price: domain.Money{Amount: 2499, Currency: "USD"}},
+			},
+			2: {{BookID: 2, Revision: 1, Title: "Draft", Price: price}},
+		},
+	}
+	bu := NewBookUsecase(repo, &fakeBlobStore{}, nil, time.Second)
+
+	revisions, err := bu.Revisions(organizationID, 1)
+	require.NoError(t, err)
+	require.Len(t, revisions, 2)
+	assert.Equal(t, 2, revisions[0].Revision, "newest first")
+
+	diff, err := bu.DiffRevisions(organizationID, 1, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.BookFieldChange{
+		{Field: "title", From: "Old title", To: "Title"},
+		{Field: "price", From: price, To: domain.Money{Amount: 2499, Currency: "USD"}},
+	}, diff.Changes)
+
+	diff, err = bu.DiffRevisions(organizationID, 1, 2, 2)
+	require.NoError(t, err)
+	assert.Empty(t, diff.Changes)
+
+	_, err = bu.DiffRevisions(organizationID, 1, 1, 3)
+	assert.IsType(t, domain.NotFoundError{}, err)
+
+	_, err = bu.Revisions(organizationID, 2)
+	assert.IsType(t, domain.NotFoundError{}, err, "the history of unpublished books is not public")
+	_, err = bu.DiffRevisions(organizationID, 2, 1, 1)
+	assert.IsType(t, domain.NotFoundError{}, err)
+
+	_, err = bu.Revisions(uuid.New(), 1)
+	assert.IsType(t, domain.NotFoundError{}, err)
+
+	_, err = bu.RestoreRevision(editor, 1, 1)
+	assert.IsType(t, domain.ForbiddenError{}, err)
+
+	book, err := bu.RestoreRevision(owner, 1, 1)
+	require.NoError(t, err)
+	assert.Equal(t, "Old title", book.Title)
+}
+
+func testCoverPNG(t *testing.T, width, height int) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, image.NewGray(image.Rect(0, 0, width, height))))
+	return buf.Bytes()
+}
+
+func TestBookUsecase_UploadCover(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: uuid.New()}
+	repo := &fakeBookRepo{books: map[int]domain.Book{
+		1: {ID: 1, CreatedBy: &owner.UserID, OrganizationID: owner.OrganizationID},
+	}}
+	store := &fakeBlobStore{blobs: map[string]string{}}
+	bu := NewBookUsecase(repo, store, nil, time.Second)
+
+	book, err := bu.UploadCover(owner, 1, testCoverPNG(t, 800, 1200))
+	require.NoError(t, err)
+	require.NotNil(t, book.Cover)
+	assert.Regexp(t, `^https://cdn\.example\.com/covers/`+owner.OrganizationID.String()+`/1/[0-9a-f-]+/original\.png$`, book.Cover.URL)
+	assert.Len(t, book.Cover.Thumbnails, len(domain.BookCoverThumbnailWidths))
+	assert.Len(t, store.blobs, 1+len(domain.BookCoverThumbnailWidths))
+	for _, key := range book.Cover.Keys[1:] {
+		assert.Equal(t, "image/jpeg", store.blobs[key], key)
+	}
+
+	first := book.Cover
+	book, err = bu.UploadCover(owner, 1, testCoverPNG(t, 100, 150))
+	require.NoError(t, err)
+	assert.NotEqual(t, first.URL, book.Cover.URL)
+	assert.Len(t, store.blobs, 1+len(domain.BookCoverThumbnailWidths), "blobs of the replaced cover are deleted")
+	for _, key := range first.Keys {
+		assert.NotContains(t, store.blobs, key)
+	}
+
+	_, err = bu.UploadCover(owner, 1, []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.Equal(t, domain.ErrBookCoverUnsupported, err)
+
+	_, err = bu.UploadCover(owner, 1, make([]byte, domain.ConstBookCoverMaxBytes+1))
+	assert.Equal(t, domain.ErrBookCoverTooLarge, err)
+
+	_, err = bu.UploadCover(owner, 1, testCoverPNG(t, 10000, 5000))
+	assert.Equal(t, domain.ErrBookCoverDimensions, err)
+
+	stranger := domain.Actor{UserID: uuid.New(), OrganizationID: owner.OrganizationID}
+	_, err = bu.UploadCover(stranger, 1, testCoverPNG(t, 100, 150))
+	assert.IsType(t, domain.ForbiddenError{}, err)
+}
+
+func TestBookUsecase_DisplayPrices(t *testing.T) {
+	converter, err := utils.NewCurrencyConverter(map[string]string{"USD": "1", "IDR": "16250"})
+	require.NoError(t, err)
+	bu := NewBookUsecase(&fakeBookRepo{}, &fakeBlobStore{}, converter, time.Second)
+
+	books := []domain.Book{{
+		ID:     1,
+		Price:  domain.Money{Amount: 1999, Currency: "USD"},
+		Prices: []domain.Money{{Amount: 1850, Currency: "EUR"}},
+	}}
+
+	require.NoError(t, bu.DisplayPrices(books, "EUR"))
+	assert.Equal(t, &domain.BookDisplayPrice{Price: domain.Money{Amount: 1850, Currency: "EUR"}}, books[0].DisplayPrice)
+
+	require.NoError(t, bu.DisplayPrices(books, "IDR"))
+	assert.Equal(t, &domain.BookDisplayPrice{Price: domain.Money{Amount: 32483750, Currency: "IDR"}, Converted: true}, books[0].DisplayPrice)
+
+	require.NoError(t, bu.DisplayPrices(books, "USD"))
+	assert.Equal(t, &domain.BookDisplayPrice{Price: books[0].Price}, books[0].DisplayPrice)
+
+	assert.IsType(t, domain.DataValidationError{}, bu.DisplayPrices(books, "GBP"), "no rate")
+	assert.IsType(t, domain.DataValidationError{}, bu.DisplayPrices(books, "ABC"), "unknown currency")
+}
+
+func TestBookUsecase_Status(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: uuid.New()}
+	repo := &fakeBookRepo{books: map[int]domain.Book{
+		1: {ID: 1, CreatedBy: &owner.UserID, OrganizationID: owner.OrganizationID, Status: domain.ConstBookStatusDraft},
+	}}
+	bu := NewBookUsecase(repo, &fakeBlobStore{}, nil, time.Second)
+
+	_, err := bu.GetByID(owner.OrganizationID, 1)
+	assert.IsType(t, domain.NotFoundError{}, err, "drafts are not in the catalog")
+
+	book, err := bu.GetForEditing(owner, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConstBookStatusDraft, book.Status)
+
+	stranger := domain.Actor{UserID: uuid.New(), OrganizationID: owner.OrganizationID}
+	_, err = bu.SubmitForReview(stranger, 1)
+	assert.IsType(t, domain.ForbiddenError{}, err)
+
+	book, err = bu.SubmitForReview(owner, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConstBookStatusInReview, book.Status)
+
+	_, err = bu.SubmitForReview(owner, 1)
+	assert.IsType(t, domain.ConflictError{}, err, "only drafts are submitted")
+
+	later := int(time.Now().Add(time.Hour).Unix())
+	book, err = bu.SetStatus(owner, 1, &domain.BookStatusForm{Status: domain.ConstBookStatusPublished, PublishAt: &later})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConstBookStatusInReview, book.Status, "a scheduled book keeps its status")
+	assert.Equal(t, &later, book.PublishAt)
+
+	_, err = bu.SetStatus(owner, 1, &domain.BookStatusForm{Status: domain.ConstBookStatusArchived, PublishAt: &later})
+	assert.IsType(t, domain.DataValidationError{}, err)
+
+	book, err = bu.SetStatus(owner, 1, &domain.BookStatusForm{Status: domain.ConstBookStatusPublished})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConstBookStatusPublished, book.Status)
+	assert.Nil(t, book.PublishAt)
+
+	_, err = bu.GetByID(owner.OrganizationID, 1)
+	assert.NoError(t, err)
+
+	_, err = bu.SetStatus(owner, 1, &domain.BookStatusForm{Status: domain.ConstBookStatusInReview})
+	assert.IsType(t, domain.ConflictError{}, err, "published books are not reviewed again")
+
+	book, err = bu.SetStatus(owner, 1, &domain.BookStatusForm{Status: domain.ConstBookStatusArchived})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ConstBookStatusArchived, book.Status)
+
+	_, err = bu.SetStatus(owner, 1, &domain.BookStatusForm{Status: domain.ConstBookStatusPublished})
+	assert.IsType(t, domain.ConflictError{}, err, "archived books return to draft first")
+}
+
+func TestBookUsecase_RestoreRevisionChapters(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: uuid.New()}
+	price := domain.Money{Amount: 1999, Currency: "USD"}
+	chapters := []domain.BookRevisionChapter{{Position: 1, Title: "Prologue", Content: "It begins."}, {Position: 2, Title: "The end", Content: "It ends."}}
+	repo := &fakeBookRepo{
+		books: map[int]domain.Book{
+			1: {ID: 1, CreatedBy: &owner.UserID, OrganizationID: owner.OrganizationID, Status: domain.ConstBookStatusPublished, Title: "Draft title"},
+		},
+		revisions: map[int][]domain.BookRevision{1: {
+			{BookID: 1, Revision: 1, Title: "First", Author: "Jane", Price: price, Chapters: []domain.BookRevisionChapter{}},
+			{BookID: 1, Revision: 2, Title: "Second", Author: "Jane", Price: price, Chapters: chapters},
+		}},
+	}
+	bu := NewBookUsecase(repo, &fakeBlobStore{}, nil, time.Second)
+
+	diff, err := bu.DiffRevisions(owner.OrganizationID, 1, 1, 2)
+	require.NoError(t, err)
+	require.Len(t, diff.Changes, 2)
+	assert.Equal(t, "title", diff.Changes[0].Field)
+	assert.Equal(t, domain.BookFieldChange{Field: "chapters", From: []domain.BookRevisionChapter{}, To: chapters}, diff.Changes[1])
+
+	stranger := domain.Actor{UserID: uuid.New(), OrganizationID: owner.OrganizationID}
+	_, err = bu.RestoreRevision(stranger, 1, 2)
+	assert.IsType(t, domain.ForbiddenError{}, err)
+	assert.Nil(t, repo.updated)
+
+	book, err := bu.RestoreRevision(owner, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Second", book.Title)
+	assert.Equal(t, chapters, repo.updated.Chapters)
+	assert.Equal(t, "19.99", repo.updated.Price.String())
+
+	_, err = bu.RestoreRevision(owner, 1, 1)
+	require.NoError(t, err)
+	assert.NotNil(t, repo.updated.Chapters, "a revision without chapters removes those of the book")
+	assert.Empty(t, repo.updated.Chapters)
+
+	_, err = bu.RestoreRevision(owner, 1, 3)
+	assert.IsType(t, domain.NotFoundError{}, err)
+}
+
+func TestBookUsecase_RestoreRevisionAuthors(t *testing.T) {
+	owner := domain.Actor{UserID: uuid.New(), OrganizationID: uuid.New()}
+	price := domain.Money{Amount: 1999, Currency: "USD"}
+	credits := []domain.BookRevisionAuthor{
+		{AuthorID: 1, Role: domain.ConstAuthorRoleAuthor, Position: 0},
+		{AuthorID: 3, Role: domain.ConstAuthorRoleAuthor, Position: 1},
+		{AuthorID: 2, Role: domain.ConstAuthorRoleEditor, Position: 2},
+		{AuthorID: 4, Role: domain.ConstAuthorRoleTranslator, Position: 3},
+	}
+	repo := &fakeBookRepo{
+		books: map[int]domain.Book{
+			1: {ID: 1, CreatedBy: &owner.UserID, OrganizationID: owner.OrganizationID, Status: domain.ConstBookStatusPublished, Author: "Bob"},
+		},
+		revisions: map[int][]domain.BookRevision{1: {
+			{BookID: 1, Revision: 1, Title: "Title", Author: "Alice, Carol", Price: price},
+			{BookID: 1, Revision: 2, Title: "Title", Author: "Alice, Carol", Price: price, Authors: credits},
+			{BookID: 1, Revision: 3, Title: "Title", Author: "Bob", Price: price, Authors: []domain.BookRevisionAuthor{{AuthorID: 5, Role: domain.ConstAuthorRoleAuthor}}},
+		}},
+	}
+	bu := NewBookUsecase(repo, &fakeBlobStore{}, nil, time.Second)
+
+	diff, err := bu.DiffRevisions(owner.OrganizationID, 1, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, []domain.BookFieldChange{
+		{Field: "author", From: "Alice, Carol", To: "Bob"},
+		{Field: "authors", From: credits, To: []domain.BookRevisionAuthor{{AuthorID: 5, Role: domain.ConstAuthorRoleAuthor}}},
+	}, diff.Changes)
+
+	_, err = bu.RestoreRevision(owner, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, "Alice, Carol", repo.updated.Author)
+	assert.Equal(t, []domain.BookAuthorForm{
+		{AuthorID: 1, Role: domain.ConstAuthorRoleAuthor},
+		{AuthorID: 3, Role: domain.ConstAuthorRoleAuthor},
+		{AuthorID: 2, Role: domain.ConstAuthorRoleEditor},
+		{AuthorID: 4, Role: domain.ConstAuthorRoleTranslator},
+	}, repo.updated.Authors, "the editor and translator credits are restored")
+	assert.False(t, repo.updated.KeepAuthors)
+
+	_, err = bu.RestoreRevision(owner, 1, 1)
+	require.NoError(t, err)
+	assert.Empty(t, repo.updated.Authors)
+	assert.True(t, repo.updated.KeepAuthors, "a revision without credits does not relink the byline")
+}
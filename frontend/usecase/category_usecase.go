@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+type categoryUsecase struct {
+	categoryRepo   domain.CategoryRepository
+	contextTimeout time.Duration
+}
+
+// NewCategoryUsecase will create new an categoryUsecase object representation of domain.CategoryUsecase interface
+func NewCategoryUsecase(c domain.CategoryRepository, timeout time.Duration) domain.CategoryUsecase {
+	return &categoryUsecase{
+		categoryRepo:   c,
+		contextTimeout: timeout,
+	}
+}
+
+func (cu *categoryUsecase) Create(actor domain.Actor, cf *domain.CategoryForm) (c domain.Category, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	return cu.categoryRepo.Create(actor.OrganizationID, cf, actor.AuditContext())
+}
+
+func (cu *categoryUsecase) Tree(organizationID uuid.UUID) (roots []*domain.Category, err error) {
+	categories, err := cu.categoryRepo.Fetch(organizationID)
+	if err != nil {
+		return
+	}
+
+	return categoryTree(categories), nil
+}
+
+func (cu *categoryUsecase) Update(actor domain.Actor, id int, cf *domain.CategoryForm) (c domain.Category, err error) {
+	if actor.OrganizationID == uuid.Nil {
+		err = domain.ErrNoActiveOrganization
+		return
+	}
+
+	return cu.categoryRepo.Update(actor.OrganizationID, id, cf, actor.AuditContext())
+}
+
+func (cu *categoryUsecase) Delete(actor domain.Actor, id int) (err error) {
+	if actor.OrganizationID == uuid.Nil {
+		return domain.ErrNoActiveOrganization
+	}
+
+	return cu.categoryRepo.Delete(actor.OrganizationID, id, actor.AuditContext())
+}
+
+// categoryTree links the categories, ordered by path so parents come before their children, to their parents.
+func categoryTree(categories []domain.Category) (roots []*domain.Category) {
+	roots = []*domain.Category{}
+	nodes := make(map[int]*domain.Category, len(categories))
+
+	for i := range categories {
+		c := &categories[i]
+		nodes[c.ID] = c
+
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+
+		if parent, ok := nodes[*c.ParentID]; ok {
+			parent.Children = append(parent.Children, c)
+		}
+	}
+
+	return
+}
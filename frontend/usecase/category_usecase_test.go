@@ -0,0 +1,27 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCategoryTree(t *testing.T) {
+	fiction, scifi := 1, 2
+	categories := []domain.Category{
+		{ID: 1, Name: "Fiction", Path: "/1/", BookCount: 3},
+		{ID: 2, ParentID: &fiction, Name: "Science Fiction", Path: "/1/2/", BookCount: 2},
+		{ID: 4, ParentID: &scifi, Name: "Space Opera", Path: "/1/2/4/", BookCount: 1},
+		{ID: 3, Name: "Poetry", Path: "/3/"},
+	}
+
+	roots := categoryTree(categories)
+
+	assert.Len(t, roots, 2)
+	assert.Equal(t, "Fiction", roots[0].Name)
+	assert.Equal(t, "Poetry", roots[1].Name)
+	assert.Len(t, roots[0].Children, 1)
+	assert.Equal(t, "Space Opera", roots[0].Children[0].Children[0].Name)
+	assert.Empty(t, roots[1].Children)
+}
@@ -0,0 +1,656 @@
+package usecase
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// fakeUserRepo keeps the users by ID like the repository does
+type fakeUserRepo struct {
+	domain.UserRepository
+	users map[uuid.UUID]domain.User
+	locks int
+}
+
+func (f *fakeUserRepo) GetByID(id uuid.UUID) (domain.User, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return u, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+	return u, nil
+}
+
+func (f *fakeUserRepo) GetByEmail(email string) (domain.User, error) {
+	for _, u := range f.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return domain.User{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+}
+
+func (f *fakeUserRepo) Login(lf *domain.LoginForm) (domain.User, error) {
+	for _, u := range f.users {
+		if u.Email == lf.Email {
+			return u, nil
+		}
+	}
+	return domain.User{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+}
+
+func (f *fakeUserRepo) UpdateProfile(id uuid.UUID, pf *domain.UpdateProfileForm, ac domain.AuditContext) error {
+	u := f.users[id]
+	u.Username, u.FullName = pf.Username, pf.FullName
+	f.users[id] = u
+	return nil
+}
+
+func (f *fakeUserRepo) UpdatePassword(id uuid.UUID, passwordHash string, ac *domain.AuditContext) error {
+	u := f.users[id]
+	u.PasswordHash = passwordHash
+	f.users[id] = u
+	return nil
+}
+
+func (f *fakeUserRepo) MarkDeleted(id uuid.UUID, releaseIdentity bool, ac domain.AuditContext) error {
+	u := f.users[id]
+	u.Status = domain.ConstUserStatusDeleted
+	u.DeletedAt = int(time.Now().Unix())
+	f.users[id] = u
+	return nil
+}
+
+func (f *fakeUserRepo) UpdateStatus(id uuid.UUID, status int, ac domain.AuditContext) (int64, error) {
+	u, ok := f.users[id]
+	if !ok {
+		return 0, nil
+	}
+	u.Status = status
+	f.users[id] = u
+	return 1, nil
+}
+
+func (f *fakeUserRepo) Lock(id uuid.UUID, until int64) (bool, error) {
+	u := f.users[id]
+	if int64(u.LockedUntil) >= time.Now().Unix() {
+		return false, nil
+	}
+	u.LockedUntil = int(until)
+	f.users[id] = u
+	f.locks++
+	return true, nil
+}
+
+func (f *fakeUserRepo) CreateExternal(u *domain.User, ac domain.AuditContext) error {
+	u.ID = uuid.New()
+	u.Status = domain.ConstUserStatusActive
+	f.users[u.ID] = *u
+	return nil
+}
+
+func (f *fakeUserRepo) CheckUsernameAvailable(username string) error {
+	for _, u := range f.users {
+		if u.Username == username {
+			return domain.ErrUsernameTaken
+		}
+	}
+	return nil
+}
+
+// fakeRoleRepo knows the admin and editor roles, grants keeps the roles granted to users by user then role
+// and emails the users granted by email
+type fakeRoleRepo struct {
+	domain.RoleRepository
+	emails map[string]uuid.UUID
+	grants map[uuid.UUID]map[string]bool
+}
+
+func (f *fakeRoleRepo) Fetch() ([]domain.Role, error) {
+	return []domain.Role{
+		{Name: domain.ConstRoleAdmin, Permissions: []string{domain.ConstPermissionRoleManage, domain.ConstPermissionBookWrite}},
+		{Name: domain.ConstRoleEditor, Permissions: []string{domain.ConstPermissionBookWrite}},
+	}, nil
+}
+
+func (f *fakeRoleRepo) GetUserRoles(userID uuid.UUID) (roles []string, err error) {
+	for role := range f.grants[userID] {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return
+}
+
+func (f *fakeRoleRepo) Grant(userID uuid.UUID, role string) error {
+	if f.grants == nil {
+		f.grants = map[uuid.UUID]map[string]bool{}
+	}
+	if f.grants[userID] == nil {
+		f.grants[userID] = map[string]bool{}
+	}
+	f.grants[userID][role] = true
+	return nil
+}
+
+func (f *fakeRoleRepo) GrantByEmail(email, role string) (int64, error) {
+	userID, ok := f.emails[email]
+	if !ok {
+		return 0, nil
+	}
+	return 1, f.Grant(userID, role)
+}
+
+func (f *fakeRoleRepo) Revoke(userID uuid.UUID, role string) (int64, error) {
+	if !f.grants[userID][role] {
+		return 0, nil
+	}
+	delete(f.grants[userID], role)
+	return 1, nil
+}
+
+// fakeLoginAttemptRepo keeps the recorded login attempts
+type fakeLoginAttemptRepo struct {
+	attempts []domain.LoginAttempt
+}
+
+func (f *fakeLoginAttemptRepo) Record(a *domain.LoginAttempt) error {
+	a.CreatedAt = int(time.Now().Unix())
+	f.attempts = append(f.attempts, *a)
+	return nil
+}
+
+func (f *fakeLoginAttemptRepo) CountFailuresByEmail(email string, since int64) (count int, lastAt int64, err error) {
+	for _, a := range f.attempts {
+		if a.Email == email && a.Reason == domain.ConstLoginAttemptInvalidCredentials && int64(a.CreatedAt) >= since {
+			count++
+			lastAt = int64(a.CreatedAt)
+		}
+	}
+	return
+}
+
+func (f *fakeLoginAttemptRepo) CountFailuresByIP(ip string, since int64) (count int, firstAt int64, err error) {
+	for _, a := range f.attempts {
+		if a.IP == ip && a.Reason == domain.ConstLoginAttemptInvalidCredentials && int64(a.CreatedAt) >= since {
+			if count == 0 {
+				firstAt = int64(a.CreatedAt)
+			}
+			count++
+		}
+	}
+	return
+}
+
+// fakeSessionRepo keeps the revoked sessions
+type fakeSessionRepo struct {
+	domain.SessionRepository
+	sessions map[uuid.UUID]domain.Session
+	revoked  map[uuid.UUID]bool
+}
+
+func (f *fakeSessionRepo) RevokeAll(userID uuid.UUID) error {
+	return f.RevokeAllExcept(userID, uuid.Nil)
+}
+
+func (f *fakeSessionRepo) RevokeAllExcept(userID, keepID uuid.UUID) error {
+	for id, s := range f.sessions {
+		if s.UserID == userID && id != keepID {
+			f.revoked[id] = true
+		}
+	}
+	return nil
+}
+
+// fakePasswordHasher hashes by prefixing, hashes prefixed with "old:" are of an older algorithm.
+// Verify counts its calls
+type fakePasswordHasher struct {
+	verified int
+}
+
+func (f *fakePasswordHasher) Hash(password string) (string, error) {
+	return "hash:" + password, nil
+}
+
+func (f *fakePasswordHasher) Verify(password, encoded string) bool {
+	f.verified++
+	return encoded == "hash:"+password || encoded == "old:"+password
+}
+
+func (f *fakePasswordHasher) NeedsRehash(encoded string) bool {
+	return !strings.HasPrefix(encoded, "hash:")
+}
+
+// fakePasswordPolicy only rejects passwords containing the identity of the account
+type fakePasswordPolicy struct{}
+
+func (f fakePasswordPolicy) Check(password string, identity ...string) error {
+	for _, i := range identity {
+		if i != "" && strings.Contains(password, i) {
+			return domain.PasswordPolicyError{Field: "password", Violations: []domain.PasswordViolation{{Code: domain.ConstPasswordContainsIdentity}}}
+		}
+	}
+	return nil
+}
+
+// fakeMfaRepo keeps the TOTP settings and the unused recovery code hashes of the users like the repository does
+type fakeMfaRepo struct {
+	mfa           map[uuid.UUID]domain.UserMfa
+	recoveryCodes map[uuid.UUID]map[string]bool
+}
+
+func (f *fakeMfaRepo) Get(userID uuid.UUID) (domain.UserMfa, error) {
+	m, ok := f.mfa[userID]
+	if !ok {
+		return m, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "two-factor authentication is not set up"}
+	}
+	return m, nil
+}
+
+func (f *fakeMfaRepo) SavePending(userID uuid.UUID, secret string) error {
+	f.mfa[userID] = domain.UserMfa{UserID: userID, Secret: secret}
+	return nil
+}
+
+func (f *fakeMfaRepo) Enable(userID uuid.UUID, counter int64, recoveryCodeHashes []string) error {
+	m := f.mfa[userID]
+	m.Enabled, m.LastUsedCounter, m.FailedAttempts = true, counter, 0
+	f.mfa[userID] = m
+	return f.ReplaceRecoveryCodes(userID, recoveryCodeHashes)
+}
+
+func (f *fakeMfaRepo) ReplaceRecoveryCodes(userID uuid.UUID, recoveryCodeHashes []string) error {
+	f.recoveryCodes[userID] = map[string]bool{}
+	for _, h := range recoveryCodeHashes {
+		f.recoveryCodes[userID][h] = true
+	}
+	return nil
+}
+
+func (f *fakeMfaRepo) UseRecoveryCode(userID uuid.UUID, codeHash string) (bool, error) {
+	if !f.recoveryCodes[userID][codeHash] {
+		return false, nil
+	}
+	delete(f.recoveryCodes[userID], codeHash)
+	return true, nil
+}
+
+func (f *fakeMfaRepo) MarkCodeUsed(userID uuid.UUID, counter int64) (bool, error) {
+	m := f.mfa[userID]
+	if m.LastUsedCounter >= counter {
+		return false, nil
+	}
+	m.LastUsedCounter, m.FailedAttempts = counter, 0
+	f.mfa[userID] = m
+	return true, nil
+}
+
+func (f *fakeMfaRepo) RecordFailure(userID uuid.UUID) error {
+	m := f.mfa[userID]
+	m.FailedAttempts++
+	m.LastFailedAt = int(time.Now().Unix())
+	f.mfa[userID] = m
+	return nil
+}
+
+func (f *fakeMfaRepo) Delete(userID uuid.UUID) error {
+	delete(f.mfa, userID)
+	delete(f.recoveryCodes, userID)
+	return nil
+}
+
+// fakeAPIKeyRepo keeps the keys by prefix, RevokeAll rotates the auth_key of the user in users
+type fakeAPIKeyRepo struct {
+	users  *fakeUserRepo
+	keys   map[string]domain.APIKey
+	owners map[string]uuid.UUID
+	hashes map[string]string
+}
+
+func (f *fakeAPIKeyRepo) Create(userID uuid.UUID, k *domain.APIKey, keyHash string) error {
+	k.ID = uuid.New()
+	f.keys[k.Prefix], f.owners[k.Prefix], f.hashes[k.Prefix] = *k, userID, keyHash
+	return nil
+}
+
+func (f *fakeAPIKeyRepo) Fetch(userID uuid.UUID) (keys []domain.APIKey, err error) {
+	for prefix, k := range f.keys {
+		if f.owners[prefix] == userID {
+			keys = append(keys, k)
+		}
+	}
+	return
+}
+
+func (f *fakeAPIKeyRepo) GetByPrefix(prefix string) (domain.APIKey, uuid.UUID, string, error) {
+	k, ok := f.keys[prefix]
+	if !ok {
+		return k, uuid.Nil, "", domain.NotFoundError{Code: fiber.StatusNotFound, Message: "api key not found"}
+	}
+	return k, f.owners[prefix], f.hashes[prefix], nil
+}
+
+func (f *fakeAPIKeyRepo) Revoke(userID, keyID uuid.UUID) (int64, error) {
+	for prefix, k := range f.keys {
+		if k.ID == keyID && f.owners[prefix] == userID && k.RevokedAt == 0 {
+			k.RevokedAt = int(time.Now().Unix())
+			f.keys[prefix] = k
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (f *fakeAPIKeyRepo) RevokeAll(userID uuid.UUID, newAuthKey string) error {
+	u := f.users.users[userID]
+	u.AuthKey = newAuthKey
+	f.users.users[userID] = u
+	return nil
+}
+
+func (f *fakeAPIKeyRepo) Touch(keyID uuid.UUID, ip string) error {
+	return nil
+}
+
+// fakeOidcRepo keeps the login states and the linked identities, by provider and subject
+type fakeOidcRepo struct {
+	states     map[string]domain.OidcLoginState
+	identities map[string]domain.UserIdentity
+}
+
+func (f *fakeOidcRepo) SaveState(s *domain.OidcLoginState) error {
+	f.states[s.State] = *s
+	return nil
+}
+
+func (f *fakeOidcRepo) ConsumeState(state string) (domain.OidcLoginState, error) {
+	s, ok := f.states[state]
+	if !ok {
+		return s, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "login state not found"}
+	}
+	delete(f.states, state)
+	return s, nil
+}
+
+func (f *fakeOidcRepo) GetIdentity(provider, subject string) (domain.UserIdentity, error) {
+	i, ok := f.identities[provider+"|"+subject]
+	if !ok {
+		return i, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "identity not linked"}
+	}
+	return i, nil
+}
+
+func (f *fakeOidcRepo) LinkIdentity(i *domain.UserIdentity) error {
+	f.identities[i.Provider+"|"+i.Subject] = *i
+	return nil
+}
+
+// fakeOrganizationRepo keeps the members of organizations, by organization then user. Like the roles of fakeRoleRepo
+// only admin grants role:manage, the last admin is neither removed nor demoted.
+type fakeOrganizationRepo struct {
+	domain.OrganizationRepository
+	members map[uuid.UUID]map[uuid.UUID]string
+}
+
+func (f *fakeOrganizationRepo) GetMembership(userID, organizationID uuid.UUID) (domain.Membership, error) {
+	role, ok := f.members[organizationID][userID]
+	if !ok {
+		return domain.Membership{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "organization not found"}
+	}
+	return domain.Membership{Organization: domain.Organization{ID: organizationID}, Role: role}, nil
+}
+
+func (f *fakeOrganizationRepo) GetMember(organizationID, userID uuid.UUID) (domain.OrganizationMember, error) {
+	role, ok := f.members[organizationID][userID]
+	if !ok {
+		return domain.OrganizationMember{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "member not found"}
+	}
+	return domain.OrganizationMember{UserID: userID, Role: role}, nil
+}
+
+func (f *fakeOrganizationRepo) UpsertMember(organizationID, userID uuid.UUID, role string) error {
+	if err := f.keepManager(organizationID, userID, role); err != nil {
+		return err
+	}
+	f.members[organizationID][userID] = role
+	return nil
+}
+
+func (f *fakeOrganizationRepo) RemoveMember(organizationID, userID uuid.UUID) (int64, error) {
+	if _, ok := f.members[organizationID][userID]; !ok {
+		return 0, nil
+	}
+	if err := f.keepManager(organizationID, userID, ""); err != nil {
+		return 0, err
+	}
+	delete(f.members[organizationID], userID)
+	return 1, nil
+}
+
+func (f *fakeOrganizationRepo) keepManager(organizationID, userID uuid.UUID, role string) error {
+	if f.members[organizationID][userID] != domain.ConstRoleAdmin || role == domain.ConstRoleAdmin {
+		return nil
+	}
+	for id, r := range f.members[organizationID] {
+		if id != userID && r == domain.ConstRoleAdmin {
+			return nil
+		}
+	}
+	return domain.ConflictError{Field: "role", Message: "last member with role:manage"}
+}
+
+// fakeBookRepo keeps the books by ID, each book is only seen in its organization
+type fakeBookRepo struct {
+	domain.BookRepository
+	books     map[int]domain.Book
+	revisions map[int][]domain.BookRevision
+	// updated the form of the last update
+	updated *domain.BookForm
+}
+
+func (f *fakeBookRepo) GetByID(organizationID uuid.UUID, id int) (domain.Book, error) {
+	b, ok := f.books[id]
+	if !ok || b.OrganizationID != organizationID {
+		return domain.Book{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+	}
+	return b, nil
+}
+
+func (f *fakeBookRepo) Update(organizationID uuid.UUID, id int, bf *domain.BookForm, ac domain.AuditContext) (domain.Book, error) {
+	b, err := f.GetByID(organizationID, id)
+	if err != nil {
+		return b, err
+	}
+
+	f.updated = bf
+	b.Title, b.Author, b.Summary, b.UpdatedBy = bf.Title, bf.Author, bf.Summary, &ac.ActorID
+	f.books[id] = b
+	return b, nil
+}
+
+func (f *fakeBookRepo) Delete(organizationID uuid.UUID, id int, ac domain.AuditContext) (int64, error) {
+	if _, err := f.GetByID(organizationID, id); err != nil {
+		return 0, nil
+	}
+
+	delete(f.books, id)
+	return 1, nil
+}
+
+func (f *fakeBookRepo) SetCover(organizationID uuid.UUID, id int, cover *domain.BookCover, ac domain.AuditContext) (domain.Book, *domain.BookCover, error) {
+	b, err := f.GetByID(organizationID, id)
+	if err != nil {
+		return b, nil, err
+	}
+
+	previous := b.Cover
+	b.Cover = cover
+	f.books[id] = b
+	return b, previous, nil
+}
+
+func (f *fakeBookRepo) SetStatus(organizationID uuid.UUID, id int, from, to string, publishAt *int, ac domain.AuditContext) (domain.Book, error) {
+	b, err := f.GetByID(organizationID, id)
+	if err != nil {
+		return b, err
+	}
+	if b.Status != from {
+		return b, domain.ConflictError{Field: "status", Message: "book status changed"}
+	}
+
+	b.Status, b.PublishAt = to, publishAt
+	f.books[id] = b
+	return b, nil
+}
+
+func (f *fakeBookRepo) FetchRevisions(organizationID uuid.UUID, id int) ([]domain.BookRevision, error) {
+	if _, err := f.GetByID(organizationID, id); err != nil {
+		return nil, err
+	}
+
+	var revisions []domain.BookRevision
+	for i := len(f.revisions[id]) - 1; i >= 0; i-- {
+		revisions = append(revisions, f.revisions[id][i])
+	}
+	return revisions, nil
+}
+
+func (f *fakeBookRepo) GetRevision(organizationID uuid.UUID, id, revision int) (domain.BookRevision, error) {
+	if _, err := f.GetByID(organizationID, id); err != nil {
+		return domain.BookRevision{}, err
+	}
+	for _, r := range f.revisions[id] {
+		if r.Revision == revision {
+			return r, nil
+		}
+	}
+	return domain.BookRevision{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book revision not found"}
+}
+
+// fakeReviewRepo keeps the reviews of the books of one organization and their rating like the repository does
+type fakeReviewRepo struct {
+	books   *fakeBookRepo
+	reviews map[int][]domain.Review
+}
+
+func (f *fakeReviewRepo) book(organizationID uuid.UUID, bookID int, publishedOnly bool) (domain.Book, error) {
+	b, err := f.books.GetByID(organizationID, bookID)
+	if err == nil && publishedOnly && b.Status != domain.ConstBookStatusPublished {
+		err = domain.NotFoundError{Code: fiber.StatusNotFound, Message: "book not found"}
+	}
+	return b, err
+}
+
+func (f *fakeReviewRepo) refreshRating(b domain.Book) {
+	sum := 0
+	for _, r := range f.reviews[b.ID] {
+		sum += r.Rating
+	}
+
+	b.RatingCount, b.Rating = len(f.reviews[b.ID]), 0
+	if b.RatingCount > 0 {
+		b.Rating = float64(sum) / float64(b.RatingCount)
+	}
+	f.books.books[b.ID] = b
+}
+
+func (f *fakeReviewRepo) Fetch(organizationID uuid.UUID, bookID, perPage, page int) ([]domain.Review, int, int, int, error) {
+	if _, err := f.book(organizationID, bookID, true); err != nil {
+		return nil, 0, 0, 0, err
+	}
+	return f.reviews[bookID], len(f.reviews[bookID]), 1, 1, nil
+}
+
+func (f *fakeReviewRepo) Create(organizationID uuid.UUID, bookID int, rf *domain.ReviewForm, ac domain.AuditContext) (domain.Review, error) {
+	b, err := f.book(organizationID, bookID, true)
+	if err != nil {
+		return domain.Review{}, err
+	}
+	for _, r := range f.reviews[bookID] {
+		if r.UserID == ac.ActorID {
+			return domain.Review{}, domain.DataValidationError{Field: "book_id", Message: "you have already reviewed this book"}
+		}
+	}
+
+	r := domain.Review{ID: len(f.reviews[bookID]) + 1, BookID: bookID, UserID: ac.ActorID, Rating: rf.Rating, Body: rf.Body}
+	f.reviews[bookID] = append(f.reviews[bookID], r)
+	f.refreshRating(b)
+	return r, nil
+}
+
+func (f *fakeReviewRepo) Update(organizationID uuid.UUID, bookID int, rf *domain.ReviewForm, ac domain.AuditContext) (domain.Review, error) {
+	b, err := f.book(organizationID, bookID, true)
+	if err != nil {
+		return domain.Review{}, err
+	}
+	for i, r := range f.reviews[bookID] {
+		if r.UserID == ac.ActorID {
+			r.Rating, r.Body = rf.Rating, rf.Body
+			f.reviews[bookID][i] = r
+			f.refreshRating(b)
+			return r, nil
+		}
+	}
+	return domain.Review{}, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "review not found"}
+}
+
+func (f *fakeReviewRepo) Delete(organizationID uuid.UUID, bookID int, ac domain.AuditContext) error {
+	b, err := f.book(organizationID, bookID, false)
+	if err != nil {
+		return err
+	}
+	for i, r := range f.reviews[bookID] {
+		if r.UserID == ac.ActorID {
+			f.reviews[bookID] = append(f.reviews[bookID][:i], f.reviews[bookID][i+1:]...)
+			f.refreshRating(b)
+			return nil
+		}
+	}
+	return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "review not found"}
+}
+
+// fakeBookChapterRepo keeps the chapters by ID
+type fakeBookChapterRepo struct {
+	domain.BookChapterRepository
+	chapters map[int]domain.BookChapter
+}
+
+func (f *fakeBookChapterRepo) GetByID(organizationID uuid.UUID, bookID, id int) (domain.BookChapter, error) {
+	c, ok := f.chapters[id]
+	if !ok || c.BookID != bookID {
+		return c, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "chapter not found"}
+	}
+	return c, nil
+}
+
+func (f *fakeBookChapterRepo) Create(organizationID uuid.UUID, bookID int, cf *domain.BookChapterForm, ac domain.AuditContext) (domain.BookChapter, error) {
+	c := domain.BookChapter{BookChapterHeading: domain.BookChapterHeading{ID: len(f.chapters) + 1, Title: cf.Title}, BookID: bookID, Content: cf.Content}
+	f.chapters[c.ID] = c
+	return c, nil
+}
+
+// fakeBlobStore keeps the content type of the blobs by key
+type fakeBlobStore struct {
+	blobs map[string]string
+}
+
+func (f *fakeBlobStore) Put(key, contentType string, data []byte) error {
+	f.blobs[key] = contentType
+	return nil
+}
+
+func (f *fakeBlobStore) Get(key string) ([]byte, string, error) {
+	return nil, f.blobs[key], nil
+}
+
+func (f *fakeBlobStore) Delete(key string) error {
+	delete(f.blobs, key)
+	return nil
+}
+
+func (f *fakeBlobStore) URL(key string) string {
+	return "https://cdn.example.com/" + key
+}
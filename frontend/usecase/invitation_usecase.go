@@ -0,0 +1,156 @@
+package usecase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// invitationTokenLength length of the token sent in the invitation email
+	invitationTokenLength = 32
+	// invitationDefaultLifetimeDays lifetime of an invitation created without expires_in_days
+	invitationDefaultLifetimeDays = 7
+)
+
+type invitationUsecase struct {
+	invitationRepo domain.InvitationRepository
+	userRepo       domain.UserRepository
+	roleRepo       domain.RoleRepository
+	contextTimeout time.Duration
+}
+
+// NewInvitationUsecase will create new an invitationUsecase object representation of domain.InvitationUsecase interface
+func NewInvitationUsecase(i domain.InvitationRepository, u domain.UserRepository, r domain.RoleRepository, timeout time.Duration) domain.InvitationUsecase {
+	return &invitationUsecase{
+		invitationRepo: i,
+		userRepo:       u,
+		roleRepo:       r,
+		contextTimeout: timeout,
+	}
+}
+
+func (iu *invitationUsecase) Create(actor domain.Actor, f *domain.InvitationForm) (i domain.Invitation, err error) {
+	email := strings.ToLower(f.Email)
+	role := strings.ToLower(f.Role)
+
+	_, err = iu.userRepo.GetByEmail(email)
+	if err == nil {
+		return i, domain.DataValidationError{Field: "email", Message: "email address already registered"}
+	}
+	if _, ok := err.(domain.NotFoundError); !ok {
+		return
+	}
+
+	// role yang diberikan lewat undangan sama dengan grant role, butuh izin yang sama
+	if role != "" {
+		err = iu.checkRoleGrantable(actor, role)
+		if err != nil {
+			return
+		}
+	}
+
+	token, err := utils.GenerateRandomString(invitationTokenLength)
+	if err != nil {
+		return
+	}
+
+	lifetimeDays := f.ExpiresInDays
+	if lifetimeDays == 0 {
+		lifetimeDays = invitationDefaultLifetimeDays
+	}
+
+	now := time.Now()
+	i = domain.Invitation{
+		Email:     email,
+		Role:      role,
+		InvitedBy: actor.UserID,
+		ExpiresAt: int(now.AddDate(0, 0, lifetimeDays).Unix()),
+		CreatedAt: int(now.Unix()),
+	}
+
+	err = iu.invitationRepo.Create(&i, hashInvitationToken(token))
+	if err != nil {
+		return
+	}
+
+	// INVITATION_SIGNUP_URL env, e.g. https://example.com/signup?invite=, the token is appended
+	var signupURL string
+	if baseURL := utils.GetEnv("INVITATION_SIGNUP_URL", ""); baseURL != "" {
+		signupURL = baseURL + url.QueryEscape(token)
+	}
+
+	sendMail("Invitation To Join - Cooljar Apps", email, path.Join("assets", "html", "invitation.html"), map[string]interface{}{
+		"email":      email,
+		"token":      token,
+		"signup_url": signupURL,
+		"expires_at": now.AddDate(0, 0, lifetimeDays).Format("2 January 2006"),
+	})
+
+	return
+}
+
+func (iu *invitationUsecase) Fetch() (invitations []domain.Invitation, err error) {
+	invitations, err = iu.invitationRepo.Fetch()
+	if invitations == nil {
+		invitations = []domain.Invitation{}
+	}
+
+	return
+}
+
+func (iu *invitationUsecase) Revoke(id uuid.UUID) (err error) {
+	rowsAffected, err := iu.invitationRepo.Revoke(id)
+	if err != nil {
+		return
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "invitation not found or already used"}
+	}
+
+	return
+}
+
+// checkRoleGrantable requires the role to exist and the actor to be allowed to manage roles.
+func (iu *invitationUsecase) checkRoleGrantable(actor domain.Actor, role string) (err error) {
+	roles, err := iu.roleRepo.Fetch()
+	if err != nil {
+		return
+	}
+
+	var exists, allowed bool
+	for _, r := range roles {
+		if r.Name == role {
+			exists = true
+		}
+		if actor.HasRole(r.Name) && containsString(r.Permissions, domain.ConstPermissionRoleManage) {
+			allowed = true
+		}
+	}
+	if !exists {
+		return domain.DataValidationError{Field: "role", Message: "unknown role " + role}
+	}
+	if !allowed {
+		return domain.ForbiddenError{Message: "granting roles requires the " + domain.ConstPermissionRoleManage + " permission"}
+	}
+
+	return
+}
+
+// hashInvitationToken digest stored in place of the invitation token.
+func hashInvitationToken(token string) string {
+	digest := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(digest[:])
+}
+
+// registrationMode returns who can sign up, configured with REGISTRATION_MODE env.
+func registrationMode() string {
+	return utils.GetEnv("REGISTRATION_MODE", domain.ConstRegistrationOpen)
+}
@@ -0,0 +1,355 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"image/png"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	// totpPeriod seconds per TOTP time step
+	totpPeriod = 30
+	// mfaMaxFailedAttempts wrong codes accepted before the second factor is throttled for mfaThrottleSeconds
+	mfaMaxFailedAttempts = 5
+	mfaThrottleSeconds   = 5 * 60
+	// recoveryCodeCount number of single-use recovery codes generated at once
+	recoveryCodeCount = 10
+)
+
+type mfaUsecase struct {
+	mfaRepo        domain.MfaRepository
+	userRepo       domain.UserRepository
+	roleRepo       domain.RoleRepository
+	passwordHasher domain.PasswordHasher
+	contextTimeout time.Duration
+}
+
+// NewMfaUsecase will create new an mfaUsecase object representation of domain.MfaUsecase interface
+func NewMfaUsecase(m domain.MfaRepository, u domain.UserRepository, r domain.RoleRepository, ph domain.PasswordHasher, timeout time.Duration) domain.MfaUsecase {
+	return &mfaUsecase{
+		mfaRepo:        m,
+		userRepo:       u,
+		roleRepo:       r,
+		passwordHasher: ph,
+		contextTimeout: timeout,
+	}
+}
+
+func (mu *mfaUsecase) IsEnabled(userID uuid.UUID) (enabled bool, err error) {
+	m, err := mu.mfaRepo.Get(userID)
+	if _, ok := err.(domain.NotFoundError); ok {
+		return false, nil
+	}
+	if err != nil {
+		return
+	}
+
+	return m.Enabled, nil
+}
+
+func (mu *mfaUsecase) Enroll(userID uuid.UUID) (enrollment domain.MfaEnrollment, err error) {
+	u, err := mu.userRepo.GetByID(userID)
+	if err != nil {
+		return
+	}
+
+	roles, err := mu.roleRepo.GetUserRoles(userID)
+	if err != nil {
+		return
+	}
+	if !hasAnyRole(roles, domain.MfaEligibleRoles) {
+		err = domain.ForbiddenError{Message: "two-factor authentication is only available for editor and admin accounts"}
+		return
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      utils.GetEnv("MFA_ISSUER", "Cooljar Apps"),
+		AccountName: u.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return
+	}
+
+	err = mu.mfaRepo.SavePending(userID, key.Secret())
+	if err != nil {
+		return
+	}
+
+	img, err := key.Image(256, 256)
+	if err != nil {
+		return
+	}
+
+	var qrCode bytes.Buffer
+	err = png.Encode(&qrCode, img)
+	if err != nil {
+		return
+	}
+
+	enrollment.Secret = key.Secret()
+	enrollment.OtpauthURI = key.URL()
+	enrollment.QRCodePNG = "data:image/png;base64," + base64.StdEncoding.EncodeToString(qrCode.Bytes())
+
+	return
+}
+
+func (mu *mfaUsecase) Confirm(userID uuid.UUID, code string) (recoveryCodes []string, err error) {
+	m, err := mu.mfaRepo.Get(userID)
+	if err != nil {
+		return
+	}
+	if m.Enabled {
+		err = domain.DataValidationError{Field: "mfa", Message: "two-factor authentication is already enabled"}
+		return
+	}
+
+	err = checkMfaThrottle(m)
+	if err != nil {
+		return
+	}
+
+	counter, ok := matchTotp(m.Secret, normalizeMfaCode(code), time.Now())
+	if !ok {
+		return nil, mu.invalidCode(userID)
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return
+	}
+
+	err = mu.mfaRepo.Enable(userID, counter, hashes)
+
+	return
+}
+
+func (mu *mfaUsecase) RegenerateRecoveryCodes(userID uuid.UUID, code string) (recoveryCodes []string, err error) {
+	m, err := mu.enabledMfa(userID)
+	if err != nil {
+		return
+	}
+
+	err = mu.verifyTotp(m, normalizeMfaCode(code))
+	if err != nil {
+		return
+	}
+
+	recoveryCodes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return
+	}
+
+	err = mu.mfaRepo.ReplaceRecoveryCodes(userID, hashes)
+
+	return
+}
+
+func (mu *mfaUsecase) Disable(userID uuid.UUID, df *domain.DisableMfaForm) (err error) {
+	u, err := mu.userRepo.GetByID(userID)
+	if err != nil {
+		return
+	}
+
+	if mu.passwordHasher.Verify(df.Password, u.PasswordHash) == false {
+		return domain.DataValidationError{Field: "password", Message: "invalid password"}
+	}
+
+	m, err := mu.enabledMfa(userID)
+	if err != nil {
+		return
+	}
+
+	err = mu.verifySecondFactor(m, df.Code)
+	if err != nil {
+		return
+	}
+
+	return mu.mfaRepo.Delete(userID)
+}
+
+// CompleteLogin exchanges a MFA challenge token and a TOTP or recovery code for the logged in user.
+func (mu *mfaUsecase) CompleteLogin(mf *domain.MfaLoginForm) (u domain.User, err error) {
+	userID, err := utils.ParseMfaChallengeToken(mf.ChallengeToken)
+	if err != nil {
+		err = domain.DataValidationError{Field: "challenge_token", Message: "invalid or expired challenge token"}
+		return
+	}
+
+	m, err := mu.enabledMfa(userID)
+	if err != nil {
+		return
+	}
+
+	err = mu.verifySecondFactor(m, mf.Code)
+	if err != nil {
+		return
+	}
+
+	u, err = mu.userRepo.GetByID(userID)
+	if err != nil {
+		return
+	}
+
+	switch u.Status {
+	case domain.ConstUserStatusActive:
+	case domain.ConstUserStatusDeleted:
+		err = domain.ErrUserDeleted
+		return
+	default:
+		err = domain.ErrUserInactive
+		return
+	}
+
+	u.Roles, err = mu.roleRepo.GetUserRoles(userID)
+
+	return
+}
+
+func (mu *mfaUsecase) enabledMfa(userID uuid.UUID) (m domain.UserMfa, err error) {
+	m, err = mu.mfaRepo.Get(userID)
+	if err != nil {
+		return
+	}
+	if !m.Enabled {
+		err = domain.DataValidationError{Field: "mfa", Message: "two-factor authentication is not enabled"}
+	}
+
+	return
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code.
+func (mu *mfaUsecase) verifySecondFactor(m domain.UserMfa, code string) (err error) {
+	code = normalizeMfaCode(code)
+	if len(code) == int(otp.DigitsSix) {
+		return mu.verifyTotp(m, code)
+	}
+
+	err = checkMfaThrottle(m)
+	if err != nil {
+		return
+	}
+
+	used, err := mu.mfaRepo.UseRecoveryCode(m.UserID, hashRecoveryCode(code))
+	if err != nil {
+		return
+	}
+	if !used {
+		return mu.invalidCode(m.UserID)
+	}
+
+	return
+}
+
+func (mu *mfaUsecase) verifyTotp(m domain.UserMfa, code string) (err error) {
+	err = checkMfaThrottle(m)
+	if err != nil {
+		return
+	}
+
+	counter, ok := matchTotp(m.Secret, code, time.Now())
+	if !ok {
+		return mu.invalidCode(m.UserID)
+	}
+
+	// tolak kode yang sudah pernah dipakai (replay)
+	accepted, err := mu.mfaRepo.MarkCodeUsed(m.UserID, counter)
+	if err != nil {
+		return
+	}
+	if !accepted {
+		return mu.invalidCode(m.UserID)
+	}
+
+	return
+}
+
+func (mu *mfaUsecase) invalidCode(userID uuid.UUID) error {
+	if err := mu.mfaRepo.RecordFailure(userID); err != nil {
+		return err
+	}
+
+	return domain.DataValidationError{Field: "code", Message: "invalid code"}
+}
+
+func checkMfaThrottle(m domain.UserMfa) error {
+	elapsed := time.Now().Unix() - int64(m.LastFailedAt)
+	if m.FailedAttempts >= mfaMaxFailedAttempts && elapsed < mfaThrottleSeconds {
+		return domain.TooManyRequestsError{Message: "too many invalid codes, try again later", RetryAfter: int(mfaThrottleSeconds - elapsed)}
+	}
+
+	return nil
+}
+
+// matchTotp checks the code against the current time step and one step of clock skew on each side,
+// returning the matched time step.
+func matchTotp(secret, code string, now time.Time) (counter int64, ok bool) {
+	opts := totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+	for _, skew := range []int64{0, -1, 1} {
+		t := now.Add(time.Duration(skew*totpPeriod) * time.Second)
+		expected, err := totp.GenerateCodeCustom(secret, t, opts)
+		if err == nil && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return t.Unix() / totpPeriod, true
+		}
+	}
+
+	return 0, false
+}
+
+// generateRecoveryCodes returns new recovery codes, to be shown once, and their hashes to store.
+func generateRecoveryCodes() (codes []string, hashes []string, err error) {
+	const letters = "abcdefghjkmnpqrstuvwxyz23456789"
+
+	for i := 0; i < recoveryCodeCount; i++ {
+		code := make([]byte, 10)
+		for j := range code {
+			num, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+			if err != nil {
+				return nil, nil, err
+			}
+			code[j] = letters[num.Int64()]
+		}
+
+		formatted := string(code[:5]) + "-" + string(code[5:])
+		codes = append(codes, formatted)
+		hashes = append(hashes, hashRecoveryCode(formatted))
+	}
+
+	return
+}
+
+func hashRecoveryCode(code string) string {
+	digest := sha256.Sum256([]byte(strings.ReplaceAll(code, "-", "")))
+	return hex.EncodeToString(digest[:])
+}
+
+func normalizeMfaCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+}
+
+func hasAnyRole(roles []string, wanted []string) bool {
+	for _, r := range roles {
+		for _, w := range wanted {
+			if r == w {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,114 @@
+package usecase
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func totpCode(t *testing.T, secret string, at time.Time) string {
+	code, err := totp.GenerateCodeCustom(secret, at, totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1})
+	require.NoError(t, err)
+	return code
+}
+
+func TestMfaUsecase(t *testing.T) {
+	os.Setenv("JWT_SECRET_KEY", "test-secret")
+	defer os.Unsetenv("JWT_SECRET_KEY")
+
+	editor := domain.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "hash:secret", Status: domain.ConstUserStatusActive}
+	reader := domain.User{ID: uuid.New(), Email: "bob@example.com", Status: domain.ConstUserStatusActive}
+	mfaRepo := &fakeMfaRepo{mfa: map[uuid.UUID]domain.UserMfa{}, recoveryCodes: map[uuid.UUID]map[string]bool{}}
+	roles := &fakeRoleRepo{grants: map[uuid.UUID]map[string]bool{editor.ID: {domain.ConstRoleEditor: true}}}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{editor.ID: editor, reader.ID: reader}}
+	mu := NewMfaUsecase(mfaRepo, users, roles, &fakePasswordHasher{}, time.Second)
+
+	completeLogin := func(code string) (domain.User, error) {
+		challengeToken, _, err := utils.GenerateMfaChallengeToken(editor.ID)
+		require.NoError(t, err)
+		return mu.CompleteLogin(&domain.MfaLoginForm{ChallengeToken: challengeToken, Code: code})
+	}
+
+	var secret string
+	var recoveryCodes []string
+
+	t.Run("only editors and admins enroll", func(t *testing.T) {
+		_, err := mu.Enroll(reader.ID)
+		assert.IsType(t, domain.ForbiddenError{}, err)
+
+		enrollment, err := mu.Enroll(editor.ID)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(enrollment.OtpauthURI, "otpauth://totp/"))
+		assert.True(t, strings.HasPrefix(enrollment.QRCodePNG, "data:image/png;base64,"))
+		secret = enrollment.Secret
+
+		enabled, err := mu.IsEnabled(editor.ID)
+		require.NoError(t, err)
+		assert.False(t, enabled, "enrollment is pending until confirmed")
+	})
+
+	t.Run("confirm with a valid code", func(t *testing.T) {
+		_, err := mu.Confirm(editor.ID, "000000")
+		assert.IsType(t, domain.DataValidationError{}, err)
+
+		recoveryCodes, err = mu.Confirm(editor.ID, totpCode(t, secret, time.Now()))
+		require.NoError(t, err)
+		assert.Len(t, recoveryCodes, recoveryCodeCount)
+		for _, code := range recoveryCodes {
+			assert.False(t, mfaRepo.recoveryCodes[editor.ID][code], "recovery codes are stored hashed")
+			assert.True(t, mfaRepo.recoveryCodes[editor.ID][hashRecoveryCode(code)])
+		}
+
+		enabled, err := mu.IsEnabled(editor.ID)
+		require.NoError(t, err)
+		assert.True(t, enabled)
+	})
+
+	t.Run("login with a totp code once", func(t *testing.T) {
+		_, err := mu.CompleteLogin(&domain.MfaLoginForm{ChallengeToken: "invalid", Code: "123456"})
+		assert.IsType(t, domain.DataValidationError{}, err)
+
+		// kode time step konfirmasi sudah terpakai, kode berikutnya masih dalam toleransi clock skew
+		code := totpCode(t, secret, time.Now().Add(totpPeriod*time.Second))
+		u, err := completeLogin(code)
+		require.NoError(t, err)
+		assert.Equal(t, editor.ID, u.ID)
+		assert.Equal(t, []string{domain.ConstRoleEditor}, u.Roles)
+
+		_, err = completeLogin(code)
+		assert.IsType(t, domain.DataValidationError{}, err, "a code is not accepted twice")
+	})
+
+	t.Run("login with a recovery code once", func(t *testing.T) {
+		code := strings.ToUpper(recoveryCodes[0])
+		_, err := completeLogin(code)
+		require.NoError(t, err)
+
+		_, err = completeLogin(code)
+		assert.IsType(t, domain.DataValidationError{}, err)
+	})
+
+	t.Run("too many invalid codes are throttled", func(t *testing.T) {
+		m := mfaRepo.mfa[editor.ID]
+		m.FailedAttempts = 0
+		mfaRepo.mfa[editor.ID] = m
+
+		for i := 0; i < mfaMaxFailedAttempts; i++ {
+			_, err := completeLogin("aaaaa-aaaaa")
+			assert.IsType(t, domain.DataValidationError{}, err)
+		}
+
+		_, err := completeLogin(recoveryCodes[1])
+		require.IsType(t, domain.TooManyRequestsError{}, err, "even a valid code waits")
+		assert.True(t, mfaRepo.recoveryCodes[editor.ID][hashRecoveryCode(recoveryCodes[1])])
+	})
+}
@@ -0,0 +1,366 @@
+package usecase
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"github.com/coreos/go-oidc"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/oauth2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// oidcStateLifetime seconds a login started at the provider can be completed
+const oidcStateLifetime = 10 * 60
+
+// oidcHTTPTimeout timeout of requests sent to identity providers
+const oidcHTTPTimeout = 10 * time.Second
+
+var errInvalidOidcState = domain.DataValidationError{Field: "state", Message: "invalid or expired login state, please start again"}
+
+// oidcClaims claims of the ID token used to find or create the account
+type oidcClaims struct {
+	Subject           string      `json:"sub"`
+	Email             string      `json:"email"`
+	EmailVerified     interface{} `json:"email_verified"`
+	Name              string      `json:"name"`
+	PreferredUsername string      `json:"preferred_username"`
+}
+
+// oidcProvider a configured provider, the discovery document is fetched on first use
+type oidcProvider struct {
+	config   domain.OidcProviderConfig
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+type oidcUsecase struct {
+	oidcRepo       domain.OidcRepository
+	userRepo       domain.UserRepository
+	roleRepo       domain.RoleRepository
+	usernamePolicy domain.UsernamePolicy
+	configs        map[string]domain.OidcProviderConfig
+	httpClient     *http.Client
+	contextTimeout time.Duration
+
+	mu        sync.Mutex
+	providers map[string]*oidcProvider
+}
+
+// NewOidcUsecase will create new an oidcUsecase object representation of domain.OidcUsecase interface
+func NewOidcUsecase(o domain.OidcRepository, u domain.UserRepository, r domain.RoleRepository, up domain.UsernamePolicy, configs []domain.OidcProviderConfig, timeout time.Duration) domain.OidcUsecase {
+	ou := &oidcUsecase{
+		oidcRepo:       o,
+		userRepo:       u,
+		roleRepo:       r,
+		usernamePolicy: up,
+		configs:        map[string]domain.OidcProviderConfig{},
+		httpClient:     &http.Client{Timeout: oidcHTTPTimeout},
+		contextTimeout: timeout,
+		providers:      map[string]*oidcProvider{},
+	}
+	for _, c := range configs {
+		ou.configs[c.Name] = c
+	}
+
+	return ou
+}
+
+func (ou *oidcUsecase) Providers() (names []string) {
+	names = []string{}
+	for name := range ou.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return
+}
+
+func (ou *oidcUsecase) Authorize(provider string) (auth domain.OidcAuthorization, err error) {
+	p, err := ou.provider(provider)
+	if err != nil {
+		return
+	}
+
+	state, err := utils.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return
+	}
+	nonce, err := utils.GenerateRandomStringURLSafe(32)
+	if err != nil {
+		return
+	}
+	codeVerifier, err := utils.GenerateRandomStringURLSafe(48)
+	if err != nil {
+		return
+	}
+	state = strings.TrimRight(state, "=")
+	nonce = strings.TrimRight(nonce, "=")
+	codeVerifier = strings.TrimRight(codeVerifier, "=")
+
+	err = ou.oidcRepo.SaveState(&domain.OidcLoginState{
+		State:        state,
+		Provider:     provider,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		CreatedAt:    int(time.Now().Unix()),
+	})
+	if err != nil {
+		return
+	}
+
+	auth.State = state
+	auth.AuthorizationURL = p.oauth2.AuthCodeURL(state,
+		oidc.Nonce(nonce),
+		oauth2.SetAuthURLParam("code_challenge", pkceChallenge(codeVerifier)),
+		oauth2.SetAuthURLParam("code_challenge_method", "S256"),
+	)
+
+	return
+}
+
+func (ou *oidcUsecase) Callback(provider, code, state string, ac domain.AuditContext) (u domain.User, err error) {
+	loginState, err := ou.oidcRepo.ConsumeState(state)
+	if _, ok := err.(domain.NotFoundError); ok {
+		return u, errInvalidOidcState
+	}
+	if err != nil {
+		return
+	}
+	if loginState.Provider != provider || int64(loginState.CreatedAt)+oidcStateLifetime < time.Now().Unix() {
+		return u, errInvalidOidcState
+	}
+
+	p, err := ou.provider(provider)
+	if err != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(oidc.ClientContext(context.Background(), ou.httpClient), oidcHTTPTimeout)
+	defer cancel()
+
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.SetAuthURLParam("code_verifier", loginState.CodeVerifier))
+	if err != nil {
+		return u, domain.DataValidationError{Field: "code", Message: "authorization code was rejected by the provider"}
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return u, domain.DataValidationError{Field: "code", Message: "provider did not return an ID token"}
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return u, domain.DataValidationError{Field: "code", Message: "invalid ID token"}
+	}
+	if idToken.Nonce != loginState.Nonce {
+		return u, domain.DataValidationError{Field: "code", Message: "invalid ID token nonce"}
+	}
+
+	var claims oidcClaims
+	err = idToken.Claims(&claims)
+	if err != nil {
+		return
+	}
+	claims.Email = strings.ToLower(claims.Email)
+
+	u, err = ou.findOrCreateUser(provider, &claims, ac)
+	if err != nil {
+		return
+	}
+
+	switch u.Status {
+	case domain.ConstUserStatusActive:
+	case domain.ConstUserStatusDeleted:
+		return u, domain.ErrUserDeleted
+	default:
+		return u, domain.ErrUserInactive
+	}
+
+	u.Roles, err = ou.roleRepo.GetUserRoles(u.ID)
+
+	return
+}
+
+// findOrCreateUser returns the account linked to the identity. An unlinked identity is linked
+// to the account with the same verified email address, or to a new account.
+func (ou *oidcUsecase) findOrCreateUser(provider string, claims *oidcClaims, ac domain.AuditContext) (u domain.User, err error) {
+	identity, err := ou.oidcRepo.GetIdentity(provider, claims.Subject)
+	if err == nil {
+		return ou.userRepo.GetByID(identity.UserID)
+	}
+	if _, ok := err.(domain.NotFoundError); !ok {
+		return
+	}
+
+	// email yang belum diverifikasi provider bisa dipakai untuk mengambil alih akun orang lain
+	if claims.Email == "" || !isTrueClaim(claims.EmailVerified) {
+		return u, domain.ForbiddenError{Message: "the provider did not return a verified email address"}
+	}
+
+	u, err = ou.userRepo.GetByEmail(claims.Email)
+	if _, ok := err.(domain.NotFoundError); ok {
+		// akun baru dari identity provider hanya dibuat bila pendaftaran terbuka
+		if registrationMode() != domain.ConstRegistrationOpen {
+			return u, domain.ErrRegistrationClosed
+		}
+
+		u = domain.User{FullName: claims.Name, Email: claims.Email}
+		u.Username, err = ou.signupUsername(claims)
+		if err != nil {
+			return
+		}
+
+		err = ou.userRepo.CreateExternal(&u, ac)
+		if err != nil {
+			return
+		}
+
+		if isBootstrapAdmin(u.Email) {
+			_, err = ou.roleRepo.GrantByEmail(u.Email, domain.ConstRoleAdmin)
+			if err != nil {
+				return
+			}
+		}
+	}
+	if err != nil {
+		return
+	}
+
+	err = ou.oidcRepo.LinkIdentity(&domain.UserIdentity{
+		Provider:  provider,
+		Subject:   claims.Subject,
+		UserID:    u.ID,
+		Email:     claims.Email,
+		CreatedAt: int(time.Now().Unix()),
+	})
+
+	return
+}
+
+// provider returns the configured provider, fetching its discovery document on first use.
+func (ou *oidcUsecase) provider(name string) (p *oidcProvider, err error) {
+	config, ok := ou.configs[name]
+	if !ok {
+		return nil, domain.NotFoundError{Code: fiber.StatusNotFound, Message: "unknown identity provider"}
+	}
+
+	ou.mu.Lock()
+	defer ou.mu.Unlock()
+
+	if p = ou.providers[name]; p != nil {
+		return
+	}
+
+	// context ini juga dipakai go-oidc untuk mengambil JWKS nanti, jadi tidak diberi timeout
+	discovered, err := oidc.NewProvider(oidc.ClientContext(context.Background(), ou.httpClient), config.Issuer)
+	if err != nil {
+		return
+	}
+
+	scopes := config.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{oidc.ScopeOpenID, "email", "profile"}
+	}
+
+	p = &oidcProvider{
+		config: config,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			RedirectURL:  config.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: config.ClientID}),
+	}
+	ou.providers[name] = p
+
+	return
+}
+
+// pkceChallenge returns the S256 code challenge of the verifier.
+func pkceChallenge(codeVerifier string) string {
+	sum := sha256.Sum256([]byte(codeVerifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// isTrueClaim some providers send boolean claims as strings.
+func isTrueClaim(v interface{}) bool {
+	switch b := v.(type) {
+	case bool:
+		return b
+	case string:
+		return strings.EqualFold(b, "true")
+	}
+	return false
+}
+
+// oidcMaxUsernameSuffix the highest number appended to the username of an OIDC signup before giving up
+const oidcMaxUsernameSuffix = 1000
+
+// signupUsername returns a username for the claims that passes the username policy and is not taken,
+// appending a number to the derived username when it is reserved, too short or clashes with an account.
+func (ou *oidcUsecase) signupUsername(claims *oidcClaims) (username string, err error) {
+	maxLength := ou.usernamePolicy.MaxLength()
+	base := oidcUsername(claims, maxLength)
+
+	for i := 1; i <= oidcMaxUsernameSuffix; i++ {
+		username = base
+		if i > 1 {
+			suffix := strconv.Itoa(i)
+			username = oidcUsernamePrefix(base, maxLength-len(suffix)) + suffix
+		}
+
+		err = ou.usernamePolicy.Check(username)
+		if err == nil {
+			err = ou.userRepo.CheckUsernameAvailable(username)
+		}
+		if _, ok := err.(domain.DataValidationError); !ok {
+			return
+		}
+	}
+
+	return
+}
+
+// oidcUsernamePrefix returns at most n characters of the start of the username, all of them when n isn't positive.
+func oidcUsernamePrefix(username string, n int) string {
+	r := []rune(username)
+	if n > 0 && len(r) > n {
+		r = r[:n]
+	}
+
+	return string(r)
+}
+
+// oidcUsername derives a username accepted by the signup rules (lowercase, letters and digits)
+// of at most maxLength characters.
+func oidcUsername(claims *oidcClaims, maxLength int) string {
+	source := claims.PreferredUsername
+	if source == "" {
+		source = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+
+	var b strings.Builder
+	for _, r := range strings.ToLower(source) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	username := b.String()
+	if username == "" {
+		username = "user"
+	}
+
+	return oidcUsernamePrefix(username, maxLength)
+}
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// mockOidcServer a minimal OpenID Connect provider: discovery, JWKS and token endpoints.
+type mockOidcServer struct {
+	*httptest.Server
+	key      *rsa.PrivateKey
+	clientID string
+
+	mu sync.Mutex
+	// codes issued authorization codes, with the request they were issued for
+	codes map[string]mockAuthorization
+}
+
+type mockAuthorization struct {
+	nonce         string
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+func newMockOidcServer(t *testing.T, clientID string) *mockOidcServer {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &mockOidcServer{key: key, clientID: clientID, codes: map[string]mockAuthorization{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &m.key.PublicKey, KeyID: "test", Algorithm: "RS256", Use: "sig"},
+		}})
+	})
+	mux.HandleFunc("/token", m.token)
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+
+	return m
+}
+
+// authorize simulates the user approving the login at the provider, returns the authorization code.
+func (m *mockOidcServer) authorize(t *testing.T, authorizationURL string, claims jwt.MapClaims) string {
+	u, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	assert.Equal(t, m.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "S256", q.Get("code_challenge_method"))
+	assert.Equal(t, m.clientID, q.Get("client_id"))
+
+	code := uuid.New().String()
+	m.mu.Lock()
+	m.codes[code] = mockAuthorization{nonce: q.Get("nonce"), codeChallenge: q.Get("code_challenge"), claims: claims}
+	m.mu.Unlock()
+
+	return code
+}
+
+func (m *mockOidcServer) token(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	auth, ok := m.codes[r.FormValue("code")]
+	delete(m.codes, r.FormValue("code"))
+	m.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != auth.codeChallenge {
+		w.WriteHeader(http.StatusBadRequest)
+		_ = json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":   m.URL,
+		"aud":   m.clientID,
+		"iat":   time.Now().Unix(),
+		"exp":   time.Now().Add(time.Minute).Unix(),
+		"nonce": auth.nonce,
+	}
+	for k, v := range auth.claims {
+		claims[k] = v
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, _ := token.SignedString(m.key)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   60,
+		"id_token":     idToken,
+	})
+}
+
+func TestOidcUsecase_Callback(t *testing.T) {
+	const clientID = "go-postgres-fiber"
+	server := newMockOidcServer(t, clientID)
+
+	existing := domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	oidcRepo := &fakeOidcRepo{states: map[string]domain.OidcLoginState{}, identities: map[string]domain.UserIdentity{}}
+	userRepo := &fakeUserRepo{users: map[uuid.UUID]domain.User{existing.ID: existing}}
+
+	uc := NewOidcUsecase(oidcRepo, userRepo, &fakeRoleRepo{}, testUsernamePolicy(), []domain.OidcProviderConfig{{
+		Name:        "mock",
+		Issuer:      server.URL,
+		ClientID:    clientID,
+		RedirectURL: "http://localhost/api/v1/user/oidc/mock/callback",
+	}}, time.Second)
+
+	login := func(claims jwt.MapClaims) (domain.User, error) {
+		auth, err := uc.Authorize("mock")
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		code := server.authorize(t, auth.AuthorizationURL, claims)
+		return uc.Callback("mock", code, auth.State, domain.AuditContext{})
+	}
+
+	t.Run("links existing account by verified email", func(t *testing.T) {
+		u, err := login(jwt.MapClaims{"sub": "jane-sub", "email": "Jane@Example.com", "email_verified": true})
+		assert.NoError(t, err)
+		assert.Equal(t, existing.ID, u.ID)
+		assert.Equal(t, existing.ID, oidcRepo.identities["mock|jane-sub"].UserID)
+	})
+
+	t.Run("linked identity logs in without email", func(t *testing.T) {
+		u, err := login(jwt.MapClaims{"sub": "jane-sub"})
+		assert.NoError(t, err)
+		assert.Equal(t, existing.ID, u.ID)
+	})
+
+	t.Run("creates account for unknown email", func(t *testing.T) {
+		u, err := login(jwt.MapClaims{"sub": "bob-sub", "email": "bob.smith@example.com", "email_verified": "true", "name": "Bob Smith"})
+		assert.NoError(t, err)
+		assert.NotEqual(t, existing.ID, u.ID)
+		assert.Equal(t, "bobsmith", u.Username)
+		assert.Equal(t, "Bob Smith", u.FullName)
+	})
+
+	t.Run("applies the username policy to new accounts", func(t *testing.T) {
+		u, err := login(jwt.MapClaims{"sub": "admin-sub", "email": "admin@example.com", "email_verified": true})
+		assert.NoError(t, err)
+		assert.Equal(t, "admin2", u.Username, "reserved usernames get a number")
+
+		u, err = login(jwt.MapClaims{"sub": "jane2-sub", "email": "jane@example.org", "email_verified": true})
+		assert.NoError(t, err)
+		assert.Equal(t, "jane2", u.Username, "taken usernames get a number")
+
+		u, err = login(jwt.MapClaims{"sub": "long-sub", "email": "bob.smith@example.org", "email_verified": true})
+		assert.NoError(t, err)
+		assert.Equal(t, "bobsmit2", u.Username, "the number fits in the maximum length")
+	})
+
+	t.Run("rejects unverified email", func(t *testing.T) {
+		_, err := login(jwt.MapClaims{"sub": "mallory-sub", "email": "jane@example.com", "email_verified": false})
+		assert.IsType(t, domain.ForbiddenError{}, err)
+	})
+
+	t.Run("state can be used once", func(t *testing.T) {
+		auth, err := uc.Authorize("mock")
+		assert.NoError(t, err)
+		code := server.authorize(t, auth.AuthorizationURL, jwt.MapClaims{"sub": "jane-sub"})
+
+		_, err = uc.Callback("mock", code, auth.State, domain.AuditContext{})
+		assert.NoError(t, err)
+
+		_, err = uc.Callback("mock", code, auth.State, domain.AuditContext{})
+		assert.Equal(t, errInvalidOidcState, err)
+	})
+
+	t.Run("rejects PKCE verifier mismatch", func(t *testing.T) {
+		auth, err := uc.Authorize("mock")
+		assert.NoError(t, err)
+		code := server.authorize(t, auth.AuthorizationURL, jwt.MapClaims{"sub": "jane-sub"})
+
+		s := oidcRepo.states[auth.State]
+		s.CodeVerifier = "tampered"
+		oidcRepo.states[auth.State] = s
+
+		_, err = uc.Callback("mock", code, auth.State, domain.AuditContext{})
+		assert.IsType(t, domain.DataValidationError{}, err)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		_, err := uc.Authorize("nope")
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+}
+
+func TestOidcUsername(t *testing.T) {
+	assert.Equal(t, "janedoe", oidcUsername(&oidcClaims{Email: "Jane.Doe@example.com"}, 32))
+	assert.Equal(t, "user", oidcUsername(&oidcClaims{PreferredUsername: "..."}, 32))
+
+	// dipotong per karakter, bukan per byte
+	username := oidcUsername(&oidcClaims{PreferredUsername: strings.Repeat("ü", 40)}, 32)
+	assert.True(t, utf8.ValidString(username))
+	assert.Equal(t, 32, utf8.RuneCountInString(username))
+}
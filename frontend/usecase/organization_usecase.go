@@ -0,0 +1,176 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+type organizationUsecase struct {
+	orgRepo        domain.OrganizationRepository
+	userRepo       domain.UserRepository
+	roleRepo       domain.RoleRepository
+	sessionRepo    domain.SessionRepository
+	contextTimeout time.Duration
+}
+
+// NewOrganizationUsecase will create new an organizationUsecase object representation of domain.OrganizationUsecase interface
+func NewOrganizationUsecase(o domain.OrganizationRepository, u domain.UserRepository, r domain.RoleRepository, s domain.SessionRepository, timeout time.Duration) domain.OrganizationUsecase {
+	return &organizationUsecase{
+		orgRepo:        o,
+		userRepo:       u,
+		roleRepo:       r,
+		sessionRepo:    s,
+		contextTimeout: timeout,
+	}
+}
+
+// Create creates an organization, its creator becomes its admin.
+func (ou *organizationUsecase) Create(actor domain.Actor, f *domain.OrganizationForm) (m domain.Membership, err error) {
+	m.Organization = domain.Organization{
+		Slug:      strings.ToLower(f.Slug),
+		Name:      f.Name,
+		CreatedAt: int(time.Now().Unix()),
+	}
+	m.Role = domain.ConstRoleAdmin
+
+	err = ou.orgRepo.Create(&m.Organization, actor.UserID, m.Role)
+
+	return
+}
+
+func (ou *organizationUsecase) Fetch(userID uuid.UUID) (memberships []domain.Membership, err error) {
+	memberships, err = ou.orgRepo.FetchMemberships(userID)
+	if memberships == nil {
+		memberships = []domain.Membership{}
+	}
+
+	return
+}
+
+func (ou *organizationUsecase) GetBySlug(slug string) (o domain.Organization, err error) {
+	return ou.orgRepo.GetBySlug(strings.ToLower(slug))
+}
+
+func (ou *organizationUsecase) DefaultMembership(userID uuid.UUID) (m *domain.Membership, err error) {
+	memberships, err := ou.orgRepo.FetchMemberships(userID)
+	if err != nil || len(memberships) == 0 {
+		return
+	}
+
+	return &memberships[0], nil
+}
+
+func (ou *organizationUsecase) GetMembership(userID, organizationID uuid.UUID) (m domain.Membership, err error) {
+	return ou.orgRepo.GetMembership(userID, organizationID)
+}
+
+// FetchMembers lists the members of an organization, for its members only.
+func (ou *organizationUsecase) FetchMembers(actor domain.Actor, organizationID uuid.UUID) (members []domain.OrganizationMember, err error) {
+	_, err = ou.orgRepo.GetMembership(actor.UserID, organizationID)
+	if err != nil {
+		return
+	}
+
+	members, err = ou.orgRepo.FetchMembers(organizationID)
+	if members == nil {
+		members = []domain.OrganizationMember{}
+	}
+
+	return
+}
+
+// SetMember adds a registered user to the organization or changes the role of a member.
+// A member whose role changes is signed out, access tokens carry the role within the organization.
+func (ou *organizationUsecase) SetMember(actor domain.Actor, organizationID uuid.UUID, f *domain.MembershipForm) (member domain.OrganizationMember, err error) {
+	role := strings.ToLower(f.Role)
+
+	err = ou.checkCanManageMembers(actor, organizationID, role)
+	if err != nil {
+		return
+	}
+
+	u, err := ou.userRepo.GetByEmail(strings.ToLower(f.Email))
+	if err != nil {
+		return
+	}
+	if u.Status != domain.ConstUserStatusActive {
+		return member, domain.DataValidationError{Field: "email", Message: "account is not active"}
+	}
+
+	before, err := ou.orgRepo.GetMember(organizationID, u.ID)
+	_, isNew := err.(domain.NotFoundError)
+	if err != nil && !isNew {
+		return
+	}
+
+	err = ou.orgRepo.UpsertMember(organizationID, u.ID, role)
+	if err != nil {
+		return
+	}
+
+	// token lama masih membawa role sebelumnya
+	if !isNew && before.Role != role {
+		err = ou.sessionRepo.RevokeAll(u.ID)
+		if err != nil {
+			return
+		}
+	}
+
+	return ou.orgRepo.GetMember(organizationID, u.ID)
+}
+
+// RemoveMember removes the user from the organization and signs them out, their access tokens may be scoped to it.
+func (ou *organizationUsecase) RemoveMember(actor domain.Actor, organizationID, userID uuid.UUID) (err error) {
+	// anggota boleh keluar sendiri dari organisasi
+	if userID != actor.UserID {
+		err = ou.checkCanManageMembers(actor, organizationID, "")
+		if err != nil {
+			return
+		}
+	}
+
+	rowsAffected, err := ou.orgRepo.RemoveMember(organizationID, userID)
+	if err != nil {
+		return
+	}
+	if rowsAffected == 0 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "member not found"}
+	}
+
+	return ou.sessionRepo.RevokeAll(userID)
+}
+
+// checkCanManageMembers requires the role within the organization of the actor to grant the role:manage permission,
+// global roles do not count. The role to grant, if any, must exist.
+func (ou *organizationUsecase) checkCanManageMembers(actor domain.Actor, organizationID uuid.UUID, role string) (err error) {
+	membership, err := ou.orgRepo.GetMembership(actor.UserID, organizationID)
+	if err != nil {
+		return
+	}
+
+	roles, err := ou.roleRepo.Fetch()
+	if err != nil {
+		return
+	}
+
+	var exists, allowed bool
+	for _, r := range roles {
+		if r.Name == role {
+			exists = true
+		}
+		if r.Name == membership.Role && containsString(r.Permissions, domain.ConstPermissionRoleManage) {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return domain.ForbiddenError{Message: "managing members requires the " + domain.ConstPermissionRoleManage + " permission in the organization"}
+	}
+	if role != "" && !exists {
+		return domain.DataValidationError{Field: "role", Message: "unknown role " + role}
+	}
+
+	return
+}
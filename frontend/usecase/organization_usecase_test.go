@@ -0,0 +1,139 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrganizationUsecase_Members(t *testing.T) {
+	organizationID := uuid.New()
+	admin := domain.User{ID: uuid.New(), Email: "admin@example.com", Status: domain.ConstUserStatusActive}
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	bob := domain.User{ID: uuid.New(), Email: "bob@example.com", Status: domain.ConstUserStatusActive}
+
+	orgs := &fakeOrganizationRepo{members: map[uuid.UUID]map[uuid.UUID]string{
+		organizationID: {admin.ID: domain.ConstRoleAdmin, jane.ID: domain.ConstRoleEditor},
+	}}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{admin.ID: admin, jane.ID: jane, bob.ID: bob}}
+	ou := NewOrganizationUsecase(orgs, users, &fakeRoleRepo{}, &fakeSessionRepo{revoked: map[uuid.UUID]bool{}}, time.Second)
+	actor := domain.Actor{UserID: admin.ID, OrganizationID: organizationID, OrganizationRole: domain.ConstRoleAdmin}
+
+	t.Run("only members with role:manage in the organization manage members", func(t *testing.T) {
+		globalAdmin := domain.Actor{UserID: jane.ID, Roles: []string{domain.ConstRoleAdmin}, OrganizationID: organizationID, OrganizationRole: domain.ConstRoleEditor}
+		_, err := ou.SetMember(globalAdmin, organizationID, &domain.MembershipForm{Email: bob.Email})
+		assert.IsType(t, domain.ForbiddenError{}, err, "global roles do not count")
+
+		err = ou.RemoveMember(globalAdmin, organizationID, admin.ID)
+		assert.IsType(t, domain.ForbiddenError{}, err)
+	})
+
+	t.Run("adds a member and changes its role", func(t *testing.T) {
+		member, err := ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: bob.Email})
+		require.NoError(t, err)
+		assert.Equal(t, bob.ID, member.UserID)
+		assert.Equal(t, "", member.Role)
+
+		member, err = ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: bob.Email, Role: "Editor"})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ConstRoleEditor, member.Role)
+
+		_, err = ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: bob.Email, Role: "owner"})
+		assert.IsType(t, domain.DataValidationError{}, err)
+	})
+
+	t.Run("members leave on their own", func(t *testing.T) {
+		require.NoError(t, ou.RemoveMember(domain.Actor{UserID: jane.ID}, organizationID, jane.ID))
+		assert.NotContains(t, orgs.members[organizationID], jane.ID)
+
+		err := ou.RemoveMember(actor, organizationID, jane.ID)
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+}
+
+func TestOrganizationUsecase_MembershipChangesRevokeSessions(t *testing.T) {
+	organizationID := uuid.New()
+	admin := domain.User{ID: uuid.New(), Email: "admin@example.com", Status: domain.ConstUserStatusActive}
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	bob := domain.User{ID: uuid.New(), Email: "bob@example.com", Status: domain.ConstUserStatusActive}
+	janeSession, bobSession, adminSession := uuid.New(), uuid.New(), uuid.New()
+
+	orgs := &fakeOrganizationRepo{members: map[uuid.UUID]map[uuid.UUID]string{
+		organizationID: {admin.ID: domain.ConstRoleAdmin, jane.ID: domain.ConstRoleAdmin},
+	}}
+	sessions := &fakeSessionRepo{revoked: map[uuid.UUID]bool{}, sessions: map[uuid.UUID]domain.Session{
+		janeSession:  {ID: janeSession, UserID: jane.ID},
+		bobSession:   {ID: bobSession, UserID: bob.ID},
+		adminSession: {ID: adminSession, UserID: admin.ID},
+	}}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{admin.ID: admin, jane.ID: jane, bob.ID: bob}}
+	ou := NewOrganizationUsecase(orgs, users, &fakeRoleRepo{}, sessions, time.Second)
+	actor := domain.Actor{UserID: admin.ID, OrganizationID: organizationID, OrganizationRole: domain.ConstRoleAdmin}
+
+	t.Run("adding a member keeps their sessions", func(t *testing.T) {
+		_, err := ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: bob.Email, Role: domain.ConstRoleEditor})
+		require.NoError(t, err)
+		assert.Empty(t, sessions.revoked)
+	})
+
+	t.Run("keeping the role keeps the sessions", func(t *testing.T) {
+		_, err := ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: jane.Email, Role: domain.ConstRoleAdmin})
+		require.NoError(t, err)
+		assert.Empty(t, sessions.revoked)
+	})
+
+	t.Run("changing the role revokes the sessions", func(t *testing.T) {
+		member, err := ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: jane.Email, Role: domain.ConstRoleEditor})
+		require.NoError(t, err)
+		assert.Equal(t, domain.ConstRoleEditor, member.Role)
+		assert.Equal(t, map[uuid.UUID]bool{janeSession: true}, sessions.revoked)
+	})
+
+	t.Run("removing a member revokes the sessions", func(t *testing.T) {
+		require.NoError(t, ou.RemoveMember(actor, organizationID, bob.ID))
+		assert.True(t, sessions.revoked[bobSession])
+		assert.False(t, sessions.revoked[adminSession])
+
+		err := ou.RemoveMember(actor, organizationID, bob.ID)
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+}
+
+func TestOrganizationUsecase_KeepsLastManager(t *testing.T) {
+	organizationID := uuid.New()
+	admin := domain.User{ID: uuid.New(), Email: "admin@example.com", Status: domain.ConstUserStatusActive}
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	adminSession := uuid.New()
+
+	orgs := &fakeOrganizationRepo{members: map[uuid.UUID]map[uuid.UUID]string{
+		organizationID: {admin.ID: domain.ConstRoleAdmin, jane.ID: domain.ConstRoleEditor},
+	}}
+	sessions := &fakeSessionRepo{revoked: map[uuid.UUID]bool{}, sessions: map[uuid.UUID]domain.Session{
+		adminSession: {ID: adminSession, UserID: admin.ID},
+	}}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{admin.ID: admin, jane.ID: jane}}
+	ou := NewOrganizationUsecase(orgs, users, &fakeRoleRepo{}, sessions, time.Second)
+	actor := domain.Actor{UserID: admin.ID, OrganizationID: organizationID, OrganizationRole: domain.ConstRoleAdmin}
+
+	err := ou.RemoveMember(actor, organizationID, admin.ID)
+	assert.IsType(t, domain.ConflictError{}, err, "the last manager does not leave")
+
+	_, err = ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: admin.Email, Role: domain.ConstRoleEditor})
+	assert.IsType(t, domain.ConflictError{}, err, "the last manager is not demoted")
+	assert.Equal(t, domain.ConstRoleAdmin, orgs.members[organizationID][admin.ID])
+	assert.Empty(t, sessions.revoked)
+
+	_, err = ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: jane.Email, Role: domain.ConstRoleAdmin})
+	require.NoError(t, err)
+
+	_, err = ou.SetMember(actor, organizationID, &domain.MembershipForm{Email: admin.Email, Role: domain.ConstRoleEditor})
+	require.NoError(t, err, "another member manages the organization")
+
+	janeActor := domain.Actor{UserID: jane.ID, OrganizationID: organizationID, OrganizationRole: domain.ConstRoleAdmin}
+	require.NoError(t, ou.RemoveMember(janeActor, organizationID, admin.ID))
+	assert.IsType(t, domain.ConflictError{}, ou.RemoveMember(janeActor, organizationID, jane.ID))
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"time"
+)
+
+type reviewUsecase struct {
+	reviewRepo     domain.ReviewRepository
+	contextTimeout time.Duration
+}
+
+// NewReviewUsecase will create new an reviewUsecase object representation of domain.ReviewUsecase interface
+func NewReviewUsecase(r domain.ReviewRepository, timeout time.Duration) domain.ReviewUsecase {
+	return &reviewUsecase{
+		reviewRepo:     r,
+		contextTimeout: timeout,
+	}
+}
+
+func (ru *reviewUsecase) Fetch(organizationID uuid.UUID, bookID, perPage, page int) (reviews []domain.Review, totalCount, pageCount, currentPage int, err error) {
+	return ru.reviewRepo.Fetch(organizationID, bookID, perPage, page)
+}
+
+func (ru *reviewUsecase) Create(actor domain.Actor, organizationID uuid.UUID, bookID int, rf *domain.ReviewForm) (r domain.Review, err error) {
+	return ru.reviewRepo.Create(organizationID, bookID, rf, actor.AuditContext())
+}
+
+func (ru *reviewUsecase) Update(actor domain.Actor, organizationID uuid.UUID, bookID int, rf *domain.ReviewForm) (r domain.Review, err error) {
+	return ru.reviewRepo.Update(organizationID, bookID, rf, actor.AuditContext())
+}
+
+func (ru *reviewUsecase) Delete(actor domain.Actor, organizationID uuid.UUID, bookID int) (err error) {
+	return ru.reviewRepo.Delete(organizationID, bookID, actor.AuditContext())
+}
@@ -0,0 +1,74 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReviewUsecase(t *testing.T) {
+	organizationID := uuid.New()
+	books := &fakeBookRepo{books: map[int]domain.Book{
+		1: {ID: 1, OrganizationID: organizationID, Status: domain.ConstBookStatusPublished},
+		2: {ID: 2, OrganizationID: organizationID, Status: domain.ConstBookStatusDraft},
+	}}
+	ru := NewReviewUsecase(&fakeReviewRepo{books: books, reviews: map[int][]domain.Review{}}, time.Second)
+
+	jane := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID}
+	bob := domain.Actor{UserID: uuid.New(), OrganizationID: organizationID}
+
+	t.Run("create and average", func(t *testing.T) {
+		r, err := ru.Create(jane, organizationID, 1, &domain.ReviewForm{Rating: 5, Body: "great"})
+		require.NoError(t, err)
+		assert.Equal(t, jane.UserID, r.UserID)
+
+		_, err = ru.Create(bob, organizationID, 1, &domain.ReviewForm{Rating: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, 3.5, books.books[1].Rating)
+		assert.Equal(t, 2, books.books[1].RatingCount)
+	})
+
+	t.Run("a user reviews a book once", func(t *testing.T) {
+		_, err := ru.Create(jane, organizationID, 1, &domain.ReviewForm{Rating: 1})
+		assert.IsType(t, domain.DataValidationError{}, err)
+		assert.Equal(t, 2, books.books[1].RatingCount)
+	})
+
+	t.Run("update changes the own review", func(t *testing.T) {
+		r, err := ru.Update(bob, organizationID, 1, &domain.ReviewForm{Rating: 4, Body: "better on second read"})
+		require.NoError(t, err)
+		assert.Equal(t, bob.UserID, r.UserID)
+		assert.Equal(t, 4.5, books.books[1].Rating)
+	})
+
+	t.Run("delete removes the own review", func(t *testing.T) {
+		require.NoError(t, ru.Delete(jane, organizationID, 1))
+		assert.Equal(t, 4.0, books.books[1].Rating)
+		assert.Equal(t, 1, books.books[1].RatingCount)
+
+		err := ru.Delete(jane, organizationID, 1)
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+
+	t.Run("unpublished books can not be reviewed", func(t *testing.T) {
+		_, err := ru.Create(jane, organizationID, 2, &domain.ReviewForm{Rating: 5})
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+
+	t.Run("a review of an unpublished book is still deleted", func(t *testing.T) {
+		b := books.books[1]
+		b.Status = domain.ConstBookStatusArchived
+		books.books[1] = b
+
+		_, err := ru.Update(bob, organizationID, 1, &domain.ReviewForm{Rating: 1})
+		assert.IsType(t, domain.NotFoundError{}, err)
+
+		require.NoError(t, ru.Delete(bob, organizationID, 1))
+		assert.Equal(t, 0, books.books[1].RatingCount)
+	})
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rolePermissionsTTL how long the role to permissions mapping is cached
+const rolePermissionsTTL = time.Minute
+
+type roleUsecase struct {
+	roleRepo       domain.RoleRepository
+	contextTimeout time.Duration
+
+	mu              sync.RWMutex
+	rolePermissions map[string]map[string]bool
+	loadedAt        time.Time
+}
+
+// NewRoleUsecase will create new an roleUsecase object representation of domain.RoleUsecase interface
+func NewRoleUsecase(r domain.RoleRepository, timeout time.Duration) domain.RoleUsecase {
+	return &roleUsecase{
+		roleRepo:       r,
+		contextTimeout: timeout,
+	}
+}
+
+func (ru *roleUsecase) Fetch() (roles []domain.Role, err error) {
+	return ru.roleRepo.Fetch()
+}
+
+func (ru *roleUsecase) GetUserRoles(userID uuid.UUID) (roles []string, err error) {
+	return ru.roleRepo.GetUserRoles(userID)
+}
+
+func (ru *roleUsecase) Grant(userID uuid.UUID, role string) (err error) {
+	return ru.roleRepo.Grant(userID, strings.ToLower(role))
+}
+
+func (ru *roleUsecase) Revoke(userID uuid.UUID, role string) (err error) {
+	rowsAffected, err := ru.roleRepo.Revoke(userID, strings.ToLower(role))
+	if err != nil {
+		return
+	}
+	if rowsAffected < 1 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user does not have this role"}
+	}
+
+	return
+}
+
+// HasPermission reports whether any of the given roles grants the permission.
+func (ru *roleUsecase) HasPermission(roles []string, permission string) (allowed bool, err error) {
+	rolePermissions, err := ru.loadRolePermissions()
+	if err != nil {
+		return
+	}
+
+	for _, role := range roles {
+		if rolePermissions[role][permission] {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Bootstrap grants the admin role to the accounts registered with the given email addresses.
+func (ru *roleUsecase) Bootstrap(emails []string) (err error) {
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		_, err = ru.roleRepo.GrantByEmail(email, domain.ConstRoleAdmin)
+		if err != nil {
+			return
+		}
+	}
+
+	return
+}
+
+func (ru *roleUsecase) loadRolePermissions() (map[string]map[string]bool, error) {
+	ru.mu.RLock()
+	if ru.rolePermissions != nil && time.Since(ru.loadedAt) < rolePermissionsTTL {
+		defer ru.mu.RUnlock()
+		return ru.rolePermissions, nil
+	}
+	ru.mu.RUnlock()
+
+	roles, err := ru.roleRepo.Fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	rolePermissions := make(map[string]map[string]bool, len(roles))
+	for _, r := range roles {
+		rolePermissions[r.Name] = make(map[string]bool, len(r.Permissions))
+		for _, p := range r.Permissions {
+			rolePermissions[r.Name][p] = true
+		}
+	}
+
+	ru.mu.Lock()
+	ru.rolePermissions = rolePermissions
+	ru.loadedAt = time.Now()
+	ru.mu.Unlock()
+
+	return rolePermissions, nil
+}
@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoleUsecase(t *testing.T) {
+	jane, bob := uuid.New(), uuid.New()
+	repo := &fakeRoleRepo{emails: map[string]uuid.UUID{"jane@example.com": jane}}
+	ru := NewRoleUsecase(repo, time.Second)
+
+	t.Run("permissions of the roles", func(t *testing.T) {
+		allowed, err := ru.HasPermission([]string{domain.ConstRoleEditor}, domain.ConstPermissionBookWrite)
+		require.NoError(t, err)
+		assert.True(t, allowed)
+
+		allowed, err = ru.HasPermission([]string{domain.ConstRoleEditor}, domain.ConstPermissionRoleManage)
+		require.NoError(t, err)
+		assert.False(t, allowed)
+
+		allowed, err = ru.HasPermission(nil, domain.ConstPermissionBookWrite)
+		require.NoError(t, err)
+		assert.False(t, allowed, "users without roles have no permission")
+	})
+
+	t.Run("bootstrap grants admin to registered emails", func(t *testing.T) {
+		require.NoError(t, ru.Bootstrap([]string{" jane@example.com ", "", "nobody@example.com"}))
+		assert.Equal(t, map[uuid.UUID]map[string]bool{jane: {domain.ConstRoleAdmin: true}}, repo.grants)
+	})
+
+	t.Run("grant and revoke", func(t *testing.T) {
+		require.NoError(t, ru.Grant(bob, "Editor"))
+		assert.True(t, repo.grants[bob][domain.ConstRoleEditor])
+
+		require.NoError(t, ru.Revoke(bob, domain.ConstRoleEditor))
+		assert.False(t, repo.grants[bob][domain.ConstRoleEditor])
+
+		err := ru.Revoke(bob, domain.ConstRoleEditor)
+		assert.IsType(t, domain.NotFoundError{}, err)
+	})
+}
@@ -0,0 +1,89 @@
+package usecase
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"time"
+)
+
+// sessionUserAgentMaxLength longer user agents are truncated to fit the column
+const sessionUserAgentMaxLength = 512
+
+type sessionUsecase struct {
+	sessionRepo    domain.SessionRepository
+	contextTimeout time.Duration
+}
+
+// NewSessionUsecase will create new an sessionUsecase object representation of domain.SessionUsecase interface
+func NewSessionUsecase(s domain.SessionRepository, timeout time.Duration) domain.SessionUsecase {
+	return &sessionUsecase{
+		sessionRepo:    s,
+		contextTimeout: timeout,
+	}
+}
+
+func (su *sessionUsecase) Start(userID uuid.UUID, userAgent, ip string) (s domain.Session, err error) {
+	if len(userAgent) > sessionUserAgentMaxLength {
+		userAgent = userAgent[:sessionUserAgentMaxLength]
+	}
+
+	now := int(time.Now().Unix())
+	s = domain.Session{
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IP:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  int(utils.AccessTokenExpiresAt()),
+	}
+
+	err = su.sessionRepo.Create(&s)
+
+	return
+}
+
+func (su *sessionUsecase) Fetch(userID, currentID uuid.UUID) (sessions []domain.Session, err error) {
+	sessions, err = su.sessionRepo.Fetch(userID)
+	if err != nil {
+		return
+	}
+	if sessions == nil {
+		sessions = []domain.Session{}
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	return
+}
+
+func (su *sessionUsecase) Revoke(userID, id uuid.UUID) (err error) {
+	rowsAffected, err := su.sessionRepo.Revoke(userID, id)
+	if err != nil {
+		return
+	}
+	if rowsAffected < 1 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "session not found"}
+	}
+
+	return
+}
+
+func (su *sessionUsecase) Validate(userID, id uuid.UUID, ip string) (err error) {
+	s, err := su.sessionRepo.GetByID(id)
+	if _, ok := err.(domain.NotFoundError); ok {
+		return domain.ErrSessionRevoked
+	}
+	if err != nil {
+		return
+	}
+
+	if s.UserID != userID || s.RevokedAt != 0 || int64(s.ExpiresAt) <= time.Now().Unix() {
+		return domain.ErrSessionRevoked
+	}
+
+	return su.sessionRepo.Touch(id, ip)
+}
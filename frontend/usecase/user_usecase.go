@@ -4,26 +4,58 @@ import (
 	"fmt"
 	"github.com/cooljar/go-postgres-fiber/domain"
 	"github.com/cooljar/go-postgres-fiber/utils"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 )
 
 type userUsecase struct {
-	userRepo       domain.UserRepository
-	contextTimeout time.Duration
+	userRepo         domain.UserRepository
+	roleRepo         domain.RoleRepository
+	loginAttemptRepo domain.LoginAttemptRepository
+	sessionRepo      domain.SessionRepository
+	invitationRepo   domain.InvitationRepository
+	passwordHasher   domain.PasswordHasher
+	passwordPolicy   domain.PasswordPolicy
+	emailScreener    domain.EmailScreener
+	usernamePolicy   domain.UsernamePolicy
+	contextTimeout   time.Duration
+
+	dummyHash     string
+	dummyHashOnce sync.Once
 }
 
 // NewUserUsecase will create new an userUsecase object representation of domain.UserUsecase interface
-func NewUserUsecase(u domain.UserRepository, timeout time.Duration) domain.UserUseCase {
+func NewUserUsecase(u domain.UserRepository, r domain.RoleRepository, la domain.LoginAttemptRepository, s domain.SessionRepository, i domain.InvitationRepository, ph domain.PasswordHasher, pp domain.PasswordPolicy, es domain.EmailScreener, up domain.UsernamePolicy, timeout time.Duration) domain.UserUseCase {
 	return &userUsecase{
-		userRepo:       u,
-		contextTimeout: timeout,
+		userRepo:         u,
+		roleRepo:         r,
+		loginAttemptRepo: la,
+		sessionRepo:      s,
+		invitationRepo:   i,
+		passwordHasher:   ph,
+		passwordPolicy:   pp,
+		emailScreener:    es,
+		usernamePolicy:   up,
+		contextTimeout:   timeout,
 	}
 }
 
 func (uu *userUsecase) RequestSecret(email string) (secret string, err error) {
+	// secret code hanya untuk pendaftaran terbuka, pendaftaran dengan undangan memakai token undangan
+	if registrationMode() != domain.ConstRegistrationOpen {
+		return secret, domain.ErrRegistrationClosed
+	}
+
+	err = uu.emailScreener.Screen(email)
+	if err != nil {
+		return
+	}
+
 	secret, err = uu.userRepo.RequestSecret(email)
 	if err != nil {
 		return
@@ -52,26 +84,139 @@ func (uu *userUsecase) RequestSecret(email string) (secret string, err error) {
 	return
 }
 
-func (uu *userUsecase) Signup(sf *domain.SignupForm) (err error) {
+func (uu *userUsecase) Signup(sf *domain.SignupForm, ac domain.AuditContext) (err error) {
 	sf.Email = strings.ToLower(sf.Email)
 	sf.Username = strings.ToLower(sf.Username)
 
-	err = uu.userRepo.Signup(sf)
+	_, err = uu.signupInvitation(sf)
+	if err != nil {
+		return
+	}
+
+	err = uu.usernamePolicy.Check(sf.Username)
+	if err != nil {
+		return
+	}
 
+	err = uu.emailScreener.Screen(sf.Email)
+	if err != nil {
+		return
+	}
+
+	err = uu.passwordPolicy.Check(sf.Password, sf.Username, sf.Email, sf.FullName)
+	if err != nil {
+		return
+	}
+
+	passwordHash, err := uu.passwordHasher.Hash(sf.Password)
+	if err != nil {
+		return
+	}
+
+	err = uu.userRepo.Signup(sf, passwordHash, ac)
+	if err != nil {
+		return
+	}
+
+	if isBootstrapAdmin(sf.Email) {
+		_, err = uu.roleRepo.GrantByEmail(sf.Email, domain.ConstRoleAdmin)
+	}
+
+	return
+}
+
+// signupInvitation returns the invitation the signup form is accepting, the registration mode decides whether one is required.
+func (uu *userUsecase) signupInvitation(sf *domain.SignupForm) (invitation domain.Invitation, err error) {
+	mode := registrationMode()
+	if mode == domain.ConstRegistrationClosed {
+		return invitation, domain.ErrRegistrationClosed
+	}
+	if sf.InviteToken == "" {
+		if mode == domain.ConstRegistrationInviteOnly {
+			err = domain.ErrInvitationRequired
+		}
+		return
+	}
+
+	invitation, err = uu.invitationRepo.GetByTokenHash(hashInvitationToken(sf.InviteToken))
+	if _, ok := err.(domain.NotFoundError); ok {
+		return invitation, domain.ErrInvitationInvalid
+	}
+	if err != nil {
+		return
+	}
+	if !invitation.IsUsable(time.Now().Unix()) || !strings.EqualFold(invitation.Email, sf.Email) {
+		return invitation, domain.ErrInvitationInvalid
+	}
+
+	sf.InvitationID = invitation.ID
 	return
 }
 
 func (uu *userUsecase) Login(lf *domain.LoginForm) (u domain.User, err error) {
+	lf.Email = strings.ToLower(lf.Email)
+	attempt := &domain.LoginAttempt{Email: lf.Email, IP: lf.IP}
+
+	err = uu.checkLoginThrottle(lf)
+	if err != nil {
+		attempt.Reason = domain.ConstLoginAttemptThrottled
+		uu.recordLoginAttempt(attempt)
+		return
+	}
+
 	u, err = uu.userRepo.Login(lf)
+	if _, ok := err.(domain.NotFoundError); ok {
+		// tetap lakukan perbandingan hash agar waktu respon sama dengan email yang terdaftar
+		uu.passwordHasher.Verify(lf.Password, uu.dummyPasswordHash())
+		attempt.Reason = domain.ConstLoginAttemptInvalidCredentials
+		uu.recordLoginAttempt(attempt)
+		err = domain.ErrInvalidCredentials
+		return
+	}
 	if err != nil {
 		return
 	}
+	attempt.UserID = &u.ID
 
-	if validatePassword(lf.Password, u.PasswordHash) == false {
-		err = domain.DataValidationError{Field: "password", Message: "invalid password"}
+	// akun terkunci ditolak sebelum password diperiksa, agar respon tidak membocorkan password yang benar
+	if int64(u.LockedUntil) > time.Now().Unix() {
+		uu.passwordHasher.Verify(lf.Password, uu.dummyPasswordHash())
+		attempt.Reason = domain.ConstLoginAttemptLocked
+		uu.recordLoginAttempt(attempt)
+		err = tooManyLoginAttempts(int64(u.LockedUntil) - time.Now().Unix())
+		return
+	}
+
+	if uu.passwordHasher.Verify(lf.Password, u.PasswordHash) == false {
+		attempt.Reason = domain.ConstLoginAttemptInvalidCredentials
+		uu.recordLoginAttempt(attempt)
+		uu.lockAfterTooManyFailures(u, lf.IP)
+		err = domain.ErrInvalidCredentials
+		return
+	}
+
+	switch u.Status {
+	case domain.ConstUserStatusActive:
+	case domain.ConstUserStatusDeleted:
+		attempt.Reason = domain.ConstLoginAttemptDeleted
+		uu.recordLoginAttempt(attempt)
+		err = domain.ErrUserDeleted
+		return
+	default:
+		attempt.Reason = domain.ConstLoginAttemptInactive
+		uu.recordLoginAttempt(attempt)
+		err = domain.ErrUserInactive
 		return
 	}
 
+	attempt.Success = true
+	attempt.Reason = domain.ConstLoginAttemptSuccess
+	uu.recordLoginAttempt(attempt)
+
+	uu.rehashPassword(u, lf.Password)
+
+	u.Roles, err = uu.roleRepo.GetUserRoles(u.ID)
+
 	return
 }
 
@@ -79,7 +224,345 @@ func (uu *userUsecase) Profile() error {
 	return nil
 }
 
-func validatePassword(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
+func (uu *userUsecase) GetByID(id uuid.UUID) (u domain.User, err error) {
+	u, err = uu.userRepo.GetByID(id)
+	if err != nil {
+		return
+	}
+
+	u.Roles, err = uu.roleRepo.GetUserRoles(id)
+
+	return
+}
+
+func (uu *userUsecase) UpdateProfile(id uuid.UUID, pf *domain.UpdateProfileForm, ac domain.AuditContext) (u domain.User, err error) {
+	pf.Username = strings.ToLower(pf.Username)
+
+	u, err = uu.userRepo.GetByID(id)
+	if err != nil {
+		return
+	}
+
+	// username lama yang sudah tidak sesuai aturan tetap boleh dipakai
+	if pf.Username != u.Username {
+		err = uu.usernamePolicy.Check(pf.Username)
+		if err != nil {
+			return
+		}
+	}
+
+	err = uu.userRepo.UpdateProfile(id, pf, ac)
+	if err != nil {
+		return
+	}
+
+	return uu.GetByID(id)
+}
+
+func (uu *userUsecase) ChangePassword(id, sessionID uuid.UUID, cf *domain.ChangePasswordForm, ac domain.AuditContext) (err error) {
+	u, err := uu.userRepo.GetByID(id)
+	if err != nil {
+		return
+	}
+
+	if uu.passwordHasher.Verify(cf.CurrentPassword, u.PasswordHash) == false {
+		err = domain.DataValidationError{Field: "current_password", Message: "invalid password"}
+		return
+	}
+
+	err = uu.passwordPolicy.Check(cf.Password, u.Username, u.Email, u.FullName)
+	if err != nil {
+		return
+	}
+
+	passwordHash, err := uu.passwordHasher.Hash(cf.Password)
+	if err != nil {
+		return
+	}
+
+	err = uu.userRepo.UpdatePassword(id, passwordHash, &ac)
+	if err != nil {
+		return
+	}
+
+	// sesi lain mungkin milik orang yang mengetahui password lama
+	return uu.sessionRepo.RevokeAllExcept(id, sessionID)
+}
+
+func (uu *userUsecase) RequestEmailChange(id uuid.UUID, ef *domain.ChangeEmailForm) (err error) {
+	ef.Email = strings.ToLower(ef.Email)
+
+	u, err := uu.userRepo.GetByID(id)
+	if err != nil {
+		return
+	}
+	if u.Email == ef.Email {
+		err = domain.DataValidationError{Field: "email", Message: "new email address is the same as the current one"}
+		return
+	}
+
+	secret, err := uu.userRepo.RequestEmailChange(id, ef.Email)
+	if err != nil {
+		return
+	}
+
+	sendMail(
+		"Confirm Your New Email Address At - Cooljar Apps",
+		ef.Email,
+		path.Join("assets", "html", "email-change.html"),
+		map[string]interface{}{
+			"email":  ef.Email,
+			"secret": secret,
+		},
+	)
+
+	return
+}
+
+func (uu *userUsecase) ConfirmEmailChange(id uuid.UUID, cf *domain.ConfirmEmailChangeForm, ac domain.AuditContext) (u domain.User, err error) {
+	oldEmail, newEmail, err := uu.userRepo.ConfirmEmailChange(id, cf.SecretCode, ac)
+	if err != nil {
+		return
+	}
+
+	// beritahu alamat email lama bahwa email akun telah diganti
+	sendMail(
+		"Your Email Address Has Been Changed At - Cooljar Apps",
+		oldEmail,
+		path.Join("assets", "html", "email-change-notification.html"),
+		map[string]interface{}{
+			"email":     oldEmail,
+			"new_email": newEmail,
+		},
+	)
+
+	return uu.GetByID(id)
+}
+
+func (uu *userUsecase) DeleteAccount(id uuid.UUID, df *domain.DeleteAccountForm, ac domain.AuditContext) (err error) {
+	u, err := uu.userRepo.GetByID(id)
+	if err != nil {
+		return
+	}
+
+	if u.Status == domain.ConstUserStatusDeleted {
+		return domain.ErrUserDeleted
+	}
+
+	if uu.passwordHasher.Verify(df.Password, u.PasswordHash) == false {
+		err = domain.DataValidationError{Field: "password", Message: "invalid password"}
+		return
+	}
+
+	err = uu.userRepo.MarkDeleted(id, userDeletedIdentityPolicy() == domain.ConstUserIdentityReleaseImmediate, ac)
+	if err != nil {
+		return
+	}
+
+	// logout dari semua perangkat
+	return uu.sessionRepo.RevokeAll(id)
+}
+
+// AnonymizeDeletedUsers scrubs personal fields of accounts deleted longer than the grace period ago.
+func (uu *userUsecase) AnonymizeDeletedUsers() (count int64, err error) {
+	gracePeriod := time.Duration(utils.GetEnvInt("USER_DELETION_GRACE_PERIOD_HOURS", 720)) * time.Hour
+	deletedBefore := time.Now().Add(-gracePeriod).Unix()
+
+	return uu.userRepo.AnonymizeDeleted(deletedBefore, userDeletedIdentityPolicy() != domain.ConstUserIdentityReleaseNever)
+}
+
+func (uu *userUsecase) SetStatus(id uuid.UUID, status int, ac domain.AuditContext) (err error) {
+	rowsAffected, err := uu.userRepo.UpdateStatus(id, status, ac)
+	if err != nil {
+		return
+	}
+	if rowsAffected < 1 {
+		return domain.NotFoundError{Code: fiber.StatusNotFound, Message: "user not found"}
+	}
+
+	if status != domain.ConstUserStatusActive {
+		err = uu.sessionRepo.RevokeAll(id)
+	}
+
+	return
+}
+
+// UsernameAvailable checks the username against the policy and the existing accounts, a rejection is
+// reported in the Reason of the result rather than as an error.
+func (uu *userUsecase) UsernameAvailable(username string) (a domain.UsernameAvailability, err error) {
+	a.Username = strings.ToLower(username)
+
+	err = uu.usernamePolicy.Check(a.Username)
+	if err == nil {
+		err = uu.userRepo.CheckUsernameAvailable(a.Username)
+	}
+	if ve, ok := err.(domain.DataValidationError); ok {
+		a.Reason = ve.Message
+		return a, nil
+	}
+	if err != nil {
+		return
+	}
+
+	a.Available = true
+	return
+}
+
+// BackfillUsernameSkeletons fills the username skeleton of accounts created before it existed,
+// returning the number of accounts updated.
+func (uu *userUsecase) BackfillUsernameSkeletons() (count int64, err error) {
+	return uu.userRepo.BackfillUsernameSkeletons()
+}
+
+// checkLoginThrottle rejects the attempt while the IP or the email is under a progressive delay or lockout.
+// It doesn't need the account to exist, so unknown emails are throttled the same way.
+func (uu *userUsecase) checkLoginThrottle(lf *domain.LoginForm) (err error) {
+	now := time.Now().Unix()
+	window := int64(utils.GetEnvInt("LOGIN_FAILURE_WINDOW_MINUTES", 15) * 60)
+	windowStart := now - window
+
+	ipFailures, ipFirstAt, err := uu.loginAttemptRepo.CountFailuresByIP(lf.IP, windowStart)
+	if err != nil {
+		return
+	}
+	if ipFailures >= utils.GetEnvInt("LOGIN_MAX_FAILURES_PER_IP", 50) {
+		// IP boleh mencoba lagi setelah kegagalan tertua keluar dari jendela waktu
+		return tooManyLoginAttempts(ipFirstAt + window - now)
+	}
+
+	failures, lastAt, err := uu.loginAttemptRepo.CountFailuresByEmail(lf.Email, windowStart)
+	if err != nil {
+		return
+	}
+
+	wait := loginDelay(failures)
+	if failures >= loginLockoutThreshold() {
+		wait = int64(utils.GetEnvInt("LOGIN_LOCKOUT_MINUTES", 30) * 60)
+	}
+	if elapsed := now - lastAt; elapsed < wait {
+		return tooManyLoginAttempts(wait - elapsed)
+	}
+
+	return
+}
+
+// lockAfterTooManyFailures locks the account once the failure threshold is reached, its owner is notified only by the
+// request that actually locked it.
+func (uu *userUsecase) lockAfterTooManyFailures(u domain.User, ip string) {
+	windowStart := time.Now().Unix() - int64(utils.GetEnvInt("LOGIN_FAILURE_WINDOW_MINUTES", 15)*60)
+
+	failures, _, err := uu.loginAttemptRepo.CountFailuresByEmail(u.Email, windowStart)
+	if err != nil || failures < loginLockoutThreshold() {
+		return
+	}
+
+	lockedUntil := time.Now().Add(time.Duration(utils.GetEnvInt("LOGIN_LOCKOUT_MINUTES", 30)) * time.Minute)
+	locked, err := uu.userRepo.Lock(u.ID, lockedUntil.Unix())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if !locked {
+		return
+	}
+
+	sendMail(
+		"Your Account Has Been Temporarily Locked At - Cooljar Apps",
+		u.Email,
+		path.Join("assets", "html", "account-locked.html"),
+		map[string]interface{}{
+			"email":    u.Email,
+			"ip":       ip,
+			"failures": failures,
+			"until":    lockedUntil.UTC().Format(time.RFC1123),
+		},
+	)
+}
+
+func (uu *userUsecase) recordLoginAttempt(a *domain.LoginAttempt) {
+	if err := uu.loginAttemptRepo.Record(a); err != nil {
+		fmt.Println(err)
+	}
+}
+
+// loginDelay returns the seconds to wait after the given number of consecutive failures,
+// doubling from the 3rd failure up to one minute.
+func loginDelay(failures int) int64 {
+	if failures < 3 {
+		return 0
+	}
+	if failures > 9 {
+		return 60
+	}
+
+	delay := int64(1) << uint(failures-3)
+	if delay > 60 {
+		delay = 60
+	}
+
+	return delay
+}
+
+func loginLockoutThreshold() int {
+	return utils.GetEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10)
+}
+
+func tooManyLoginAttempts(retryAfter int64) domain.TooManyRequestsError {
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	return domain.TooManyRequestsError{Message: "too many failed login attempts, try again later", RetryAfter: int(retryAfter)}
+}
+
+// dummyPasswordHash returns a hash of a random password, compared against when the email is unknown.
+func (uu *userUsecase) dummyPasswordHash() string {
+	uu.dummyHashOnce.Do(func() {
+		password, _ := utils.GenerateRandomString(32)
+		uu.dummyHash, _ = uu.passwordHasher.Hash(password)
+	})
+
+	return uu.dummyHash
+}
+
+// rehashPassword upgrades the stored hash after a successful login, failures are only logged.
+func (uu *userUsecase) rehashPassword(u domain.User, password string) {
+	if !uu.passwordHasher.NeedsRehash(u.PasswordHash) {
+		return
+	}
+
+	passwordHash, err := uu.passwordHasher.Hash(password)
+	if err == nil {
+		err = uu.userRepo.UpdatePassword(u.ID, passwordHash, nil)
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// isBootstrapAdmin reports whether the email is listed in ADMIN_EMAILS env.
+func isBootstrapAdmin(email string) bool {
+	for _, adminEmail := range strings.Split(os.Getenv("ADMIN_EMAILS"), ",") {
+		if strings.EqualFold(strings.TrimSpace(adminEmail), email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// userDeletedIdentityPolicy returns when username and email of deleted accounts become reusable.
+func userDeletedIdentityPolicy() string {
+	return utils.GetEnv("USER_DELETED_IDENTITY_POLICY", domain.ConstUserIdentityReleaseAfterGrace)
+}
+
+// sendMail sends an email in background, failures are only logged.
+func sendMail(subject, destination, viewPath string, data map[string]interface{}) {
+	mailer := utils.NewMailer(subject, destination, viewPath, data)
+
+	go func(mailer *utils.Mailer) {
+		if err := mailer.SendMail(); err != nil {
+			fmt.Println(err)
+		}
+	}(mailer)
 }
@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/cooljar/go-postgres-fiber/utils"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserUsecase_UpdateProfile(t *testing.T) {
+	// "ab" tidak lagi sesuai aturan, tetapi dibuat sebelum aturan berlaku
+	jane := domain.User{ID: uuid.New(), Username: "ab", Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, testUsernamePolicy(), time.Second)
+
+	u, err := uu.UpdateProfile(jane.ID, &domain.UpdateProfileForm{Username: "AB", FullName: "Jane Doe"}, domain.AuditContext{})
+	require.NoError(t, err, "the current username is kept even when the policy changed")
+	assert.Equal(t, "Jane Doe", u.FullName)
+
+	_, err = uu.UpdateProfile(jane.ID, &domain.UpdateProfileForm{Username: "admin"}, domain.AuditContext{})
+	assert.IsType(t, domain.DataValidationError{}, err)
+	assert.Equal(t, "ab", users.users[jane.ID].Username)
+
+	u, err = uu.UpdateProfile(jane.ID, &domain.UpdateProfileForm{Username: "JaneDoe", FullName: "Jane Doe"}, domain.AuditContext{})
+	require.NoError(t, err)
+	assert.Equal(t, "janedoe", u.Username)
+}
+
+func TestUserUsecase_UsernameAvailable(t *testing.T) {
+	jane := domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	uu := NewUserUsecase(&fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, testUsernamePolicy(), time.Second)
+
+	for username, available := range map[string]bool{"Bob": true, "JANE": false, "admin": false, "ab": false} {
+		a, err := uu.UsernameAvailable(username)
+		require.NoError(t, err, username)
+		assert.Equal(t, available, a.Available, username)
+		assert.Equal(t, available, a.Reason == "", username)
+	}
+}
+
+func TestUserUsecase_ChangePassword(t *testing.T) {
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "hash:old"}
+	bob := domain.User{ID: uuid.New(), Email: "bob@example.com", PasswordHash: "hash:bob"}
+	current, other, bobs := uuid.New(), uuid.New(), uuid.New()
+
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane, bob.ID: bob}}
+	sessions := &fakeSessionRepo{revoked: map[uuid.UUID]bool{}, sessions: map[uuid.UUID]domain.Session{
+		current: {ID: current, UserID: jane.ID},
+		other:   {ID: other, UserID: jane.ID},
+		bobs:    {ID: bobs, UserID: bob.ID},
+	}}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, sessions, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	err := uu.ChangePassword(jane.ID, current, &domain.ChangePasswordForm{CurrentPassword: "wrong", Password: "new"}, domain.AuditContext{})
+	assert.IsType(t, domain.DataValidationError{}, err)
+	assert.Equal(t, "hash:old", users.users[jane.ID].PasswordHash)
+
+	err = uu.ChangePassword(jane.ID, current, &domain.ChangePasswordForm{CurrentPassword: "old", Password: "jane@example.com1"}, domain.AuditContext{})
+	assert.IsType(t, domain.PasswordPolicyError{}, err, "the new password is checked against the policy")
+	assert.Equal(t, "hash:old", users.users[jane.ID].PasswordHash)
+	assert.Empty(t, sessions.revoked)
+
+	require.NoError(t, uu.ChangePassword(jane.ID, current, &domain.ChangePasswordForm{CurrentPassword: "old", Password: "new"}, domain.AuditContext{}))
+	assert.Equal(t, "hash:new", users.users[jane.ID].PasswordHash)
+	assert.Equal(t, map[uuid.UUID]bool{other: true}, sessions.revoked, "the other sessions are signed out")
+}
+
+func TestUserUsecase_RequestEmailChangeToSameAddress(t *testing.T) {
+	jane := domain.User{ID: uuid.New(), Username: "jane", Email: "jane@example.com"}
+	uu := NewUserUsecase(&fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	err := uu.RequestEmailChange(jane.ID, &domain.ChangeEmailForm{Email: "Jane@Example.com"})
+	assert.IsType(t, domain.DataValidationError{}, err)
+}
+
+func TestUserUsecase_DeleteAccount(t *testing.T) {
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "hash:secret", Status: domain.ConstUserStatusActive}
+	session := uuid.New()
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}
+	sessions := &fakeSessionRepo{revoked: map[uuid.UUID]bool{}, sessions: map[uuid.UUID]domain.Session{session: {ID: session, UserID: jane.ID}}}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, sessions, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	err := uu.DeleteAccount(jane.ID, &domain.DeleteAccountForm{Password: "wrong"}, domain.AuditContext{})
+	assert.IsType(t, domain.DataValidationError{}, err)
+	assert.Equal(t, domain.ConstUserStatusActive, users.users[jane.ID].Status)
+	assert.Empty(t, sessions.revoked)
+
+	require.NoError(t, uu.DeleteAccount(jane.ID, &domain.DeleteAccountForm{Password: "secret"}, domain.AuditContext{}))
+	assert.Equal(t, domain.ConstUserStatusDeleted, users.users[jane.ID].Status)
+	assert.True(t, sessions.revoked[session], "deleting the account signs out everywhere")
+
+	assert.Equal(t, domain.ErrUserDeleted, uu.DeleteAccount(jane.ID, &domain.DeleteAccountForm{Password: "secret"}, domain.AuditContext{}))
+	_, err = uu.Login(&domain.LoginForm{Email: jane.Email, Password: "secret"})
+	assert.Equal(t, domain.ErrUserDeleted, err)
+}
+
+func TestUserUsecase_SetStatus(t *testing.T) {
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", Status: domain.ConstUserStatusActive}
+	session := uuid.New()
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}
+	sessions := &fakeSessionRepo{revoked: map[uuid.UUID]bool{}, sessions: map[uuid.UUID]domain.Session{session: {ID: session, UserID: jane.ID}}}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, sessions, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	require.NoError(t, uu.SetStatus(jane.ID, domain.ConstUserStatusActive, domain.AuditContext{}))
+	assert.Empty(t, sessions.revoked)
+
+	require.NoError(t, uu.SetStatus(jane.ID, domain.ConstUserStatusInnactive, domain.AuditContext{}))
+	assert.Equal(t, domain.ConstUserStatusInnactive, users.users[jane.ID].Status)
+	assert.True(t, sessions.revoked[session], "deactivated accounts are signed out")
+
+	assert.IsType(t, domain.NotFoundError{}, uu.SetStatus(uuid.New(), domain.ConstUserStatusActive, domain.AuditContext{}))
+}
+
+func TestUserUsecase_LoginLockout(t *testing.T) {
+	os.Setenv("LOGIN_LOCKOUT_THRESHOLD", "3")
+	defer os.Unsetenv("LOGIN_LOCKOUT_THRESHOLD")
+
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "hash:secret", Status: domain.ConstUserStatusActive}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}
+	attempts := &fakeLoginAttemptRepo{}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, attempts, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	for i := 0; i < 3; i++ {
+		_, err := uu.Login(&domain.LoginForm{Email: "Jane@Example.com", Password: "wrong", IP: "192.0.2.1"})
+		assert.Equal(t, domain.ErrInvalidCredentials, err)
+	}
+	assert.InDelta(t, time.Now().Add(30*time.Minute).Unix(), users.users[jane.ID].LockedUntil, 2)
+
+	_, err := uu.Login(&domain.LoginForm{Email: jane.Email, Password: "secret", IP: "192.0.2.1"})
+	require.IsType(t, domain.TooManyRequestsError{}, err)
+	assert.InDelta(t, 1800, err.(domain.TooManyRequestsError).RetryAfter, 2)
+	assert.Equal(t, domain.ConstLoginAttemptThrottled, attempts.attempts[len(attempts.attempts)-1].Reason)
+}
+
+func TestUserUsecase_LockOnlyOnce(t *testing.T) {
+	os.Setenv("LOGIN_LOCKOUT_THRESHOLD", "3")
+	defer os.Unsetenv("LOGIN_LOCKOUT_THRESHOLD")
+
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "hash:secret", Status: domain.ConstUserStatusActive}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}
+	attempts := &fakeLoginAttemptRepo{}
+	for i := 0; i < 4; i++ {
+		attempts.attempts = append(attempts.attempts, domain.LoginAttempt{
+			Email: jane.Email, IP: "192.0.2.1", Reason: domain.ConstLoginAttemptInvalidCredentials, CreatedAt: int(time.Now().Unix()),
+		})
+	}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, attempts, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second).(*userUsecase)
+
+	// kegagalan di atas ambang (login bersamaan) tetap mengunci, tapi hanya sekali
+	uu.lockAfterTooManyFailures(jane, "192.0.2.1")
+	lockedUntil := users.users[jane.ID].LockedUntil
+	assert.InDelta(t, time.Now().Add(30*time.Minute).Unix(), lockedUntil, 2)
+
+	uu.lockAfterTooManyFailures(jane, "192.0.2.1")
+	assert.Equal(t, 1, users.locks, "the second request neither locks again nor notifies the owner")
+	assert.Equal(t, lockedUntil, users.users[jane.ID].LockedUntil)
+}
+
+func TestUserUsecase_LoginLockedAccount(t *testing.T) {
+	locked := domain.User{
+		ID:           uuid.New(),
+		Email:        "jane@example.com",
+		PasswordHash: "hash:secret",
+		Status:       domain.ConstUserStatusActive,
+		LockedUntil:  int(time.Now().Add(10 * time.Minute).Unix()),
+	}
+	attempts := &fakeLoginAttemptRepo{}
+	hasher := &fakePasswordHasher{}
+	uu := NewUserUsecase(&fakeUserRepo{users: map[uuid.UUID]domain.User{locked.ID: locked}}, &fakeRoleRepo{}, attempts, nil, nil, hasher, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	_, errRight := uu.Login(&domain.LoginForm{Email: locked.Email, Password: "secret", IP: "192.0.2.1"})
+	_, errWrong := uu.Login(&domain.LoginForm{Email: locked.Email, Password: "wrong", IP: "192.0.2.1"})
+
+	// respon sama apa pun passwordnya, dan kegagalan tidak bertambah selama terkunci
+	require.IsType(t, domain.TooManyRequestsError{}, errRight)
+	assert.Equal(t, errRight.(domain.TooManyRequestsError).Message, errWrong.(domain.TooManyRequestsError).Message)
+	assert.InDelta(t, 600, errRight.(domain.TooManyRequestsError).RetryAfter, 2)
+	for _, a := range attempts.attempts {
+		assert.Equal(t, domain.ConstLoginAttemptLocked, a.Reason)
+	}
+}
+
+func TestUserUsecase_LoginUnknownEmail(t *testing.T) {
+	hasher := &fakePasswordHasher{}
+	uu := NewUserUsecase(&fakeUserRepo{users: map[uuid.UUID]domain.User{}}, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, nil, nil, hasher, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	_, err := uu.Login(&domain.LoginForm{Email: "nobody@example.com", Password: "secret", IP: "192.0.2.1"})
+	assert.Equal(t, domain.ErrInvalidCredentials, err)
+	assert.Equal(t, 1, hasher.verified, "the password is still compared against a dummy hash")
+}
+
+func TestUserUsecase_LoginIPThrottle(t *testing.T) {
+	os.Setenv("LOGIN_MAX_FAILURES_PER_IP", "2")
+	defer os.Unsetenv("LOGIN_MAX_FAILURES_PER_IP")
+
+	now := time.Now().Unix()
+	attempts := &fakeLoginAttemptRepo{attempts: []domain.LoginAttempt{
+		{Email: "a@example.com", IP: "192.0.2.1", Reason: domain.ConstLoginAttemptInvalidCredentials, CreatedAt: int(now - 600)},
+		{Email: "b@example.com", IP: "192.0.2.1", Reason: domain.ConstLoginAttemptInvalidCredentials, CreatedAt: int(now - 60)},
+	}}
+	uu := NewUserUsecase(&fakeUserRepo{users: map[uuid.UUID]domain.User{}}, &fakeRoleRepo{}, attempts, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	_, err := uu.Login(&domain.LoginForm{Email: "c@example.com", Password: "secret", IP: "192.0.2.1"})
+	require.IsType(t, domain.TooManyRequestsError{}, err)
+	// kegagalan tertua keluar dari jendela 15 menit setelah 5 menit
+	assert.InDelta(t, 300, err.(domain.TooManyRequestsError).RetryAfter, 2)
+}
+
+func TestUserUsecase_LoginRehashesPassword(t *testing.T) {
+	jane := domain.User{ID: uuid.New(), Email: "jane@example.com", PasswordHash: "old:secret", Status: domain.ConstUserStatusActive}
+	users := &fakeUserRepo{users: map[uuid.UUID]domain.User{jane.ID: jane}}
+	uu := NewUserUsecase(users, &fakeRoleRepo{}, &fakeLoginAttemptRepo{}, nil, nil, &fakePasswordHasher{}, fakePasswordPolicy{}, nil, nil, time.Second)
+
+	_, err := uu.Login(&domain.LoginForm{Email: jane.Email, Password: "wrong", IP: "192.0.2.1"})
+	assert.Equal(t, domain.ErrInvalidCredentials, err)
+	assert.Equal(t, "old:secret", users.users[jane.ID].PasswordHash)
+
+	_, err = uu.Login(&domain.LoginForm{Email: jane.Email, Password: "secret", IP: "192.0.2.1"})
+	require.NoError(t, err)
+	assert.Equal(t, "hash:secret", users.users[jane.ID].PasswordHash, "hashes of an older algorithm are upgraded")
+}
+
+func testUsernamePolicy() domain.UsernamePolicy {
+	return utils.NewUsernamePolicy(utils.UsernamePolicyConfig{MinLength: 3, MaxLength: 8, Reserved: []string{"admin"}})
+}
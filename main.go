@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
 	_frontendHttpDelivery "github.com/cooljar/go-postgres-fiber/frontend/delivery/http"
 	"github.com/cooljar/go-postgres-fiber/frontend/delivery/http/configs"
 	_frontendDeliveryMiddleware "github.com/cooljar/go-postgres-fiber/frontend/delivery/http/middleware"
@@ -14,6 +15,7 @@ import (
 	"github.com/jackc/pgx/v4/pgxpool"
 	"io"
 	"os"
+	"strings"
 	"time"
 
 	// docs are generated by Swag CLI, you have to import them.
@@ -57,6 +59,18 @@ func init() {
 		exitf("SMTP_AUTH_PASSWORD env is required")
 	}
 
+	switch os.Getenv("USER_DELETED_IDENTITY_POLICY") {
+	case "", domain.ConstUserIdentityReleaseImmediate, domain.ConstUserIdentityReleaseAfterGrace, domain.ConstUserIdentityReleaseNever:
+	default:
+		exitf("USER_DELETED_IDENTITY_POLICY env must be one of: immediate, after_grace, never")
+	}
+
+	for _, provider := range utils.OidcProvidersFromEnv() {
+		if provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			exitf("OIDC provider %s requires ISSUER, CLIENT_ID and REDIRECT_URL envs", provider.Name)
+		}
+	}
+
 	// Adapted from https://elithrar.github.io/article/generating-secure-random-numbers-crypto-rand/
 	assertAvailablePRNG()
 }
@@ -101,8 +115,29 @@ func main() {
 	// Swagger handler
 	_frontendHttpDelivery.NewSwaggerHandler(app)
 
-	middL := _frontendDeliveryMiddleware.InitMiddleware(app)
+	timeoutContext := time.Duration(2) * time.Second
+
+	roleRepo := _frontendRepo.NewPgsqlRoleRepository(dbConn)
+	roleUsecase := _frontendUcase.NewRoleUsecase(roleRepo, timeoutContext)
+
+	// Grant admin role to accounts listed in ADMIN_EMAILS env
+	err = roleUsecase.Bootstrap(strings.Split(os.Getenv("ADMIN_EMAILS"), ","))
+	if err != nil {
+		exitf("Unable to bootstrap admin accounts: %v\n", err)
+	}
+
+	userRepo := _frontendRepo.NewPgsqlUserRepository(dbConn)
+	sessionRepo := _frontendRepo.NewPgsqlSessionRepository(dbConn)
+	organizationRepo := _frontendRepo.NewPgsqlOrganizationRepository(dbConn)
+	organizationUsecase := _frontendUcase.NewOrganizationUsecase(organizationRepo, userRepo, roleRepo, sessionRepo, timeoutContext)
+	apiKeyRepo := _frontendRepo.NewPgsqlAPIKeyRepository(dbConn)
+	apiKeyUsecase := _frontendUcase.NewAPIKeyUsecase(apiKeyRepo, userRepo, roleRepo, organizationRepo, timeoutContext)
+
+	sessionUsecase := _frontendUcase.NewSessionUsecase(sessionRepo, timeoutContext)
+
+	middL := _frontendDeliveryMiddleware.InitMiddleware(app, roleUsecase, apiKeyUsecase, sessionUsecase)
 	app.Use(middL.CORS())
+	app.Use(middL.RequestID())
 	app.Use(middL.LOGGER())
 
 	// router for public access
@@ -111,15 +146,103 @@ func main() {
 	// router for private access
 	rPrivate := app.Group("/api/v1/auth", middL.JWT())
 
-	timeoutContext := time.Duration(2) * time.Second
+	blobConfig := utils.BlobStoreConfigFromEnv()
+	blobStore, err := utils.NewBlobStore(blobConfig)
+	if err != nil {
+		exitf("Unable to open blob store: %v\n", err)
+	}
+	if blobConfig.Driver == utils.BlobDriverLocal {
+		app.Static(utils.LocalBlobRoute, blobConfig.LocalDir)
+	}
+
+	currencyRates, err := utils.CurrencyRatesFromEnv()
+	if err != nil {
+		exitf("Unable to read currency rates: %v\n", err)
+	}
+	currencyConverter, err := utils.NewCurrencyConverter(currencyRates)
+	if err != nil {
+		exitf("Unable to read currency rates: %v\n", err)
+	}
 
 	bookRepo := _frontendRepo.NewPgsqlBookRepository(dbConn)
-	bookUsecae := _frontendUcase.NewBookUsecase(bookRepo, timeoutContext)
-	_frontendHttpDelivery.NewBookHandler(app, bookUsecae, rPublic, rPrivate)
+	bookUsecae := _frontendUcase.NewBookUsecase(bookRepo, blobStore, currencyConverter, timeoutContext)
+	_frontendHttpDelivery.NewBookHandler(app, bookUsecae, organizationUsecase, rPublic, rPrivate, middL)
 
-	userRepo := _frontendRepo.NewPgsqlUserRepository(dbConn)
-	userUsecase := _frontendUcase.NewUserUsecase(userRepo, timeoutContext)
-	_frontendHttpDelivery.NewUserHandler(app, validator, userUsecase, rPublic, rPrivate)
+	bookChapterRepo := _frontendRepo.NewPgsqlBookChapterRepository(dbConn)
+	bookChapterUsecase := _frontendUcase.NewBookChapterUsecase(bookChapterRepo, bookRepo, timeoutContext)
+	_frontendHttpDelivery.NewBookChapterHandler(app, validator, bookChapterUsecase, organizationUsecase, rPublic, rPrivate, middL)
+
+	authorRepo := _frontendRepo.NewPgsqlAuthorRepository(dbConn)
+	authorUsecase := _frontendUcase.NewAuthorUsecase(authorRepo, timeoutContext)
+	_frontendHttpDelivery.NewAuthorHandler(app, validator, authorUsecase, organizationUsecase, rPublic, rPrivate, middL)
+
+	categoryRepo := _frontendRepo.NewPgsqlCategoryRepository(dbConn)
+	categoryUsecase := _frontendUcase.NewCategoryUsecase(categoryRepo, timeoutContext)
+	_frontendHttpDelivery.NewCategoryHandler(app, validator, categoryUsecase, organizationUsecase, rPublic, rPrivate, middL)
+
+	reviewRepo := _frontendRepo.NewPgsqlReviewRepository(dbConn)
+	reviewUsecase := _frontendUcase.NewReviewUsecase(reviewRepo, timeoutContext)
+	_frontendHttpDelivery.NewReviewHandler(app, validator, reviewUsecase, organizationUsecase, rPublic, rPrivate, middL)
+
+	loginAttemptRepo := _frontendRepo.NewPgsqlLoginAttemptRepository(dbConn)
+	argon2idParams, err := utils.Argon2idParamsFromEnv()
+	if err != nil {
+		exitf("Invalid password hashing parameters: %v\n", err)
+	}
+	passwordHasher, err := utils.NewPasswordHasher(argon2idParams)
+	if err != nil {
+		exitf("Invalid password hashing parameters: %v\n", err)
+	}
+	passwordPolicy, err := utils.NewPasswordPolicy(utils.PasswordPolicyConfigFromEnv())
+	if err != nil {
+		exitf("Unable to load password policy: %v\n", err)
+	}
+	emailScreener, err := utils.NewEmailScreener(utils.EmailScreenerConfigFromEnv())
+	if err != nil {
+		exitf("Unable to load email screening rules: %v\n", err)
+	}
+	usernamePolicy := utils.NewUsernamePolicy(utils.UsernamePolicyConfigFromEnv())
+	invitationRepo := _frontendRepo.NewPgsqlInvitationRepository(dbConn)
+	userUsecase := _frontendUcase.NewUserUsecase(userRepo, roleRepo, loginAttemptRepo, sessionRepo, invitationRepo, passwordHasher, passwordPolicy, emailScreener, usernamePolicy, timeoutContext)
+
+	// Accounts created before lookalike checks existed have no username skeleton yet
+	_, err = userUsecase.BackfillUsernameSkeletons()
+	if err != nil {
+		exitf("Unable to backfill username skeletons: %v\n", err)
+	}
+
+	mfaRepo := _frontendRepo.NewPgsqlMfaRepository(dbConn)
+	mfaUsecase := _frontendUcase.NewMfaUsecase(mfaRepo, userRepo, roleRepo, passwordHasher, timeoutContext)
+	oidcRepo := _frontendRepo.NewPgsqlOidcRepository(dbConn)
+	oidcUsecase := _frontendUcase.NewOidcUsecase(oidcRepo, userRepo, roleRepo, usernamePolicy, utils.OidcProvidersFromEnv(), timeoutContext)
+	_frontendHttpDelivery.NewUserHandler(app, validator, userUsecase, mfaUsecase, apiKeyUsecase, oidcUsecase, sessionUsecase, organizationUsecase, rPublic, rPrivate, middL)
+
+	invitationUsecase := _frontendUcase.NewInvitationUsecase(invitationRepo, userRepo, roleRepo, timeoutContext)
+	_frontendHttpDelivery.NewAdminHandler(app, validator, roleUsecase, userUsecase, invitationUsecase, rPrivate, middL)
+
+	auditRepo := _frontendRepo.NewPgsqlAuditRepository(dbConn)
+	auditUsecase := _frontendUcase.NewAuditUsecase(auditRepo, timeoutContext)
+	_frontendHttpDelivery.NewAuditHandler(app, auditUsecase, rPrivate, middL)
+
+	// Scrub personal fields of deleted accounts once the grace period is over
+	anonymizeInterval := time.Duration(utils.GetEnvInt("USER_ANONYMIZE_INTERVAL_MINUTES", 60)) * time.Minute
+	err = utils.RunEvery("anonymize deleted users", anonymizeInterval, func() error {
+		_, err := userUsecase.AnonymizeDeletedUsers()
+		return err
+	})
+	if err != nil {
+		exitf("Invalid USER_ANONYMIZE_INTERVAL_MINUTES: %v\n", err)
+	}
+
+	// Publish the books whose scheduled publication is due
+	publishInterval := time.Duration(utils.GetEnvInt("BOOK_PUBLISH_INTERVAL_MINUTES", 1)) * time.Minute
+	err = utils.RunEvery("publish scheduled books", publishInterval, func() error {
+		_, err := bookUsecae.PublishDue()
+		return err
+	})
+	if err != nil {
+		exitf("Invalid BOOK_PUBLISH_INTERVAL_MINUTES: %v\n", err)
+	}
 
 	utils.StartServer(app)
 }
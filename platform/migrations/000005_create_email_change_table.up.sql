@@ -0,0 +1,12 @@
+-- Create email_change table
+CREATE TABLE email_change (
+    user_id     uuid          NOT NULL constraint email_change_user_id_fkey references "user" (id) on delete cascade,
+    new_email   VARCHAR (255) NOT NULL default '',
+    secret_code CHAR (6)      NOT NULL default '',
+    created_at  INT           NOT NULL default 0,
+
+    PRIMARY KEY (user_id)
+);
+
+-- Comments
+comment on column "email_change".secret_code is '6 digits number, sent to new_email';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_user_status_deleted_at;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS deleted_at,
+    DROP COLUMN IF EXISTS anonymized_at;
@@ -0,0 +1,9 @@
+-- Add account deletion columns to user table
+ALTER TABLE "user"
+    ADD COLUMN deleted_at    integer not null default 0,
+    ADD COLUMN anonymized_at integer not null default 0;
+
+comment on column "user".deleted_at is 'Unix time when the account was deleted by its owner, 0 if not deleted';
+comment on column "user".anonymized_at is 'Unix time when personal fields of a deleted account were scrubbed, 0 if not yet';
+
+CREATE INDEX idx_user_status_deleted_at ON "user" (status, deleted_at);
@@ -0,0 +1,43 @@
+-- Create role based access control tables
+CREATE TABLE roles (
+    name        VARCHAR (64)  NOT NULL PRIMARY KEY,
+    description VARCHAR (255) NOT NULL default ''
+);
+
+CREATE TABLE permissions (
+    name        VARCHAR (64)  NOT NULL PRIMARY KEY,
+    description VARCHAR (255) NOT NULL default ''
+);
+
+CREATE TABLE role_permissions (
+    role_name       VARCHAR (64) NOT NULL references roles (name) on delete cascade on update cascade,
+    permission_name VARCHAR (64) NOT NULL references permissions (name) on delete cascade on update cascade,
+
+    PRIMARY KEY (role_name, permission_name)
+);
+
+CREATE TABLE user_roles (
+    user_id    uuid         NOT NULL references "user" (id) on delete cascade,
+    role_name  VARCHAR (64) NOT NULL references roles (name) on delete cascade on update cascade,
+    created_at INT          NOT NULL default 0,
+
+    PRIMARY KEY (user_id, role_name)
+);
+
+CREATE INDEX idx_user_roles_role_name ON user_roles (role_name);
+
+-- Default roles & permissions
+INSERT INTO roles (name, description) VALUES
+    ('admin', 'Full access, manages users and roles'),
+    ('editor', 'Manages the book catalog');
+
+INSERT INTO permissions (name, description) VALUES
+    ('book:write', 'Create, update and delete books'),
+    ('role:manage', 'Grant and revoke user roles'),
+    ('user:manage', 'Activate and deactivate user accounts');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'book:write'),
+    ('admin', 'role:manage'),
+    ('admin', 'user:manage'),
+    ('editor', 'book:write');
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_books_created_by;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS updated_by;
@@ -0,0 +1,6 @@
+-- Add ownership columns to books table
+ALTER TABLE books
+    ADD COLUMN created_by uuid NULL constraint books_created_by_fkey references "user" (id) on delete set null,
+    ADD COLUMN updated_by uuid NULL constraint books_updated_by_fkey references "user" (id) on delete set null;
+
+CREATE INDEX idx_books_created_by ON books (created_by);
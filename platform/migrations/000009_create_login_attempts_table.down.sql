@@ -0,0 +1,4 @@
+ALTER TABLE "user" DROP COLUMN IF EXISTS locked_until;
+
+-- Delete tables
+DROP TABLE IF EXISTS login_attempts;
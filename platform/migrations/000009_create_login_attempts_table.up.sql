@@ -0,0 +1,22 @@
+-- Create login_attempts table, an audit trail of every login attempt
+CREATE TABLE login_attempts (
+    id         BIGSERIAL     PRIMARY KEY,
+    user_id    uuid          NULL references "user" (id) on delete set null,
+    email      VARCHAR (255) NOT NULL default '',
+    ip         VARCHAR (45)  NOT NULL default '',
+    success    BOOLEAN       NOT NULL default false,
+    reason     VARCHAR (32)  NOT NULL default '',
+    created_at INT           NOT NULL default 0
+);
+
+-- Comments
+comment on column login_attempts.user_id is 'NULL when the email does not belong to any account';
+comment on column login_attempts.reason is 'success, invalid_credentials, throttled, locked, inactive or deleted';
+
+-- Indexes
+CREATE INDEX idx_login_attempts_email_created_at ON login_attempts (email, created_at);
+CREATE INDEX idx_login_attempts_ip_created_at ON login_attempts (ip, created_at);
+CREATE INDEX idx_login_attempts_user_id ON login_attempts (user_id);
+
+-- Temporary lockout
+ALTER TABLE "user" ADD COLUMN locked_until integer not null default 0;
@@ -0,0 +1,29 @@
+-- Create TOTP two-factor authentication tables
+CREATE TABLE user_mfa (
+    user_id           uuid         NOT NULL PRIMARY KEY references "user" (id) on delete cascade,
+    secret            VARCHAR (64) NOT NULL default '',
+    enabled           BOOLEAN      NOT NULL default false,
+    last_used_counter BIGINT       NOT NULL default 0,
+    failed_attempts   INT          NOT NULL default 0,
+    last_failed_at    INT          NOT NULL default 0,
+    created_at        INT          NOT NULL default 0,
+    confirmed_at      INT          NOT NULL default 0
+);
+
+-- Comments
+comment on column user_mfa.secret is 'base32 TOTP secret';
+comment on column user_mfa.last_used_counter is 'TOTP time step of the last accepted code, older or equal steps are rejected';
+
+CREATE TABLE user_mfa_recovery_codes (
+    id         BIGSERIAL    PRIMARY KEY,
+    user_id    uuid         NOT NULL references "user" (id) on delete cascade,
+    code_hash  CHAR (64)    NOT NULL default '',
+    used_at    INT          NOT NULL default 0,
+    created_at INT          NOT NULL default 0
+);
+
+-- Comments
+comment on column user_mfa_recovery_codes.code_hash is 'hex encoded sha256 of the recovery code';
+
+-- Indexes
+CREATE INDEX idx_user_mfa_recovery_codes_user_id ON user_mfa_recovery_codes (user_id);
@@ -0,0 +1,23 @@
+-- Create api_keys table, personal API keys of users
+CREATE TABLE api_keys (
+    id           uuid          NOT NULL default uuid_generate_v4() PRIMARY KEY,
+    user_id      uuid          NOT NULL references "user" (id) on delete cascade,
+    name         VARCHAR (100) NOT NULL default '',
+    prefix       VARCHAR (16)  NOT NULL constraint api_keys_prefix_key unique,
+    key_hash     CHAR (64)     NOT NULL default '',
+    scopes       TEXT[]        NOT NULL default '{}',
+    expires_at   INT           NOT NULL default 0,
+    last_used_at INT           NOT NULL default 0,
+    last_used_ip VARCHAR (45)  NOT NULL default '',
+    created_at   INT           NOT NULL default 0,
+    revoked_at   INT           NOT NULL default 0
+);
+
+-- Comments
+comment on column api_keys.prefix is 'public part of the key, used for lookup';
+comment on column api_keys.key_hash is 'hex encoded HMAC-SHA256 of the secret part, keyed with user.auth_key';
+comment on column api_keys.scopes is 'permissions the key is limited to';
+comment on column api_keys.expires_at is '0 means the key never expires';
+
+-- Indexes
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id);
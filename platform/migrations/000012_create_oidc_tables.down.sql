@@ -0,0 +1,3 @@
+-- Delete tables
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS oidc_login_states;
@@ -0,0 +1,30 @@
+-- Pending OpenID Connect logins, a row lives from the authorize redirect until the callback
+CREATE TABLE oidc_login_states (
+    state         VARCHAR (64)  NOT NULL PRIMARY KEY,
+    provider      VARCHAR (50)  NOT NULL default '',
+    code_verifier VARCHAR (128) NOT NULL default '',
+    nonce         VARCHAR (64)  NOT NULL default '',
+    created_at    INT           NOT NULL default 0
+);
+
+-- Comments
+comment on column oidc_login_states.code_verifier is 'PKCE code verifier sent with the token request';
+
+-- Indexes
+CREATE INDEX idx_oidc_login_states_created_at ON oidc_login_states (created_at);
+
+-- Accounts of external identity providers linked to users
+CREATE TABLE user_identities (
+    provider   VARCHAR (50)  NOT NULL,
+    subject    VARCHAR (255) NOT NULL,
+    user_id    uuid          NOT NULL references "user" (id) on delete cascade,
+    email      VARCHAR (255) NOT NULL default '',
+    created_at INT           NOT NULL default 0,
+    CONSTRAINT user_identities_pkey PRIMARY KEY (provider, subject)
+);
+
+-- Comments
+comment on column user_identities.subject is 'sub claim of the ID token, stable per provider';
+
+-- Indexes
+CREATE INDEX idx_user_identities_user_id ON user_identities (user_id);
@@ -0,0 +1,18 @@
+-- Create user_sessions table, one row per login
+CREATE TABLE user_sessions (
+    id           uuid          NOT NULL default uuid_generate_v4() PRIMARY KEY,
+    user_id      uuid          NOT NULL references "user" (id) on delete cascade,
+    user_agent   VARCHAR (512) NOT NULL default '',
+    ip           VARCHAR (45)  NOT NULL default '',
+    created_at   INT           NOT NULL default 0,
+    last_seen_at INT           NOT NULL default 0,
+    expires_at   INT           NOT NULL default 0,
+    revoked_at   INT           NOT NULL default 0
+);
+
+-- Comments
+comment on column user_sessions.expires_at is 'expiry of the access token issued for the session';
+comment on column user_sessions.ip is 'IP address the session was last seen from';
+
+-- Indexes
+CREATE INDEX idx_user_sessions_user_id ON user_sessions (user_id);
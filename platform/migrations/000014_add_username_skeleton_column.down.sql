@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_user_username_skeleton;
+
+ALTER TABLE "user"
+    DROP COLUMN IF EXISTS username_skeleton;
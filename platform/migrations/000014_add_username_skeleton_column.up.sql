@@ -0,0 +1,7 @@
+-- Add username skeleton column to user table, lookalike usernames share the same skeleton
+ALTER TABLE "user"
+    ADD COLUMN username_skeleton varchar(255) not null default '';
+
+comment on column "user".username_skeleton is 'username without diacritics and with confusable characters replaced, filled by the application';
+
+CREATE INDEX idx_user_username_skeleton ON "user" (username_skeleton);
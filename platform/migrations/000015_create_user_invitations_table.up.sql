@@ -0,0 +1,20 @@
+-- Create user_invitations table, signup invitations sent by administrators
+CREATE TABLE user_invitations (
+    id          uuid          NOT NULL default uuid_generate_v4() PRIMARY KEY,
+    email       VARCHAR (255) NOT NULL default '',
+    role        VARCHAR (50)  NOT NULL default '',
+    token_hash  CHAR (64)     NOT NULL constraint user_invitations_token_hash_key unique,
+    invited_by  uuid          references "user" (id) on delete set null,
+    accepted_by uuid          references "user" (id) on delete set null,
+    expires_at  INT           NOT NULL default 0,
+    accepted_at INT           NOT NULL default 0,
+    revoked_at  INT           NOT NULL default 0,
+    created_at  INT           NOT NULL default 0
+);
+
+-- Comments
+comment on column user_invitations.token_hash is 'hex encoded SHA-256 of the invitation token';
+comment on column user_invitations.role is 'role granted on signup, empty for none';
+
+-- Indexes
+CREATE INDEX idx_user_invitations_email ON user_invitations (LOWER(email));
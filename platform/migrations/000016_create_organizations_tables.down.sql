@@ -0,0 +1,12 @@
+ALTER TABLE api_keys DROP COLUMN IF EXISTS organization_id;
+
+DROP POLICY IF EXISTS books_organization_isolation ON books;
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE books DISABLE ROW LEVEL SECURITY;
+
+DROP INDEX IF EXISTS idx_books_organization_id_created_at;
+ALTER TABLE books DROP COLUMN IF EXISTS organization_id;
+
+-- Delete tables
+DROP TABLE IF EXISTS organization_members;
+DROP TABLE IF EXISTS organizations;
@@ -0,0 +1,47 @@
+-- Create organizations tables, every organization has its own book catalog
+CREATE TABLE organizations (
+    id         uuid          NOT NULL default uuid_generate_v4() PRIMARY KEY,
+    slug       VARCHAR (64)  NOT NULL constraint organizations_slug_key unique,
+    name       VARCHAR (255) NOT NULL default '',
+    created_by uuid          NULL references "user" (id) on delete set null,
+    created_at INT           NOT NULL default 0,
+    updated_at INT           NOT NULL default 0
+);
+
+CREATE TABLE organization_members (
+    organization_id uuid         NOT NULL references organizations (id) on delete cascade,
+    user_id         uuid         NOT NULL references "user" (id) on delete cascade,
+    role_name       VARCHAR (64) NULL references roles (name) on delete set null on update cascade,
+    created_at      INT          NOT NULL default 0,
+
+    PRIMARY KEY (organization_id, user_id)
+);
+
+comment on column organization_members.role_name is 'role granted within the organization only, NULL for a plain member';
+
+CREATE INDEX idx_organization_members_user_id ON organization_members (user_id);
+
+-- Existing catalog and accounts move to the default organization
+INSERT INTO organizations (slug, name, created_at, updated_at)
+    VALUES ('default', 'Default', extract(epoch from now())::int, extract(epoch from now())::int);
+
+INSERT INTO organization_members (organization_id, user_id, created_at)
+    SELECT o.id, u.id, extract(epoch from now())::int FROM organizations o, "user" u WHERE o.slug = 'default' AND u.status <> 2;
+
+ALTER TABLE books ADD COLUMN organization_id uuid NULL references organizations (id) on delete cascade;
+UPDATE books SET organization_id = (SELECT id FROM organizations WHERE slug = 'default');
+ALTER TABLE books ALTER COLUMN organization_id SET NOT NULL;
+
+CREATE INDEX idx_books_organization_id_created_at ON books (organization_id, created_at);
+
+-- Row level security: a transaction only sees the books of the organization set in app.current_organization,
+-- nothing when it is not set. The application must not connect as a superuser, superusers bypass the policy.
+ALTER TABLE books ENABLE ROW LEVEL SECURITY;
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY books_organization_isolation ON books
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
+
+-- API keys act in the organization that was active when they were created
+ALTER TABLE api_keys ADD COLUMN organization_id uuid NULL references organizations (id) on delete set null;
@@ -0,0 +1,23 @@
+-- Record of write operations, rows are written in the same transaction as the change
+CREATE TABLE audit_log (
+    id            BIGSERIAL     PRIMARY KEY,
+    actor_id      uuid          NULL,
+    action        VARCHAR (64)  NOT NULL,
+    resource_type VARCHAR (32)  NOT NULL,
+    resource_id   VARCHAR (64)  NOT NULL,
+    before        JSONB         NULL,
+    after         JSONB         NULL,
+    ip            VARCHAR (64)  NOT NULL default '',
+    request_id    VARCHAR (128) NOT NULL default '',
+    created_at    INT           NOT NULL default 0
+);
+
+CREATE INDEX idx_audit_log_created_at ON audit_log (created_at);
+CREATE INDEX idx_audit_log_actor ON audit_log (actor_id, created_at);
+CREATE INDEX idx_audit_log_resource ON audit_log (resource_type, resource_id, created_at);
+
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'Read the audit log');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'audit:read');
@@ -0,0 +1,30 @@
+-- Every version of a book, revision 1 is the book as created
+CREATE TABLE book_revisions (
+    book_id         INT           NOT NULL references books (id) on delete cascade,
+    revision        INT           NOT NULL,
+    organization_id uuid          NOT NULL references organizations (id) on delete cascade,
+    title           VARCHAR (255) NOT NULL,
+    author          VARCHAR (255) NOT NULL,
+    content         TEXT          NOT NULL,
+    price           NUMERIC(15,2) NOT NULL,
+    rating          INT           NOT NULL default 0,
+    created_by      uuid          NULL references "user" (id) on delete set null,
+    created_at      INT           NOT NULL default 0,
+
+    PRIMARY KEY (book_id, revision)
+);
+
+-- Existing books start their history with their current version, the owner only sees them without forced row level security
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+
+INSERT INTO book_revisions (book_id, revision, organization_id, title, author, content, price, rating, created_by, created_at)
+    SELECT id, 1, organization_id, title, author, content, price, rating, COALESCE(updated_by, created_by), updated_at FROM books;
+
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+
+ALTER TABLE book_revisions ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_revisions FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY book_revisions_organization_isolation ON book_revisions
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
@@ -0,0 +1,4 @@
+comment on column books.author is NULL;
+
+DROP TABLE IF EXISTS book_authors;
+DROP TABLE IF EXISTS authors;
@@ -0,0 +1,55 @@
+-- Authors of the book catalog of an organization, name_key deduplicates spelling variants such as "J.K. Rowling" and "JK Rowling"
+CREATE TABLE authors (
+    id              SERIAL        PRIMARY KEY,
+    organization_id uuid          NOT NULL references organizations (id) on delete cascade,
+    name            VARCHAR (255) NOT NULL,
+    name_key        VARCHAR (255) NOT NULL,
+    bio             TEXT          NOT NULL default '',
+    created_at      INT           NOT NULL default 0,
+    updated_at      INT           NOT NULL default 0,
+
+    constraint authors_organization_id_name_key_key unique (organization_id, name_key)
+);
+
+CREATE TABLE book_authors (
+    book_id         INT          NOT NULL references books (id) on delete cascade,
+    author_id       INT          NOT NULL references authors (id) on delete cascade,
+    role            VARCHAR (16) NOT NULL default 'author' CHECK (role IN ('author', 'editor', 'translator')),
+    position        INT          NOT NULL default 0,
+    organization_id uuid         NOT NULL references organizations (id) on delete cascade,
+
+    PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX idx_book_authors_author_id ON book_authors (author_id);
+
+comment on column books.author is 'byline as credited on the book, the authors are linked in book_authors';
+
+-- Existing author strings become authors, the owner only sees the books without forced row level security
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+
+INSERT INTO authors (organization_id, name, name_key, created_at, updated_at)
+    SELECT organization_id, mode() WITHIN GROUP (ORDER BY name), name_key, extract(epoch from now())::int, extract(epoch from now())::int
+    FROM (SELECT organization_id, btrim(author) AS name, lower(regexp_replace(author, '[^[:alnum:]]+', '', 'g')) AS name_key FROM books) b
+    WHERE name_key <> ''
+    GROUP BY organization_id, name_key;
+
+INSERT INTO book_authors (book_id, author_id, role, position, organization_id)
+    SELECT b.id, a.id, 'author', 0, b.organization_id FROM books b
+    JOIN authors a ON a.organization_id = b.organization_id AND a.name_key = lower(regexp_replace(b.author, '[^[:alnum:]]+', '', 'g'));
+
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+
+ALTER TABLE authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE authors FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY authors_organization_isolation ON authors
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
+
+ALTER TABLE book_authors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_authors FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY book_authors_organization_isolation ON book_authors
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
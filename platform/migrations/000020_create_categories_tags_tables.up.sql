@@ -0,0 +1,67 @@
+-- Category tree of the book catalog of an organization, path holds the ids from the root e.g. '/1/4/9/'
+CREATE TABLE categories (
+    id              SERIAL        PRIMARY KEY,
+    organization_id uuid          NOT NULL references organizations (id) on delete cascade,
+    parent_id       INT           NULL references categories (id) on delete restrict,
+    name            VARCHAR (128) NOT NULL,
+    path            VARCHAR (512) NOT NULL default '',
+    created_at      INT           NOT NULL default 0,
+    updated_at      INT           NOT NULL default 0
+);
+
+CREATE INDEX idx_categories_organization_id_path ON categories (organization_id, path varchar_pattern_ops);
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+
+CREATE TABLE book_categories (
+    book_id         INT  NOT NULL references books (id) on delete cascade,
+    category_id     INT  NOT NULL references categories (id) on delete cascade,
+    organization_id uuid NOT NULL references organizations (id) on delete cascade,
+
+    PRIMARY KEY (book_id, category_id)
+);
+
+CREATE INDEX idx_book_categories_category_id ON book_categories (category_id);
+
+-- Free-form tags, name is trimmed and lowercase
+CREATE TABLE tags (
+    id              SERIAL       PRIMARY KEY,
+    organization_id uuid         NOT NULL references organizations (id) on delete cascade,
+    name            VARCHAR (64) NOT NULL,
+    created_at      INT          NOT NULL default 0,
+
+    constraint tags_organization_id_name_key unique (organization_id, name)
+);
+
+CREATE TABLE book_tags (
+    book_id         INT  NOT NULL references books (id) on delete cascade,
+    tag_id          INT  NOT NULL references tags (id) on delete cascade,
+    organization_id uuid NOT NULL references organizations (id) on delete cascade,
+
+    PRIMARY KEY (book_id, tag_id)
+);
+
+CREATE INDEX idx_book_tags_tag_id ON book_tags (tag_id);
+
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE categories FORCE ROW LEVEL SECURITY;
+CREATE POLICY categories_organization_isolation ON categories
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
+
+ALTER TABLE book_categories ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_categories FORCE ROW LEVEL SECURITY;
+CREATE POLICY book_categories_organization_isolation ON book_categories
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
+
+ALTER TABLE tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE tags FORCE ROW LEVEL SECURITY;
+CREATE POLICY tags_organization_isolation ON tags
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
+
+ALTER TABLE book_tags ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_tags FORCE ROW LEVEL SECURITY;
+CREATE POLICY book_tags_organization_isolation ON book_tags
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
@@ -0,0 +1,7 @@
+ALTER TABLE book_revisions ADD COLUMN rating INT NOT NULL default 0;
+
+ALTER TABLE books DROP COLUMN IF EXISTS rating_count;
+ALTER TABLE books DROP COLUMN IF EXISTS rating_average;
+ALTER TABLE books ADD COLUMN rating INT NOT NULL default 0;
+
+DROP TABLE IF EXISTS book_reviews;
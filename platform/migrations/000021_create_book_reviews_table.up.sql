@@ -0,0 +1,29 @@
+-- Reviews by readers replace the rating set by whoever edited the book, one review per user per book
+CREATE TABLE book_reviews (
+    id              SERIAL   PRIMARY KEY,
+    book_id         INT      NOT NULL references books (id) on delete cascade,
+    user_id         uuid     NOT NULL references "user" (id) on delete cascade,
+    organization_id uuid     NOT NULL references organizations (id) on delete cascade,
+    rating          SMALLINT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    body            TEXT     NOT NULL default '',
+    created_at      INT      NOT NULL default 0,
+    updated_at      INT      NOT NULL default 0,
+
+    constraint book_reviews_book_id_user_id_key unique (book_id, user_id)
+);
+
+CREATE INDEX idx_book_reviews_book_id_created_at ON book_reviews (book_id, created_at);
+
+ALTER TABLE book_reviews ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_reviews FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY book_reviews_organization_isolation ON book_reviews
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
+
+-- Denormalized from book_reviews, updated in the transaction that changes a review
+ALTER TABLE books DROP COLUMN rating;
+ALTER TABLE books ADD COLUMN rating_average NUMERIC(3,2) NOT NULL default 0;
+ALTER TABLE books ADD COLUMN rating_count   INT          NOT NULL default 0;
+
+ALTER TABLE book_revisions DROP COLUMN rating;
@@ -0,0 +1,4 @@
+-- ISBN-13, ISBN-10 are converted before saving. Unique per organization, a book without ISBN is NULL
+ALTER TABLE books ADD COLUMN isbn VARCHAR(13) NULL;
+
+CREATE UNIQUE INDEX books_organization_id_isbn_key ON books (organization_id, isbn);
@@ -0,0 +1,2 @@
+-- URLs of the cover and its thumbnails together with their blob keys, NULL without a cover
+ALTER TABLE books ADD COLUMN cover JSONB NULL;
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS book_prices;
+
+ALTER TABLE book_revisions DROP COLUMN IF EXISTS currency;
+ALTER TABLE book_revisions ALTER COLUMN price TYPE NUMERIC(15,2) USING price / 100.0;
+
+ALTER TABLE books DROP COLUMN IF EXISTS currency;
+ALTER TABLE books ALTER COLUMN price TYPE NUMERIC(15,2) USING price / 100.0;
+ALTER TABLE books ALTER COLUMN price SET default 0;
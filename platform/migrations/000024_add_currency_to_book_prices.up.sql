@@ -0,0 +1,27 @@
+-- Prices are stored in the minor unit of their ISO 4217 currency, e.g. 1999 USD is 19.99 dollars.
+-- Prices entered before currencies existed were in rupiah
+ALTER TABLE books ALTER COLUMN price DROP DEFAULT;
+ALTER TABLE books ALTER COLUMN price TYPE BIGINT USING round(price * 100)::bigint;
+ALTER TABLE books ADD COLUMN currency CHAR(3) NOT NULL default 'IDR';
+ALTER TABLE books ALTER COLUMN currency DROP DEFAULT;
+
+ALTER TABLE book_revisions ALTER COLUMN price TYPE BIGINT USING round(price * 100)::bigint;
+ALTER TABLE book_revisions ADD COLUMN currency CHAR(3) NOT NULL default 'IDR';
+ALTER TABLE book_revisions ALTER COLUMN currency DROP DEFAULT;
+
+-- Price list of a book in currencies other than its own
+CREATE TABLE book_prices (
+    book_id         INT     NOT NULL references books (id) on delete cascade,
+    currency        CHAR(3) NOT NULL,
+    amount          BIGINT  NOT NULL CHECK (amount > 0),
+    organization_id uuid    NOT NULL references organizations (id) on delete cascade,
+
+    PRIMARY KEY (book_id, currency)
+);
+
+ALTER TABLE book_prices ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_prices FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY book_prices_organization_isolation ON book_prices
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
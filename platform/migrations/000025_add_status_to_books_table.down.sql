@@ -0,0 +1,8 @@
+DELETE FROM permissions WHERE name = 'book:publish';
+
+DROP INDEX IF EXISTS idx_books_publish_at;
+DROP INDEX IF EXISTS idx_books_organization_id_status_created_at;
+
+ALTER TABLE books DROP COLUMN IF EXISTS published_at;
+ALTER TABLE books DROP COLUMN IF EXISTS publish_at;
+ALTER TABLE books DROP COLUMN IF EXISTS status;
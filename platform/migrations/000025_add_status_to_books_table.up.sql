@@ -0,0 +1,23 @@
+-- Publication workflow, new books start as draft. Books existing before it stay in the public catalog
+ALTER TABLE books ADD COLUMN status VARCHAR (16) NOT NULL default 'published'
+    CHECK (status IN ('draft', 'in_review', 'published', 'archived'));
+ALTER TABLE books ALTER COLUMN status SET default 'draft';
+
+ALTER TABLE books ADD COLUMN publish_at   INT NULL;
+ALTER TABLE books ADD COLUMN published_at INT NULL;
+
+comment on column books.publish_at is 'scheduled publication time, NULL when none is scheduled';
+
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+UPDATE books SET published_at = created_at;
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+
+CREATE INDEX idx_books_organization_id_status_created_at ON books (organization_id, status, created_at);
+CREATE INDEX idx_books_publish_at ON books (publish_at) WHERE publish_at IS NOT NULL;
+
+INSERT INTO permissions (name, description) VALUES
+    ('book:publish', 'Publish, schedule, unpublish and archive books');
+
+INSERT INTO role_permissions (role_name, permission_name) VALUES
+    ('admin', 'book:publish'),
+    ('editor', 'book:publish');
@@ -0,0 +1,24 @@
+-- Chapters are joined back into the content of their book and of its revisions, revisions without chapters keep their summary
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE book_revisions NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE book_chapters NO FORCE ROW LEVEL SECURITY;
+
+ALTER TABLE books ADD COLUMN content TEXT NOT NULL default '';
+UPDATE books b SET content = c.content FROM (
+    SELECT book_id, string_agg('# ' || title || E'\n\n' || content, E'\n\n' ORDER BY position) AS content
+    FROM book_chapters GROUP BY book_id
+) c WHERE c.book_id = b.id;
+ALTER TABLE books DROP COLUMN IF EXISTS summary;
+
+ALTER TABLE book_revisions ADD COLUMN content TEXT NOT NULL default '';
+UPDATE book_revisions r SET content = COALESCE((
+    SELECT string_agg('# ' || (c->>'title') || E'\n\n' || (c->>'content'), E'\n\n' ORDER BY (c->>'position')::int)
+    FROM jsonb_array_elements(r.chapters) c
+), summary);
+ALTER TABLE book_revisions DROP COLUMN IF EXISTS chapters;
+ALTER TABLE book_revisions DROP COLUMN IF EXISTS summary;
+
+ALTER TABLE book_revisions FORCE ROW LEVEL SECURITY;
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+
+DROP TABLE IF EXISTS book_chapters;
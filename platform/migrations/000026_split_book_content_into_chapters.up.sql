@@ -0,0 +1,49 @@
+-- Content of a book in ordered chapters of Markdown, positions are 1 to n within a book
+CREATE TABLE book_chapters (
+    id              SERIAL        PRIMARY KEY,
+    book_id         INT           NOT NULL references books (id) on delete cascade,
+    organization_id uuid          NOT NULL references organizations (id) on delete cascade,
+    position        INT           NOT NULL CHECK (position > 0),
+    title           VARCHAR (255) NOT NULL,
+    content         TEXT          NOT NULL default '',
+    created_at      INT           NOT NULL default 0,
+    updated_at      INT           NOT NULL default 0,
+    created_by      uuid          NULL references "user" (id) on delete set null,
+    updated_by      uuid          NULL references "user" (id) on delete set null,
+
+    -- diperiksa di akhir transaksi supaya posisi bab bisa digeser dengan satu UPDATE
+    CONSTRAINT book_chapters_book_id_position_key UNIQUE (book_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+-- The content of existing books becomes their first chapter and a short excerpt their summary,
+-- the owner only sees them without forced row level security
+ALTER TABLE books NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE book_revisions NO FORCE ROW LEVEL SECURITY;
+
+INSERT INTO book_chapters (book_id, organization_id, position, title, content, created_at, updated_at, created_by, updated_by)
+    SELECT id, organization_id, 1, title, content, created_at, updated_at, created_by, updated_by FROM books WHERE btrim(content) <> '';
+
+ALTER TABLE books ADD COLUMN summary VARCHAR (1000) NOT NULL default '';
+UPDATE books SET summary = left(btrim(regexp_replace(content, '\s+', ' ', 'g')), 300);
+ALTER TABLE books DROP COLUMN content;
+
+-- Revisions keep their content as their only chapter, chapters is a snapshot of the chapters of the book
+ALTER TABLE book_revisions ADD COLUMN summary VARCHAR (1000) NOT NULL default '';
+ALTER TABLE book_revisions ADD COLUMN chapters JSONB NOT NULL default '[]';
+UPDATE book_revisions SET summary = left(btrim(regexp_replace(content, '\s+', ' ', 'g')), 300),
+    chapters = CASE WHEN btrim(content) <> ''
+        THEN jsonb_build_array(jsonb_build_object('position', 1, 'title', title, 'content', content))
+        ELSE '[]' END;
+ALTER TABLE book_revisions DROP COLUMN content;
+
+ALTER TABLE book_revisions FORCE ROW LEVEL SECURITY;
+ALTER TABLE books FORCE ROW LEVEL SECURITY;
+
+CREATE INDEX idx_book_chapters_organization_id ON book_chapters (organization_id);
+
+ALTER TABLE book_chapters ENABLE ROW LEVEL SECURITY;
+ALTER TABLE book_chapters FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY book_chapters_organization_isolation ON book_chapters
+    USING (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid)
+    WITH CHECK (organization_id = NULLIF(current_setting('app.current_organization', true), '')::uuid);
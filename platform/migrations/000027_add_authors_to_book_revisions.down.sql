@@ -0,0 +1 @@
+ALTER TABLE book_revisions DROP COLUMN IF EXISTS authors;
@@ -0,0 +1,2 @@
+-- Credits of the book at the revision, NULL for revisions stored before they were kept
+ALTER TABLE book_revisions ADD COLUMN authors JSONB NULL;
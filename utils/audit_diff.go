@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// AuditDiff returns the JSON encoded fields of before and after that differ, nil stands for a resource
+// that does not exist (yet or anymore) and yields a nil side. Values must encode to JSON objects.
+func AuditDiff(before, after interface{}) (b, a json.RawMessage, err error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return
+	}
+
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return
+	}
+
+	if beforeFields != nil && afterFields != nil {
+		for k, v := range beforeFields {
+			if w, ok := afterFields[k]; ok && reflect.DeepEqual(v, w) {
+				delete(beforeFields, k)
+				delete(afterFields, k)
+			}
+		}
+	}
+
+	if beforeFields != nil {
+		b, err = json.Marshal(beforeFields)
+		if err != nil {
+			return
+		}
+	}
+
+	if afterFields != nil {
+		a, err = json.Marshal(afterFields)
+	}
+
+	return
+}
+
+func jsonFields(v interface{}) (fields map[string]interface{}, err error) {
+	if v == nil || (reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil()) {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	err = json.Unmarshal(raw, &fields)
+	return
+}
@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuditDiff(t *testing.T) {
+	type book struct {
+		Title string  `json:"title"`
+		Price float64 `json:"price"`
+	}
+
+	b, a, err := AuditDiff(book{Title: "Go", Price: 10}, book{Title: "Go", Price: 12})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"price":10}`, string(b))
+	assert.JSONEq(t, `{"price":12}`, string(a))
+
+	b, a, err = AuditDiff(nil, book{Title: "Go", Price: 10})
+	assert.NoError(t, err)
+	assert.Nil(t, b)
+	assert.JSONEq(t, `{"title":"Go","price":10}`, string(a))
+
+	var deleted *book
+	b, a, err = AuditDiff(&book{Title: "Go", Price: 10}, deleted)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"title":"Go","price":10}`, string(b))
+	assert.Nil(t, a)
+
+	b, a, err = AuditDiff(book{Title: "Go"}, book{Title: "Go"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{}`, string(b))
+	assert.JSONEq(t, `{}`, string(a))
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AuthorNameKey returns the key under which spelling variants of an author name are deduplicated:
+// lowercase letters and digits only, so "J.K. Rowling" and "JK Rowling" share a key. It mirrors
+// lower(regexp_replace(name, '[^[:alnum:]]+', '', 'g')) used by the authors migration.
+func AuthorNameKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	return b.String()
+}
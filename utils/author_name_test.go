@@ -0,0 +1,15 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorNameKey(t *testing.T) {
+	assert.Equal(t, AuthorNameKey("JK Rowling"), AuthorNameKey("J.K. Rowling"))
+	assert.Equal(t, AuthorNameKey("Ursula K. Le Guin"), AuthorNameKey("ursula k le guin"))
+	assert.Equal(t, "pramoedyaanantatoer", AuthorNameKey(" Pramoedya Ananta Toer "))
+	assert.NotEqual(t, AuthorNameKey("Tere Liye"), AuthorNameKey("Tere Liyé"))
+	assert.Equal(t, "", AuthorNameKey("..."))
+}
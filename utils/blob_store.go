@@ -0,0 +1,171 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Blob store drivers
+const (
+	BlobDriverLocal = "local"
+	BlobDriverS3    = "s3"
+)
+
+// LocalBlobRoute the route the app serves the blobs of the local store from
+const LocalBlobRoute = "/media"
+
+// BlobStoreConfig where uploaded files are stored.
+type BlobStoreConfig struct {
+	Driver string
+	// PublicURL the base URL of the blobs, defaults to LocalBlobRoute for the local store
+	// and to the bucket URL for S3
+	PublicURL string
+	// LocalDir the directory of the local store
+	LocalDir string
+	// S3Endpoint the URL of an S3-compatible service, the bucket is addressed path-style
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// BlobStoreConfigFromEnv func for read the blob store settings from BLOB_* envs.
+func BlobStoreConfigFromEnv() BlobStoreConfig {
+	return BlobStoreConfig{
+		Driver:      GetEnv("BLOB_DRIVER", BlobDriverLocal),
+		PublicURL:   GetEnv("BLOB_PUBLIC_URL", ""),
+		LocalDir:    GetEnv("BLOB_LOCAL_DIR", "storage"),
+		S3Endpoint:  GetEnv("BLOB_S3_ENDPOINT", ""),
+		S3Region:    GetEnv("BLOB_S3_REGION", "us-east-1"),
+		S3Bucket:    GetEnv("BLOB_S3_BUCKET", ""),
+		S3AccessKey: GetEnv("BLOB_S3_ACCESS_KEY", ""),
+		S3SecretKey: GetEnv("BLOB_S3_SECRET_KEY", ""),
+	}
+}
+
+// NewBlobStore func for create the blob store of the configured driver.
+func NewBlobStore(config BlobStoreConfig) (domain.BlobStore, error) {
+	switch config.Driver {
+	case BlobDriverLocal:
+		publicURL := config.PublicURL
+		if publicURL == "" {
+			publicURL = LocalBlobRoute
+		}
+		return NewLocalBlobStore(config.LocalDir, publicURL)
+	case BlobDriverS3:
+		if config.S3Endpoint == "" || config.S3Bucket == "" || config.S3AccessKey == "" || config.S3SecretKey == "" {
+			return nil, fmt.Errorf("S3 blob store requires BLOB_S3_ENDPOINT, BLOB_S3_BUCKET, BLOB_S3_ACCESS_KEY and BLOB_S3_SECRET_KEY envs")
+		}
+		return NewS3BlobStore(config), nil
+	}
+
+	return nil, fmt.Errorf("unknown blob store driver %q", config.Driver)
+}
+
+type localBlobStore struct {
+	dir       string
+	publicURL string
+}
+
+// NewLocalBlobStore func for create a blob store keeping the blobs as files in the directory.
+func NewLocalBlobStore(dir, publicURL string) (domain.BlobStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &localBlobStore{dir: dir, publicURL: strings.TrimSuffix(publicURL, "/")}, nil
+}
+
+func (l *localBlobStore) Put(key, contentType string, data []byte) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(file), 0755)
+	if err != nil {
+		return err
+	}
+
+	// tulis ke file sementara lalu rename, supaya blob tidak pernah terbaca setengah jadi
+	tmp, err := ioutil.TempFile(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), file)
+}
+
+func (l *localBlobStore) Get(key string) (data []byte, contentType string, err error) {
+	file, err := l.path(key)
+	if err != nil {
+		return
+	}
+
+	data, err = ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, "", domain.NotFoundError{Code: fiber.StatusNotFound, Message: "blob not found"}
+	}
+	if err != nil {
+		return
+	}
+
+	// file lokal tidak menyimpan content type, ditebak dari ekstensi seperti saat disajikan
+	contentType = mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+
+	return
+}
+
+func (l *localBlobStore) Delete(key string) error {
+	file, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(file)
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (l *localBlobStore) URL(key string) string {
+	return l.publicURL + "/" + key
+}
+
+// path returns the file of the key, keys must stay inside the directory of the store.
+func (l *localBlobStore) path(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if clean == "/" || clean != "/"+key {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+
+	return filepath.Join(l.dir, filepath.FromSlash(clean)), nil
+}
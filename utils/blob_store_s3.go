@@ -0,0 +1,210 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// s3Timeout timeout of a request to the S3 service
+const s3Timeout = 30 * time.Second
+
+type s3BlobStore struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	publicURL string
+	client    *http.Client
+	// now the time requests are signed at, replaced in tests
+	now func() time.Time
+}
+
+// NewS3BlobStore func for create a blob store keeping the blobs in a bucket of an S3-compatible service,
+// requests are signed with AWS Signature Version 4.
+func NewS3BlobStore(config BlobStoreConfig) domain.BlobStore {
+	endpoint := strings.TrimSuffix(config.S3Endpoint, "/")
+
+	publicURL := strings.TrimSuffix(config.PublicURL, "/")
+	if publicURL == "" {
+		publicURL = endpoint + "/" + config.S3Bucket
+	}
+
+	return &s3BlobStore{
+		endpoint:  endpoint,
+		region:    config.S3Region,
+		bucket:    config.S3Bucket,
+		accessKey: config.S3AccessKey,
+		secretKey: config.S3SecretKey,
+		publicURL: publicURL,
+		client:    &http.Client{Timeout: s3Timeout},
+		now:       time.Now,
+	}
+}
+
+func (s *s3BlobStore) Put(key, contentType string, data []byte) error {
+	res, err := s.do(http.MethodPut, key, contentType, data)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return s3Error(res)
+	}
+
+	return nil
+}
+
+func (s *s3BlobStore) Get(key string) (data []byte, contentType string, err error) {
+	res, err := s.do(http.MethodGet, key, "", nil)
+	if err != nil {
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		return nil, "", domain.NotFoundError{Code: fiber.StatusNotFound, Message: "blob not found"}
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, "", s3Error(res)
+	}
+
+	data, err = ioutil.ReadAll(res.Body)
+	return data, res.Header.Get("Content-Type"), err
+}
+
+func (s *s3BlobStore) Delete(key string) error {
+	res, err := s.do(http.MethodDelete, key, "", nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	// S3 menjawab 204 juga untuk object yang tidak ada
+	if res.StatusCode != http.StatusNoContent && res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return s3Error(res)
+	}
+
+	return nil
+}
+
+func (s *s3BlobStore) URL(key string) string {
+	return s.publicURL + "/" + key
+}
+
+// do sends a signed request for the object of the key.
+func (s *s3BlobStore) do(method, key, contentType string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, s.endpoint+"/"+s.bucket+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	signS3Request(req, body, s.region, s.accessKey, s.secretKey, s.now())
+
+	return s.client.Do(req)
+}
+
+// signS3Request adds the Authorization header of AWS Signature Version 4 to the request,
+// every header already set on the request is signed.
+func signS3Request(req *http.Request, body []byte, region, accessKey, secretKey string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := t.Format("20060102")
+
+	payloadHash := sha256Hex(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery query string sorted by name, escaped as SigV4 requires.
+func canonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var parts []string
+	for _, name := range names {
+		values := query[name]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, s3Escape(name)+"="+s3Escape(v))
+		}
+	}
+
+	return strings.Join(parts, "&")
+}
+
+func s3Escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Error error of an unexpected response of the S3 service.
+func s3Error(res *http.Response) error {
+	body, _ := ioutil.ReadAll(res.Body)
+	return fmt.Errorf("s3 %s %s: %s %s", res.Request.Method, res.Request.URL.Path, res.Status, strings.TrimSpace(string(body)))
+}
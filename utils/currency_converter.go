@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"math/big"
+	"os"
+	"strings"
+)
+
+// CurrencyRatesFromEnv func for read the conversion rates from the CURRENCY_RATES env, eg. "USD=1,IDR=16250,EUR=0.92".
+// A rate is the amount of the currency worth as much as one unit of a common base currency.
+func CurrencyRatesFromEnv() (map[string]string, error) {
+	rates := map[string]string{}
+
+	for _, pair := range strings.Split(os.Getenv("CURRENCY_RATES"), ",") {
+		if pair = strings.TrimSpace(pair); pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid currency rate %q, expected CODE=rate", pair)
+		}
+		rates[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return rates, nil
+}
+
+type currencyConverter struct {
+	rates map[string]*big.Rat
+}
+
+// NewCurrencyConverter func for create a converter of money with the rates by currency code, rates are decimals
+// relative to a common base currency. Conversion is exact until the result is rounded to the minor unit.
+func NewCurrencyConverter(rates map[string]string) (domain.CurrencyConverter, error) {
+	c := &currencyConverter{rates: map[string]*big.Rat{}}
+
+	for currency, rate := range rates {
+		if !domain.IsCurrency(currency) {
+			return nil, fmt.Errorf("unknown currency %q", currency)
+		}
+
+		r, ok := new(big.Rat).SetString(rate)
+		if !ok || r.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid rate %q of %s", rate, currency)
+		}
+		c.rates[currency] = r
+	}
+
+	return c, nil
+}
+
+func (c *currencyConverter) Convert(m domain.Money, currency string) (domain.Money, error) {
+	if m.Currency == currency {
+		return m, nil
+	}
+
+	from, to := c.rates[m.Currency], c.rates[currency]
+	if from == nil || to == nil {
+		return domain.Money{}, domain.DataValidationError{Field: "currency", Message: "no conversion rate from " + m.Currency + " to " + currency}
+	}
+
+	// minor unit asal -> mata uang asal -> mata uang tujuan -> minor unit tujuan
+	v := new(big.Rat).SetInt64(m.Amount)
+	v.Quo(v, pow10(domain.CurrencyExponent(m.Currency)))
+	v.Mul(v, to)
+	v.Quo(v, from)
+	v.Mul(v, pow10(domain.CurrencyExponent(currency)))
+
+	amount, err := roundHalfAwayFromZero(v)
+	if err != nil {
+		return domain.Money{}, domain.DataValidationError{Field: "currency", Message: "the amount in " + currency + " is too large"}
+	}
+
+	return domain.Money{Amount: amount, Currency: currency}, nil
+}
+
+func pow10(n int) *big.Rat {
+	return new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+}
+
+// roundHalfAwayFromZero rounds to a whole minor unit, failing when it does not fit an int64
+func roundHalfAwayFromZero(v *big.Rat) (int64, error) {
+	abs := new(big.Rat).Abs(v)
+	abs.Add(abs, big.NewRat(1, 2))
+
+	n := new(big.Int).Quo(abs.Num(), abs.Denom())
+	if v.Sign() < 0 {
+		n.Neg(n)
+	}
+
+	if !n.IsInt64() {
+		return 0, fmt.Errorf("amount %s overflows int64", n)
+	}
+	return n.Int64(), nil
+}
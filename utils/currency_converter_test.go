@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustMoney(t *testing.T, amount, currency string) domain.Money {
+	m, err := domain.ParseMoney(amount, currency)
+	require.NoError(t, err, amount+" "+currency)
+	return m
+}
+
+func TestParseMoney(t *testing.T) {
+	assert.Equal(t, domain.Money{Amount: 1999, Currency: "USD"}, mustMoney(t, "19.99", "USD"))
+	assert.Equal(t, domain.Money{Amount: 1990, Currency: "USD"}, mustMoney(t, "19.9", "USD"))
+	assert.Equal(t, domain.Money{Amount: 1500, Currency: "JPY"}, mustMoney(t, "1500", "JPY"))
+	assert.Equal(t, domain.Money{Amount: 1500, Currency: "KWD"}, mustMoney(t, "1.5", "KWD"))
+	assert.Equal(t, domain.Money{Amount: -5, Currency: "EUR"}, mustMoney(t, "-0.05", "eur"))
+
+	for _, c := range [][2]string{{"19.999", "USD"}, {"1.5", "JPY"}, {"1,5", "EUR"}, {"1e3", "USD"}, {".5", "USD"}, {"5.", "USD"}, {"", "USD"}, {"10", "XYZ"}, {"1234567890123456", "JPY"}} {
+		_, err := domain.ParseMoney(c[0], c[1])
+		assert.Error(t, err, c[0]+" "+c[1])
+	}
+
+	assert.Equal(t, "19.99", mustMoney(t, "19.99", "USD").Decimal())
+	assert.Equal(t, "0.05", mustMoney(t, "0.05", "USD").Decimal())
+	assert.Equal(t, "-0.005", mustMoney(t, "-0.005", "BHD").Decimal())
+	assert.Equal(t, "1500", mustMoney(t, "1500", "JPY").Decimal())
+
+	encoded, err := json.Marshal(mustMoney(t, "19.99", "USD"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"19.99","currency":"USD"}`, string(encoded))
+
+	var decoded domain.Money
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, mustMoney(t, "19.99", "USD"), decoded)
+}
+
+func TestCurrencyConverter(t *testing.T) {
+	_, err := NewCurrencyConverter(map[string]string{"XYZ": "1"})
+	assert.Error(t, err)
+	_, err = NewCurrencyConverter(map[string]string{"USD": "0"})
+	assert.Error(t, err)
+
+	converter, err := NewCurrencyConverter(map[string]string{"USD": "1", "IDR": "16250", "EUR": "0.92", "JPY": "151.3"})
+	require.NoError(t, err)
+
+	converted, err := converter.Convert(mustMoney(t, "19.99", "USD"), "IDR")
+	require.NoError(t, err)
+	assert.Equal(t, mustMoney(t, "324837.50", "IDR"), converted)
+
+	converted, err = converter.Convert(mustMoney(t, "10.00", "EUR"), "JPY")
+	require.NoError(t, err)
+	assert.Equal(t, mustMoney(t, "1645", "JPY"), converted, "1644.565 rounded to whole yen")
+
+	converted, err = converter.Convert(mustMoney(t, "0.01", "IDR"), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, mustMoney(t, "0", "USD"), converted)
+
+	_, err = converter.Convert(mustMoney(t, "1", "USD"), "GBP")
+	assert.IsType(t, domain.DataValidationError{}, err)
+
+	inflated, err := NewCurrencyConverter(map[string]string{"USD": "1", "IDR": "100000000"})
+	require.NoError(t, err)
+	_, err = inflated.Convert(mustMoney(t, "9999999999999.99", "USD"), "IDR")
+	assert.IsType(t, domain.DataValidationError{}, err, "the converted amount overflows int64")
+}
+
+func TestRoundHalfAwayFromZero(t *testing.T) {
+	for _, c := range []struct {
+		v    *big.Rat
+		want int64
+	}{
+		{big.NewRat(5, 2), 3},
+		{big.NewRat(-5, 2), -3},
+		{big.NewRat(12, 5), 2},
+		{big.NewRat(-12, 5), -2},
+		{new(big.Rat).SetInt64(math.MaxInt64), math.MaxInt64},
+	} {
+		n, err := roundHalfAwayFromZero(c.v)
+		require.NoError(t, err, c.v.String())
+		assert.Equal(t, c.want, n, c.v.String())
+	}
+
+	_, err := roundHalfAwayFromZero(new(big.Rat).Add(new(big.Rat).SetInt64(math.MaxInt64), big.NewRat(1, 1)))
+	assert.Error(t, err)
+	_, err = roundHalfAwayFromZero(new(big.Rat).Sub(new(big.Rat).SetInt64(math.MinInt64), big.NewRat(1, 1)))
+	assert.Error(t, err)
+}
@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"bufio"
+	"context"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EmailResolver the DNS lookups used by the MX check, implemented by *net.Resolver.
+type EmailResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// EmailScreenerConfig rules of the email screening.
+type EmailScreenerConfig struct {
+	// DisposableDomains blocked domains, their subdomains are blocked too
+	DisposableDomains []string
+	// DisposableDomainsFile file of more blocked domains, one per line, lines starting with # are ignored
+	DisposableDomainsFile string
+	CheckMX               bool
+	MXCacheTTL            time.Duration
+	// MXCacheSize maximum number of cached domains, the entry expiring first is evicted when full
+	MXCacheSize     int
+	MXLookupTimeout time.Duration
+	// Resolver used by the MX check, net.DefaultResolver when nil
+	Resolver EmailResolver
+}
+
+// EmailScreenerConfigFromEnv func for read the email screening rules from EMAIL_SCREENING_* envs.
+func EmailScreenerConfigFromEnv() EmailScreenerConfig {
+	var domains []string
+	for _, d := range strings.Split(os.Getenv("EMAIL_SCREENING_DISPOSABLE_DOMAINS"), ",") {
+		if d = strings.TrimSpace(d); d != "" {
+			domains = append(domains, d)
+		}
+	}
+
+	return EmailScreenerConfig{
+		DisposableDomains:     domains,
+		DisposableDomainsFile: GetEnv("EMAIL_SCREENING_DISPOSABLE_DOMAINS_FILE", ""),
+		CheckMX:               GetEnv("EMAIL_SCREENING_CHECK_MX", "false") == "true",
+		MXCacheTTL:            time.Duration(GetEnvInt("EMAIL_SCREENING_MX_CACHE_MINUTES", 60)) * time.Minute,
+		MXCacheSize:           GetEnvInt("EMAIL_SCREENING_MX_CACHE_SIZE", 10000),
+		MXLookupTimeout:       time.Duration(GetEnvInt("EMAIL_SCREENING_MX_TIMEOUT_SECONDS", 3)) * time.Second,
+	}
+}
+
+type mxCacheEntry struct {
+	deliverable bool
+	expiresAt   time.Time
+}
+
+type emailScreener struct {
+	config     EmailScreenerConfig
+	disposable map[string]bool
+
+	mu      sync.Mutex
+	mxCache map[string]mxCacheEntry
+	now     func() time.Time
+}
+
+// NewEmailScreener func for create the email screener, the disposable domains file must be readable when configured.
+func NewEmailScreener(config EmailScreenerConfig) (domain.EmailScreener, error) {
+	if config.Resolver == nil {
+		config.Resolver = net.DefaultResolver
+	}
+	if config.MXLookupTimeout <= 0 {
+		config.MXLookupTimeout = 3 * time.Second
+	}
+	if config.MXCacheSize <= 0 {
+		config.MXCacheSize = 10000
+	}
+
+	s := &emailScreener{
+		config:     config,
+		disposable: make(map[string]bool),
+		mxCache:    make(map[string]mxCacheEntry),
+		now:        time.Now,
+	}
+	for _, d := range config.DisposableDomains {
+		s.addDisposable(d)
+	}
+
+	if config.DisposableDomainsFile != "" {
+		f, err := os.Open(config.DisposableDomainsFile)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			s.addDisposable(line)
+		}
+		if err = scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+func (s *emailScreener) addDisposable(d string) {
+	s.disposable[strings.TrimSuffix(strings.ToLower(strings.TrimSpace(d)), ".")] = true
+}
+
+func (s *emailScreener) Screen(email string) error {
+	host, ok := emailDomain(email)
+	if !ok {
+		return domain.ErrEmailInvalid
+	}
+
+	if s.isDisposable(host) {
+		return domain.ErrEmailDisposable
+	}
+
+	if s.config.CheckMX && !s.isDeliverable(host) {
+		return domain.ErrEmailUndeliverable
+	}
+
+	return nil
+}
+
+// emailDomain returns the lowercased domain of a bare address, display names and dotless domains are rejected.
+func emailDomain(email string) (string, bool) {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != strings.TrimSpace(email) || addr.Name != "" {
+		return "", false
+	}
+
+	_, host := split(addr.Address)
+	host = strings.ToLower(host)
+	if host == "" || !strings.Contains(host, ".") || strings.HasPrefix(host, ".") || strings.HasSuffix(host, ".") {
+		return "", false
+	}
+
+	return host, true
+}
+
+// isDisposable reports whether the domain or one of its parent domains is blocked.
+func (s *emailScreener) isDisposable(host string) bool {
+	for {
+		if s.disposable[host] {
+			return true
+		}
+		i := strings.IndexByte(host, '.')
+		if i < 0 {
+			return false
+		}
+		host = host[i+1:]
+	}
+}
+
+// isDeliverable reports whether the domain has a mail exchanger, or an address record as implicit MX (RFC 5321).
+// Lookup failures other than "not found" accept the address, a DNS outage must not block registrations.
+func (s *emailScreener) isDeliverable(host string) bool {
+	s.mu.Lock()
+	entry, ok := s.mxCache[host]
+	s.mu.Unlock()
+	if ok && s.now().Before(entry.expiresAt) {
+		return entry.deliverable
+	}
+
+	deliverable, definitive := s.lookupMX(host)
+	if definitive {
+		s.cacheMX(host, mxCacheEntry{deliverable: deliverable, expiresAt: s.now().Add(s.config.MXCacheTTL)})
+	}
+
+	return deliverable
+}
+
+// cacheMX stores the result of a lookup. A full cache first drops its expired entries,
+// then the entry expiring first, so arbitrary domains sent to the signup can't grow it without bound.
+func (s *emailScreener) cacheMX(host string, entry mxCacheEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.mxCache[host]; !ok && len(s.mxCache) >= s.config.MXCacheSize {
+		now := s.now()
+		for h, e := range s.mxCache {
+			if !now.Before(e.expiresAt) {
+				delete(s.mxCache, h)
+			}
+		}
+
+		if len(s.mxCache) >= s.config.MXCacheSize {
+			var oldest string
+			for h, e := range s.mxCache {
+				if oldest == "" || e.expiresAt.Before(s.mxCache[oldest].expiresAt) {
+					oldest = h
+				}
+			}
+			delete(s.mxCache, oldest)
+		}
+	}
+
+	s.mxCache[host] = entry
+}
+
+func (s *emailScreener) lookupMX(host string) (deliverable, definitive bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.MXLookupTimeout)
+	defer cancel()
+
+	mx, err := s.config.Resolver.LookupMX(ctx, host)
+	if err == nil && len(mx) > 0 {
+		// null MX (RFC 7505): the domain explicitly accepts no mail
+		if len(mx) == 1 && (mx[0].Host == "." || mx[0].Host == "") {
+			return false, true
+		}
+		return true, true
+	}
+	if err != nil && !isDNSNotFound(err) {
+		return true, false
+	}
+
+	addrs, err := s.config.Resolver.LookupHost(ctx, host)
+	if err != nil {
+		if isDNSNotFound(err) {
+			return false, true
+		}
+		return true, false
+	}
+
+	return len(addrs) > 0, true
+}
+
+func isDNSNotFound(err error) bool {
+	dnsErr, ok := err.(*net.DNSError)
+	return ok && dnsErr.IsNotFound
+}
@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeResolver struct {
+	mx      map[string][]*net.MX
+	hosts   map[string][]string
+	lookups int
+}
+
+func (r *fakeResolver) LookupMX(_ context.Context, name string) ([]*net.MX, error) {
+	r.lookups++
+	if mx, ok := r.mx[name]; ok {
+		return mx, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
+
+func (r *fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addrs, ok := r.hosts[host]; ok {
+		return addrs, nil
+	}
+	return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+}
+
+func TestEmailScreener_Syntax(t *testing.T) {
+	screener, err := NewEmailScreener(EmailScreenerConfig{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, screener.Screen("jane@example.com"))
+	for _, email := range []string{"", "jane", "jane@", "jane@localhost", "Jane <jane@example.com>", "jane@example.com."} {
+		assert.Equal(t, domain.ErrEmailInvalid, screener.Screen(email), email)
+	}
+}
+
+func TestEmailScreener_Disposable(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "disposable.txt")
+	assert.NoError(t, os.WriteFile(file, []byte("# blocklist\nguerrillamail.com\n\n"), 0o644))
+
+	screener, err := NewEmailScreener(EmailScreenerConfig{DisposableDomains: []string{"Mailinator.com"}, DisposableDomainsFile: file})
+	assert.NoError(t, err)
+
+	assert.Equal(t, domain.ErrEmailDisposable, screener.Screen("jane@mailinator.com"))
+	assert.Equal(t, domain.ErrEmailDisposable, screener.Screen("jane@eu.mailinator.com"))
+	assert.Equal(t, domain.ErrEmailDisposable, screener.Screen("jane@guerrillamail.com"))
+	assert.NoError(t, screener.Screen("jane@notmailinator.com"))
+
+	_, err = NewEmailScreener(EmailScreenerConfig{DisposableDomainsFile: file + ".missing"})
+	assert.Error(t, err)
+}
+
+func TestEmailScreener_MX(t *testing.T) {
+	resolver := &fakeResolver{
+		mx: map[string][]*net.MX{
+			"example.com": {{Host: "mx.example.com.", Pref: 10}},
+			"nomail.com":  {{Host: ".", Pref: 0}},
+		},
+		hosts: map[string][]string{"implicit.com": {"192.0.2.1"}},
+	}
+	screener, err := NewEmailScreener(EmailScreenerConfig{CheckMX: true, MXCacheTTL: time.Hour, Resolver: resolver})
+	assert.NoError(t, err)
+
+	assert.NoError(t, screener.Screen("jane@example.com"))
+	assert.NoError(t, screener.Screen("jane@implicit.com"))
+	assert.Equal(t, domain.ErrEmailUndeliverable, screener.Screen("jane@nomail.com"))
+	assert.Equal(t, domain.ErrEmailUndeliverable, screener.Screen("jane@missing.com"))
+
+	lookups := resolver.lookups
+	assert.NoError(t, screener.Screen("john@example.com"))
+	assert.Equal(t, domain.ErrEmailUndeliverable, screener.Screen("john@missing.com"))
+	assert.Equal(t, lookups, resolver.lookups)
+}
+
+func TestEmailScreener_MXCacheSize(t *testing.T) {
+	resolver := &fakeResolver{mx: map[string][]*net.MX{}}
+	screener, err := NewEmailScreener(EmailScreenerConfig{CheckMX: true, MXCacheTTL: time.Hour, MXCacheSize: 2, Resolver: resolver})
+	assert.NoError(t, err)
+
+	now := time.Now()
+	s := screener.(*emailScreener)
+	s.now = func() time.Time { return now }
+
+	for i, host := range []string{"a.com", "b.com", "c.com"} {
+		now = now.Add(time.Duration(i) * time.Minute)
+		screener.Screen("jane@" + host)
+	}
+	assert.Len(t, s.mxCache, 2)
+	assert.NotContains(t, s.mxCache, "a.com", "the entry expiring first is evicted")
+
+	// entri yang sudah kedaluwarsa dibuang lebih dulu
+	now = now.Add(2 * time.Hour)
+	screener.Screen("jane@d.com")
+	assert.Len(t, s.mxCache, 1)
+	assert.Contains(t, s.mxCache, "d.com")
+}
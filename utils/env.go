@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"os"
+	"strconv"
+)
+
+// GetEnv returns the value of the environment variable named by the key,
+// or def when the variable is not present or empty.
+func GetEnv(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+
+	return def
+}
+
+// GetEnvInt returns the integer value of the environment variable named by the key,
+// or def when the variable is not present or is not a valid integer.
+func GetEnvInt(key string, def int) int {
+	v, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+
+	return v
+}
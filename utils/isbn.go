@@ -0,0 +1,87 @@
+package utils
+
+import "strings"
+
+// NormalizeISBN validates the checksum of an ISBN-10 or ISBN-13, hyphens and spaces allowed,
+// and returns it as ISBN-13. ok is false when the ISBN is invalid.
+func NormalizeISBN(isbn string) (isbn13 string, ok bool) {
+	s := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i, r := range s {
+			var d int
+			switch {
+			case r >= '0' && r <= '9':
+				d = int(r - '0')
+			case r == 'X' && i == 9:
+				d = 10
+			default:
+				return "", false
+			}
+			sum += (10 - i) * d
+		}
+		if sum%11 != 0 {
+			return "", false
+		}
+
+		s = "978" + s[:9]
+		return s + isbn13CheckDigit(s), true
+	case 13:
+		if !isDigits(s) || (!strings.HasPrefix(s, "978") && !strings.HasPrefix(s, "979")) {
+			return "", false
+		}
+		if isbn13CheckDigit(s[:12]) != s[12:] {
+			return "", false
+		}
+
+		return s, true
+	}
+
+	return "", false
+}
+
+// ISBN10 returns the ISBN-10 form of a normalized ISBN-13, "" for the 979 prefix that has no ISBN-10.
+func ISBN10(isbn13 string) string {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return ""
+	}
+
+	s := isbn13[3:12]
+	sum := 0
+	for i, r := range s {
+		sum += (10 - i) * int(r-'0')
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return s + "X"
+	}
+
+	return s + string(rune('0'+check))
+}
+
+// isbn13CheckDigit returns the check digit of the first 12 digits of an ISBN-13.
+func isbn13CheckDigit(s string) string {
+	sum := 0
+	for i, r := range s {
+		d := int(r - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+
+	return string(rune('0' + (10-sum%10)%10))
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+
+	return true
+}
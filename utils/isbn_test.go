@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	cases := map[string]string{
+		"0-306-40615-2":     "9780306406157",
+		"080442957X":        "9780804429573",
+		"080442957x":        "9780804429573",
+		"978-0-306-40615-7": "9780306406157",
+		"978 0 306 40615 7": "9780306406157",
+		"9791090636071":     "9791090636071",
+	}
+	for in, want := range cases {
+		got, ok := NormalizeISBN(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, want, got, in)
+	}
+
+	for _, in := range []string{"", "0-306-40615-3", "9780306406158", "X306406152", "9770306406150", "978030640615", "97803064061577"} {
+		_, ok := NormalizeISBN(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestISBN10(t *testing.T) {
+	assert.Equal(t, "0306406152", ISBN10("9780306406157"))
+	assert.Equal(t, "080442957X", ISBN10("9780804429573"))
+	assert.Equal(t, "", ISBN10("9791090636071"))
+}
@@ -1,21 +1,29 @@
 package utils
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"github.com/cooljar/go-postgres-fiber/domain"
 	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
 	"os"
 	"strconv"
 	"time"
 )
 
-// GenerateNewAccessToken func for generate a new Access token.
-func GenerateNewAccessToken(u *domain.User) (string, error) {
-	// Set secret key from .env file.
-	secret := os.Getenv("JWT_SECRET_KEY")
-
+// AccessTokenExpiresAt func for the expiry of an access token issued now.
+func AccessTokenExpiresAt() int64 {
 	// Set expires minutes count for secret key from .env file.
 	minutesCount, _ := strconv.Atoi(os.Getenv("JWT_SECRET_KEY_EXPIRE_MINUTES"))
 
+	return time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
+}
+
+// GenerateNewAccessToken func for generate a new Access token for the session.
+func GenerateNewAccessToken(u *domain.User, s *domain.Session) (string, error) {
+	// Set secret key from .env file.
+	secret := os.Getenv("JWT_SECRET_KEY")
+
 	// Create token
 	token := jwt.New(jwt.SigningMethodHS256)
 
@@ -25,7 +33,13 @@ func GenerateNewAccessToken(u *domain.User) (string, error) {
 	claims["email"] = u.Email
 	claims["username"] = u.Username
 	claims["full_name"] = u.FullName
-	claims["exp"] = time.Now().Add(time.Minute * time.Duration(minutesCount)).Unix()
+	claims["roles"] = u.Roles
+	claims["sid"] = s.ID
+	claims["exp"] = s.ExpiresAt
+	if u.Organization != nil {
+		claims["org"] = u.Organization.ID
+		claims["org_role"] = u.Organization.Role
+	}
 
 	// Generate encoded token and send it as response.
 	t, err := token.SignedString([]byte(secret))
@@ -36,3 +50,62 @@ func GenerateNewAccessToken(u *domain.User) (string, error) {
 
 	return t, nil
 }
+
+// mfaChallengeLifetime how long a MFA challenge token can be exchanged for an access token.
+const mfaChallengeLifetime = 5 * time.Minute
+
+// GenerateMfaChallengeToken func for generate a short-lived token proving that the password step of a login succeeded.
+// It is signed with a key derived from JWT_SECRET_KEY, so the JWT middleware never accepts it as an access token.
+func GenerateMfaChallengeToken(userID uuid.UUID) (string, int64, error) {
+	expiresAt := time.Now().Add(mfaChallengeLifetime).Unix()
+
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["id"] = userID
+	claims["purpose"] = "mfa"
+	claims["exp"] = expiresAt
+
+	t, err := token.SignedString(mfaChallengeSigningKey())
+	if err != nil {
+		return "", 0, err
+	}
+
+	return t, expiresAt, nil
+}
+
+// mfaChallengeSigningKey derives the MFA challenge signing key from JWT_SECRET_KEY.
+func mfaChallengeSigningKey() []byte {
+	mac := hmac.New(sha256.New, []byte(os.Getenv("JWT_SECRET_KEY")))
+	mac.Write([]byte("mfa-challenge"))
+	return mac.Sum(nil)
+}
+
+// NewAPIKeyToken func for build an already verified token for a request authenticated with an API key.
+// Claims have the same shape as a parsed access token, plus the key ID and its scopes.
+func NewAPIKeyToken(u *domain.User, k *domain.APIKey) *jwt.Token {
+	roles := make([]interface{}, 0, len(u.Roles))
+	for _, r := range u.Roles {
+		roles = append(roles, r)
+	}
+	scopes := make([]interface{}, 0, len(k.Scopes))
+	for _, s := range k.Scopes {
+		scopes = append(scopes, s)
+	}
+
+	claims := jwt.MapClaims{
+		"id":         u.ID.String(),
+		"email":      u.Email,
+		"username":   u.Username,
+		"full_name":  u.FullName,
+		"roles":      roles,
+		"api_key_id": k.ID.String(),
+		"scopes":     scopes,
+		"exp":        float64(k.ExpiresAt),
+	}
+	if u.Organization != nil {
+		claims["org"] = u.Organization.ID.String()
+		claims["org_role"] = u.Organization.Role
+	}
+
+	return &jwt.Token{Claims: claims, Method: jwt.SigningMethodNone, Valid: true}
+}
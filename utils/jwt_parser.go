@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"errors"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt"
+	"github.com/google/uuid"
+)
+
+// TokenMetadata struct to describe metadata in JWT.
+type TokenMetadata struct {
+	UserID   uuid.UUID
+	Email    string
+	Username string
+	Roles    []string
+	Expires  int64
+	// SessionID is the session the access token was issued for
+	SessionID uuid.UUID
+	// APIKeyID is set when the request is authenticated with an API key, Scopes then limits its permissions
+	APIKeyID uuid.UUID
+	Scopes   []string
+	// OrganizationID is the active organization, OrganizationRole the role granted within it
+	OrganizationID   uuid.UUID
+	OrganizationRole string
+}
+
+// IsAPIKey reports whether the request is authenticated with an API key instead of an access token.
+func (tm *TokenMetadata) IsAPIKey() bool {
+	return tm.APIKeyID != uuid.Nil
+}
+
+// APIKeyScopeContextKey the fiber.Ctx locals key set by AllowAPIKey
+const APIKeyScopeContextKey = "apikeyscope"
+
+// ErrAPIKeyNotAllowed is returned for a request authenticated with an API key to a route no middleware allowed API keys on
+var ErrAPIKeyNotAllowed = domain.ForbiddenError{Message: "not available with an API key"}
+
+// AllowAPIKey marks the request as allowed for an API key, called by the middlewares that checked the scopes of the key.
+func AllowAPIKey(c *fiber.Ctx) {
+	c.Locals(APIKeyScopeContextKey, true)
+}
+
+// ExtractTokenMetadata func to extract metadata from the JWT stored by the JWT middleware.
+// Requests authenticated with an API key are rejected with ErrAPIKeyNotAllowed unless a middleware
+// checked the scopes of the key with AllowAPIKey, so routes are closed to API keys by default.
+func ExtractTokenMetadata(c *fiber.Ctx) (*TokenMetadata, error) {
+	tokenMeta, err := ParseTokenMetadata(c)
+	if err != nil {
+		return nil, err
+	}
+
+	if allowed, _ := c.Locals(APIKeyScopeContextKey).(bool); tokenMeta.IsAPIKey() && !allowed {
+		return nil, ErrAPIKeyNotAllowed
+	}
+
+	return tokenMeta, nil
+}
+
+// ParseTokenMetadata func to extract metadata from the JWT stored by the JWT middleware without
+// the API key check of ExtractTokenMetadata, for the middlewares checking the scopes of API keys.
+func ParseTokenMetadata(c *fiber.Ctx) (*TokenMetadata, error) {
+	token, ok := c.Locals("jwt").(*jwt.Token)
+	if !ok {
+		return nil, errors.New("missing JWT in request context")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid JWT claims")
+	}
+
+	idStr, _ := claims["id"].(string)
+	userID, err := uuid.Parse(idStr)
+	if err != nil {
+		return nil, err
+	}
+
+	email, _ := claims["email"].(string)
+	username, _ := claims["username"].(string)
+	expires, _ := claims["exp"].(float64)
+
+	var sessionID uuid.UUID
+	if sessionIDStr, ok := claims["sid"].(string); ok {
+		sessionID, err = uuid.Parse(sessionIDStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var apiKeyID uuid.UUID
+	if apiKeyIDStr, ok := claims["api_key_id"].(string); ok {
+		apiKeyID, err = uuid.Parse(apiKeyIDStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var organizationID uuid.UUID
+	if organizationIDStr, ok := claims["org"].(string); ok {
+		organizationID, err = uuid.Parse(organizationIDStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	organizationRole, _ := claims["org_role"].(string)
+
+	return &TokenMetadata{
+		UserID:    userID,
+		Email:     email,
+		Username:  username,
+		Roles:     claimStrings(claims, "roles"),
+		Expires:   int64(expires),
+		SessionID: sessionID,
+		APIKeyID:  apiKeyID,
+		Scopes:    claimStrings(claims, "scopes"),
+
+		OrganizationID:   organizationID,
+		OrganizationRole: organizationRole,
+	}, nil
+}
+
+// claimStrings returns the string elements of a list claim.
+func claimStrings(claims jwt.MapClaims, name string) (values []string) {
+	raw, _ := claims[name].([]interface{})
+	for _, v := range raw {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+
+	return
+}
+
+// ParseMfaChallengeToken func to verify a token created by GenerateMfaChallengeToken and return its user ID.
+func ParseMfaChallengeToken(challengeToken string) (uuid.UUID, error) {
+	token, err := jwt.Parse(challengeToken, func(t *jwt.Token) (interface{}, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return mfaChallengeSigningKey(), nil
+	})
+	if err != nil {
+		return uuid.Nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid || claims["purpose"] != "mfa" {
+		return uuid.Nil, errors.New("invalid challenge token")
+	}
+
+	idStr, _ := claims["id"].(string)
+	return uuid.Parse(idStr)
+}
@@ -39,6 +39,9 @@ func (m *Mailer) IsEmailAddressValid() (valid bool, err error) {
 	if err != nil {
 		return false, err
 	}
+	if len(mx) == 0 {
+		return false, fmt.Errorf("no MX record found for %s", host)
+	}
 	client, err := dialTimeout(fmt.Sprintf("%s:%d", mx[0].Host, 25), forceDisconnectAfter)
 	if err != nil {
 		return false, err
@@ -68,7 +71,7 @@ func (m *Mailer) SendMail() error {
 	mailer.SetHeader("From", os.Getenv("SMTP_SENDER_NAME"))
 	mailer.SetHeader("To", m.Destination)
 	//mailer.SetAddressHeader("Cc", "tralalala@gmail.com", "Tra Lala La")
-	mailer.SetHeader("Subject", "Email Validation Secret Code At - Cooljar Apps")
+	mailer.SetHeader("Subject", m.Subject)
 	mailer.SetBody("text/html", tpl.String())
 	//mailer.Attach("./sample.png")
 
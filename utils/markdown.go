@@ -0,0 +1,628 @@
+package utils
+
+import (
+	"html"
+	"strconv"
+	"strings"
+)
+
+// maxMarkdownDepth nesting of block quotes, lists and emphasis rendered, deeper markup is rendered as text
+const maxMarkdownDepth = 16
+
+// maxMarkdownLinkSearch bytes searched for the end of a link text or destination
+const maxMarkdownLinkSearch = 2048
+
+// markdownURLSchemes the URL schemes that are linked, relative URLs are linked too
+var markdownURLSchemes = map[string]bool{"http": true, "https": true, "mailto": true}
+
+// RenderMarkdown renders Markdown as HTML that is safe to embed in a page: raw HTML in the source is escaped
+// instead of passed through and only http, https, mailto and relative URLs are linked. It supports ATX headings,
+// paragraphs, fenced code blocks, block quotes, ordered and unordered lists, thematic breaks, emphasis,
+// code spans, links, images, autolinks and hard line breaks.
+func RenderMarkdown(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+	source = strings.ReplaceAll(source, "\t", "    ")
+
+	var b strings.Builder
+	renderMarkdownBlocks(&b, strings.Split(source, "\n"), 0)
+
+	return b.String()
+}
+
+func renderMarkdownBlocks(b *strings.Builder, lines []string, depth int) {
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		indented := len(line)-len(trimmed) >= 4
+
+		switch {
+		case trimmed == "":
+			i++
+		case !indented && isMarkdownCodeFence(trimmed):
+			i = renderMarkdownCodeFence(b, lines, i)
+		case !indented && markdownHeadingLevel(trimmed) > 0:
+			level := strconv.Itoa(markdownHeadingLevel(trimmed))
+			b.WriteString("<h" + level + ">")
+			renderMarkdownInline(b, markdownHeadingText(trimmed), depth)
+			b.WriteString("</h" + level + ">\n")
+			i++
+		case !indented && isMarkdownThematicBreak(trimmed):
+			b.WriteString("<hr>\n")
+			i++
+		case !indented && strings.HasPrefix(trimmed, ">") && depth < maxMarkdownDepth:
+			var quote []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimLeft(lines[i], " ")
+				if !strings.HasPrefix(t, ">") || len(lines[i])-len(t) >= 4 {
+					break
+				}
+				t = strings.TrimPrefix(t[1:], " ")
+				quote = append(quote, t)
+			}
+
+			b.WriteString("<blockquote>\n")
+			renderMarkdownBlocks(b, quote, depth+1)
+			b.WriteString("</blockquote>\n")
+		case !indented && isMarkdownListItem(trimmed) && depth < maxMarkdownDepth:
+			i = renderMarkdownList(b, lines, i, depth)
+		default:
+			var paragraph []string
+			for ; i < len(lines); i++ {
+				t := strings.TrimLeft(lines[i], " ")
+				if t == "" || (len(paragraph) > 0 && startsMarkdownBlock(lines[i])) {
+					break
+				}
+				paragraph = append(paragraph, t)
+			}
+
+			b.WriteString("<p>")
+			renderMarkdownInline(b, strings.TrimRight(strings.Join(paragraph, "\n"), " "), depth)
+			b.WriteString("</p>\n")
+		}
+	}
+}
+
+// startsMarkdownBlock reports whether the line starts a block other than a paragraph, it interrupts a paragraph.
+func startsMarkdownBlock(line string) bool {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) >= 4 {
+		return false
+	}
+
+	return isMarkdownCodeFence(trimmed) || markdownHeadingLevel(trimmed) > 0 || isMarkdownThematicBreak(trimmed) ||
+		strings.HasPrefix(trimmed, ">") || isMarkdownListItem(trimmed)
+}
+
+func isMarkdownCodeFence(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// renderMarkdownCodeFence renders the fenced code block starting at lines[start], it returns the line after the block.
+func renderMarkdownCodeFence(b *strings.Builder, lines []string, start int) int {
+	trimmed := strings.TrimLeft(lines[start], " ")
+	indent := len(lines[start]) - len(trimmed)
+	fence := trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+
+	b.WriteString("<pre><code")
+	if info := strings.Fields(trimmed[len(fence):]); len(info) > 0 {
+		if language := markdownLanguage(info[0]); language != "" {
+			b.WriteString(` class="language-` + language + `"`)
+		}
+	}
+	b.WriteString(">")
+
+	i := start + 1
+	for ; i < len(lines); i++ {
+		t := strings.TrimLeft(lines[i], " ")
+		if len(lines[i])-len(t) < 4 && strings.HasPrefix(t, fence) && strings.Trim(t, fence[:1]+" ") == "" {
+			i++
+			break
+		}
+
+		// indentasi pagar kode dibuang dari setiap baris isinya
+		line := lines[i]
+		for n := 0; n < indent && strings.HasPrefix(line, " "); n++ {
+			line = line[1:]
+		}
+		b.WriteString(html.EscapeString(line) + "\n")
+	}
+
+	b.WriteString("</code></pre>\n")
+	return i
+}
+
+// markdownLanguage returns the language of a code fence info string when it is a plain name, eg. go or c++.
+func markdownLanguage(info string) string {
+	for _, r := range info {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("+-_#.", r)) {
+			return ""
+		}
+	}
+
+	return info
+}
+
+// markdownHeadingLevel returns the level of an ATX heading, 0 when the line is not a heading.
+func markdownHeadingLevel(trimmed string) int {
+	level := len(trimmed) - len(strings.TrimLeft(trimmed, "#"))
+	if level < 1 || level > 6 || (len(trimmed) > level && trimmed[level] != ' ') {
+		return 0
+	}
+
+	return level
+}
+
+// markdownHeadingText returns the text of an ATX heading without the opening and the optional closing sequence.
+func markdownHeadingText(trimmed string) string {
+	text := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+
+	closing := strings.TrimRight(text, "#")
+	if closing == "" || strings.HasSuffix(closing, " ") {
+		text = strings.TrimSpace(closing)
+	}
+
+	return text
+}
+
+func isMarkdownThematicBreak(trimmed string) bool {
+	if trimmed == "" || !strings.ContainsRune("-*_", rune(trimmed[0])) {
+		return false
+	}
+
+	count := 0
+	for _, r := range trimmed {
+		switch r {
+		case rune(trimmed[0]):
+			count++
+		case ' ':
+		default:
+			return false
+		}
+	}
+
+	return count >= 3
+}
+
+// markdownListMarker parses the marker of a list item line. Width is the length of the marker with the spaces
+// up to the content, start the number of an ordered item.
+func markdownListMarker(trimmed string) (ordered bool, start, width int, ok bool) {
+	n := 0
+	switch {
+	case trimmed != "" && strings.ContainsRune("-*+", rune(trimmed[0])):
+		n = 1
+	default:
+		for n < len(trimmed) && n < 9 && trimmed[n] >= '0' && trimmed[n] <= '9' {
+			n++
+		}
+		if n == 0 || n >= len(trimmed) || (trimmed[n] != '.' && trimmed[n] != ')') {
+			return
+		}
+		ordered = true
+		start, _ = strconv.Atoi(trimmed[:n])
+		n++
+	}
+
+	if n == len(trimmed) {
+		return ordered, start, n + 1, true
+	}
+	if trimmed[n] != ' ' {
+		return false, 0, 0, false
+	}
+
+	spaces := len(trimmed[n:]) - len(strings.TrimLeft(trimmed[n:], " "))
+	if spaces > 4 {
+		spaces = 1
+	}
+
+	return ordered, start, n + spaces, true
+}
+
+func isMarkdownListItem(trimmed string) bool {
+	_, _, _, ok := markdownListMarker(trimmed)
+	return ok
+}
+
+// renderMarkdownList renders the list starting at lines[start], it returns the line after the list.
+func renderMarkdownList(b *strings.Builder, lines []string, start, depth int) int {
+	ordered, number, _, _ := markdownListMarker(strings.TrimLeft(lines[start], " "))
+
+	switch {
+	case !ordered:
+		b.WriteString("<ul>\n")
+	case number != 1:
+		b.WriteString(`<ol start="` + strconv.Itoa(number) + `">` + "\n")
+	default:
+		b.WriteString("<ol>\n")
+	}
+
+	i := start
+	for i < len(lines) {
+		line := lines[i]
+		trimmed := strings.TrimLeft(line, " ")
+		itemOrdered, _, width, ok := markdownListMarker(trimmed)
+		if !ok || itemOrdered != ordered || len(line)-len(trimmed) >= 4 {
+			break
+		}
+
+		contentIndent := len(line) - len(trimmed) + width
+		item := []string{trimmed[min(width, len(trimmed)):]}
+		loose := false
+
+		for i++; i < len(lines); i++ {
+			l := lines[i]
+			t := strings.TrimLeft(l, " ")
+			indent := len(l) - len(t)
+
+			if t == "" {
+				// baris kosong masih bagian item bila baris berikutnya menjorok sampai isi item
+				next := i + 1
+				for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+					next++
+				}
+				if next == len(lines) || len(lines[next])-len(strings.TrimLeft(lines[next], " ")) < contentIndent {
+					break
+				}
+				item = append(item, "")
+				loose = true
+				continue
+			}
+
+			if indent >= contentIndent {
+				item = append(item, l[contentIndent:])
+				continue
+			}
+
+			// baris lanjutan paragraf yang tidak menjorok
+			if item[len(item)-1] == "" || startsMarkdownBlock(l) {
+				break
+			}
+			item = append(item, t)
+		}
+
+		var ib strings.Builder
+		renderMarkdownBlocks(&ib, item, depth+1)
+		content := ib.String()
+
+		// item rapat tanpa baris kosong tidak dibungkus paragraf
+		if !loose && strings.HasPrefix(content, "<p>") {
+			end := strings.Index(content, "</p>\n")
+			rest := content[end+len("</p>\n"):]
+			content = content[len("<p>"):end]
+			if rest != "" {
+				content += "\n" + rest
+			}
+		}
+
+		b.WriteString("<li>" + strings.TrimSuffix(content, "\n") + "</li>\n")
+
+		// baris kosong di antara item tidak mengakhiri daftar
+		next := i
+		for next < len(lines) && strings.TrimSpace(lines[next]) == "" {
+			next++
+		}
+		if next < len(lines) && next > i {
+			t := strings.TrimLeft(lines[next], " ")
+			if o, _, _, ok := markdownListMarker(t); !ok || o != ordered || len(lines[next])-len(t) >= 4 {
+				break
+			}
+			i = next
+		}
+	}
+
+	if ordered {
+		b.WriteString("</ol>\n")
+	} else {
+		b.WriteString("</ul>\n")
+	}
+
+	return i
+}
+
+// renderMarkdownInline renders the inline markup of a block, text is escaped.
+func renderMarkdownInline(b *strings.Builder, s string, depth int) {
+	// pencarian penutup yang gagal tidak diulang, supaya teks tanpa penutup tetap linear
+	unclosed := map[string]bool{}
+
+	for i := 0; i < len(s); {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) && s[i+1] == '\n' {
+				b.WriteString("<br>\n")
+				i += 2
+				continue
+			}
+			if i+1 < len(s) && isMarkdownPunctuation(s[i+1]) {
+				b.WriteString(html.EscapeString(s[i+1 : i+2]))
+				i += 2
+				continue
+			}
+		case '`':
+			run := s[i : i+len(s[i:])-len(strings.TrimLeft(s[i:], "`"))]
+			if end := markdownCodeSpanEnd(s, i+len(run), run, unclosed); end >= 0 {
+				code := strings.ReplaceAll(s[i+len(run):end], "\n", " ")
+				if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.Trim(code, " ") != "" {
+					code = code[1 : len(code)-1]
+				}
+				b.WriteString("<code>" + html.EscapeString(code) + "</code>")
+				i = end + len(run)
+				continue
+			}
+			b.WriteString(run)
+			i += len(run)
+			continue
+		case '!':
+			if text, destination, title, end, ok := markdownLink(s, i+1); ok {
+				if src, safe := safeMarkdownURL(destination); safe {
+					b.WriteString(`<img src="` + src + `" alt="` + html.EscapeString(markdownPlainText(text)) + `"`)
+					if title != "" {
+						b.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					b.WriteString(">")
+				} else {
+					b.WriteString(html.EscapeString(markdownPlainText(text)))
+				}
+				i = end
+				continue
+			}
+		case '[':
+			if text, destination, title, end, ok := markdownLink(s, i); ok && depth < maxMarkdownDepth {
+				if href, safe := safeMarkdownURL(destination); safe {
+					b.WriteString(`<a href="` + href + `"`)
+					if title != "" {
+						b.WriteString(` title="` + html.EscapeString(title) + `"`)
+					}
+					b.WriteString(` rel="nofollow">`)
+					renderMarkdownInline(b, text, depth+1)
+					b.WriteString("</a>")
+				} else {
+					renderMarkdownInline(b, text, depth+1)
+				}
+				i = end
+				continue
+			}
+		case '<':
+			if end := strings.IndexByte(s[i:min(len(s), i+maxMarkdownLinkSearch)], '>'); end > 0 {
+				destination := s[i+1 : i+end]
+				if href, safe := safeMarkdownURL(destination); safe && !strings.ContainsAny(destination, " \n<") && strings.Contains(destination, ":") {
+					b.WriteString(`<a href="` + href + `" rel="nofollow">` + html.EscapeString(destination) + "</a>")
+					i += end + 1
+					continue
+				}
+			}
+		case '*', '_':
+			run := len(s[i:]) - len(strings.TrimLeft(s[i:], s[i:i+1]))
+			delimiter := s[i : i+min(run, 2)]
+
+			// garis bawah di tengah kata bukan penekanan, eg. snake_case
+			opens := i+len(delimiter) < len(s) && s[i+len(delimiter)] != ' ' && s[i+len(delimiter)] != '\n' &&
+				!(s[i] == '_' && i > 0 && isMarkdownWordByte(s[i-1]))
+			if opens && depth < maxMarkdownDepth {
+				if end := markdownEmphasisEnd(s, i+len(delimiter), delimiter, unclosed); end >= 0 {
+					tag := "em"
+					if len(delimiter) == 2 {
+						tag = "strong"
+					}
+					b.WriteString("<" + tag + ">")
+					renderMarkdownInline(b, s[i+len(delimiter):end], depth+1)
+					b.WriteString("</" + tag + ">")
+					i = end + len(delimiter)
+					continue
+				}
+			}
+			b.WriteString(s[i : i+run])
+			i += run
+			continue
+		case ' ':
+			if spaces := strings.TrimLeft(s[i:], " "); len(s[i:])-len(spaces) >= 2 && strings.HasPrefix(spaces, "\n") {
+				b.WriteString("<br>\n")
+				i = len(s) - len(spaces) + 1
+				continue
+			}
+		}
+
+		// teks biasa sampai karakter khusus berikutnya
+		end := len(s)
+		if next := strings.IndexAny(s[i+1:], "\\`![<*_ "); next >= 0 {
+			end = i + 1 + next
+		}
+		b.WriteString(html.EscapeString(s[i:end]))
+		i = end
+	}
+}
+
+// markdownCodeSpanEnd returns the start of the backtick run of the same length closing a code span, or -1.
+func markdownCodeSpanEnd(s string, from int, run string, unclosed map[string]bool) int {
+	if unclosed[run] {
+		return -1
+	}
+
+	for i := from; i < len(s); {
+		j := strings.Index(s[i:], run)
+		if j < 0 {
+			break
+		}
+		j += i
+
+		end := len(s) - len(strings.TrimLeft(s[j:], "`"))
+		if end-j == len(run) {
+			return j
+		}
+		i = end
+	}
+
+	unclosed[run] = true
+	return -1
+}
+
+// markdownEmphasisEnd returns the start of the delimiter closing an emphasis, or -1. A closing delimiter follows
+// a character other than a space and a closing underscore is not followed by a letter or digit.
+func markdownEmphasisEnd(s string, from int, delimiter string, unclosed map[string]bool) int {
+	if unclosed[delimiter] {
+		return -1
+	}
+
+	for i := from + 1; i+len(delimiter) <= len(s); i++ {
+		if s[i] == '`' {
+			// tanda penekanan di dalam kode bukan penutup
+			run := s[i : i+len(s[i:])-len(strings.TrimLeft(s[i:], "`"))]
+			if end := markdownCodeSpanEnd(s, i+len(run), run, unclosed); end >= 0 {
+				i = end + len(run) - 1
+				continue
+			}
+		}
+
+		if s[i:i+len(delimiter)] != delimiter || s[i-1] == ' ' || s[i-1] == '\n' || s[i-1] == delimiter[0] {
+			continue
+		}
+
+		after := i + len(delimiter)
+		if len(delimiter) == 1 && after < len(s) && s[after] == delimiter[0] {
+			i++
+			continue
+		}
+		if delimiter[0] == '_' && after < len(s) && isMarkdownWordByte(s[after]) {
+			continue
+		}
+
+		return i
+	}
+
+	unclosed[delimiter] = true
+	return -1
+}
+
+// markdownLink parses a link [text](destination "title") starting at s[start], end is the index after it.
+func markdownLink(s string, start int) (text, destination, title string, end int, ok bool) {
+	if start >= len(s) || s[start] != '[' {
+		return
+	}
+
+	// teks link boleh memuat kurung siku yang berpasangan
+	nesting, close := 0, -1
+	for i := start; i < len(s) && i < start+maxMarkdownLinkSearch && close < 0; i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			nesting++
+		case ']':
+			nesting--
+			if nesting == 0 {
+				close = i
+			}
+		}
+	}
+	if close < 0 || close+1 >= len(s) || s[close+1] != '(' {
+		return
+	}
+
+	i := close + 2
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+
+	if i < len(s) && s[i] == '<' {
+		j := strings.IndexAny(s[i+1:], ">\n")
+		if j < 0 || s[i+1+j] != '>' {
+			return
+		}
+		destination = s[i+1 : i+1+j]
+		i += j + 2
+	} else {
+		parens, from := 0, i
+		for ; i < len(s) && i < from+maxMarkdownLinkSearch; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+				continue
+			}
+			if s[i] == ' ' || s[i] == '\n' || (s[i] == ')' && parens == 0) {
+				break
+			}
+			if s[i] == '(' {
+				parens++
+			}
+			if s[i] == ')' {
+				parens--
+			}
+		}
+		destination = s[from:i]
+	}
+
+	for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+		i++
+	}
+
+	if i < len(s) && (s[i] == '"' || s[i] == '\'') {
+		quote := s[i]
+		j := strings.IndexByte(s[i+1:min(len(s), i+1+maxMarkdownLinkSearch)], quote)
+		if j < 0 {
+			return
+		}
+		title = markdownUnescape(s[i+1 : i+1+j])
+		i += j + 2
+		for i < len(s) && (s[i] == ' ' || s[i] == '\n') {
+			i++
+		}
+	}
+
+	if i >= len(s) || s[i] != ')' {
+		return
+	}
+
+	return s[start+1 : close], markdownUnescape(destination), title, i + 1, true
+}
+
+// safeMarkdownURL returns the URL escaped for an attribute when it is relative or of an allowed scheme.
+func safeMarkdownURL(u string) (string, bool) {
+	u = strings.TrimSpace(u)
+	if strings.IndexFunc(u, func(r rune) bool { return r < 0x20 || r == 0x7f }) >= 0 {
+		return "", false
+	}
+
+	// skema ada bila titik dua muncul sebelum /, ? atau #
+	if colon := strings.IndexByte(u, ':'); colon >= 0 && !strings.ContainsAny(u[:colon], "/?#") {
+		if !markdownURLSchemes[strings.ToLower(u[:colon])] {
+			return "", false
+		}
+	}
+
+	return html.EscapeString(u), true
+}
+
+// markdownPlainText returns the text of inline markup without the markup, for image descriptions.
+func markdownPlainText(s string) string {
+	s = markdownUnescape(s)
+	return strings.NewReplacer("*", "", "_", "", "`", "", "[", "", "]", "").Replace(s)
+}
+
+func markdownUnescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) && isMarkdownPunctuation(s[i+1]) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+func isMarkdownPunctuation(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isMarkdownWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		html     string
+	}{
+		{"paragraphs", "Hello\nworld\n\nAgain", "<p>Hello\nworld</p>\n<p>Again</p>\n"},
+		{"headings", "# Title #\n## C#\n####### seven", "<h1>Title</h1>\n<h2>C#</h2>\n<p>####### seven</p>\n"},
+		{"emphasis", "*em* **strong** _em_ __strong__ snake_case_name", "<p><em>em</em> <strong>strong</strong> <em>em</em> <strong>strong</strong> snake_case_name</p>\n"},
+		{"nested emphasis", "**bold *and* more**", "<p><strong>bold <em>and</em> more</strong></p>\n"},
+		{"unclosed emphasis", "2 * 3 * 4 and *open", "<p>2 * 3 * 4 and *open</p>\n"},
+		{"code span", "use `a < b` or `` x`y ``", "<p>use <code>a &lt; b</code> or <code>x`y</code></p>\n"},
+		{"escapes", `\*not em\* and \\`, "<p>*not em* and \\</p>\n"},
+		{"hard break", "one  \ntwo\\\nthree", "<p>one<br>\ntwo<br>\nthree</p>\n"},
+		{"code fence", "```go\nif a < b {\n\t*x*\n}\n```\nafter", "<pre><code class=\"language-go\">if a &lt; b {\n    *x*\n}\n</code></pre>\n<p>after</p>\n"},
+		{"unclosed code fence", "~~~\ncode", "<pre><code>code\n</code></pre>\n"},
+		{"block quote", "> quoted\n> **text**\n>\n> - item", "<blockquote>\n<p>quoted\n<strong>text</strong></p>\n<ul>\n<li>item</li>\n</ul>\n</blockquote>\n"},
+		{"unordered list", "- one\n- two\n  continued\n* three\n\nafter", "<ul>\n<li>one</li>\n<li>two\ncontinued</li>\n<li>three</li>\n</ul>\n<p>after</p>\n"},
+		{"ordered list", "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>\n"},
+		{"nested list", "1. one\n   - a\n   - b\n2. two", "<ol>\n<li>one\n<ul>\n<li>a</li>\n<li>b</li>\n</ul></li>\n<li>two</li>\n</ol>\n"},
+		{"loose list", "- one\n\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>\n"},
+		{"thematic break", "a\n\n---\n* * *", "<p>a</p>\n<hr>\n<hr>\n"},
+		{"link", `[the *site*](https://example.com/a?b=1&c=2 "Title")`, `<p><a href="https://example.com/a?b=1&amp;c=2" title="Title" rel="nofollow">the <em>site</em></a></p>` + "\n"},
+		{"relative link", "[next](chapter-2#top)", `<p><a href="chapter-2#top" rel="nofollow">next</a></p>` + "\n"},
+		{"image", `![a *cover*](/media/cover.jpg)`, `<p><img src="/media/cover.jpg" alt="a cover"></p>` + "\n"},
+		{"autolink", "<https://example.com> and <mailto:a@example.com>", `<p><a href="https://example.com" rel="nofollow">https://example.com</a> and <a href="mailto:a@example.com" rel="nofollow">mailto:a@example.com</a></p>` + "\n"},
+		{"not a link", "[text] (url) and [text]", "<p>[text] (url) and [text]</p>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.html, RenderMarkdown(tt.markdown))
+		})
+	}
+}
+
+func TestRenderMarkdown_Sanitizes(t *testing.T) {
+	tests := []struct {
+		name     string
+		markdown string
+		html     string
+	}{
+		{"raw html", `<script>alert(1)</script><img src=x onerror="alert(1)">`, "<p>&lt;script&gt;alert(1)&lt;/script&gt;&lt;img src=x onerror=&#34;alert(1)&#34;&gt;</p>\n"},
+		{"javascript link", "[click](javascript:alert(1))", "<p>click</p>\n"},
+		{"javascript link case", "[click](JavaScript:alert(1))", "<p>click</p>\n"},
+		{"javascript link control", "[click](java\x01script:alert(1))", "<p>click</p>\n"},
+		{"data image", "![x](data:text/html;base64,PHNjcmlwdD4=)", "<p>x</p>\n"},
+		{"javascript autolink", "<javascript:alert(1)>", "<p>&lt;javascript:alert(1)&gt;</p>\n"},
+		{"attribute breakout", `[x](https://example.com/"onmouseover="alert(1))`, `<p><a href="https://example.com/&#34;onmouseover=&#34;alert(1)" rel="nofollow">x</a></p>` + "\n"},
+		{"title breakout", `[x](/a '"><script>')`, `<p><a href="/a" title="&#34;&gt;&lt;script&gt;" rel="nofollow">x</a></p>` + "\n"},
+		{"code language", "```\"><script>\ncode\n```", "<pre><code>code\n</code></pre>\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.html, RenderMarkdown(tt.markdown))
+		})
+	}
+}
+
+func TestRenderMarkdown_Pathological(t *testing.T) {
+	inputs := []string{
+		strings.Repeat("*a ", 50000),
+		strings.Repeat("`", 50000) + "a",
+		strings.Repeat("[", 50000),
+		strings.Repeat("> ", 10000) + "deep",
+		strings.Repeat("- ", 10000) + "deep",
+	}
+
+	for _, input := range inputs {
+		start := time.Now()
+		RenderMarkdown(input)
+		assert.Less(t, int64(time.Since(start)), int64(2*time.Second), input[:10])
+	}
+}
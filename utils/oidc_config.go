@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"os"
+	"strings"
+)
+
+// OidcProvidersFromEnv func for read the OpenID Connect providers listed in OIDC_PROVIDERS.
+// Each provider is configured with OIDC_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL and optional _SCOPES.
+func OidcProvidersFromEnv() (providers []domain.OidcProviderConfig) {
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		provider := domain.OidcProviderConfig{
+			Name:         name,
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+		}
+		if scopes := os.Getenv(prefix + "SCOPES"); scopes != "" {
+			provider.Scopes = strings.Fields(strings.ReplaceAll(scopes, ",", " "))
+		}
+
+		providers = append(providers, provider)
+	}
+
+	return
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"math"
+	"strings"
+)
+
+const (
+	argon2idSaltLength = 16
+	argon2idKeyLength  = 32
+)
+
+// Argon2idParams cost parameters of argon2id, see RFC 9106 for recommendations.
+type Argon2idParams struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// Argon2idParamsFromEnv func for read argon2id parameters from PASSWORD_ARGON2_* envs,
+// values not fitting the parameters are rejected rather than truncated.
+func Argon2idParamsFromEnv() (params Argon2idParams, err error) {
+	memory := GetEnvInt("PASSWORD_ARGON2_MEMORY_KIB", 64*1024)
+	iterations := GetEnvInt("PASSWORD_ARGON2_ITERATIONS", 3)
+	parallelism := GetEnvInt("PASSWORD_ARGON2_PARALLELISM", 2)
+
+	if memory < 0 || int64(memory) > math.MaxUint32 {
+		return params, fmt.Errorf("PASSWORD_ARGON2_MEMORY_KIB must be between 0 and %d, got %d", uint32(math.MaxUint32), memory)
+	}
+	if iterations < 0 || int64(iterations) > math.MaxUint32 {
+		return params, fmt.Errorf("PASSWORD_ARGON2_ITERATIONS must be between 1 and %d, got %d", uint32(math.MaxUint32), iterations)
+	}
+	if parallelism < 0 || parallelism > math.MaxUint8 {
+		return params, fmt.Errorf("PASSWORD_ARGON2_PARALLELISM must be between 1 and %d, got %d", math.MaxUint8, parallelism)
+	}
+
+	params = Argon2idParams{
+		Memory:      uint32(memory),
+		Iterations:  uint32(iterations),
+		Parallelism: uint8(parallelism),
+	}
+
+	return params, params.Validate()
+}
+
+// Validate func for check the parameters are accepted by argon2id: at least 1 iteration,
+// a parallelism of 1 to 255 and at least 8 KiB of memory per lane.
+func (p Argon2idParams) Validate() error {
+	if p.Iterations < 1 {
+		return fmt.Errorf("argon2id needs at least 1 iteration, got %d", p.Iterations)
+	}
+	if p.Parallelism < 1 {
+		return fmt.Errorf("argon2id needs a parallelism of at least 1, got %d", p.Parallelism)
+	}
+	if uint64(p.Memory) < 8*uint64(p.Parallelism) {
+		return fmt.Errorf("argon2id needs at least %d KiB of memory for a parallelism of %d, got %d KiB", 8*uint64(p.Parallelism), p.Parallelism, p.Memory)
+	}
+
+	return nil
+}
+
+type argon2idHasher struct {
+	params Argon2idParams
+}
+
+// NewPasswordHasher func for create a hasher producing argon2id hashes, bcrypt hashes are still verified.
+// Parameters argon2id does not accept are rejected, see Argon2idParams.Validate.
+func NewPasswordHasher(params Argon2idParams) (domain.PasswordHasher, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &argon2idHasher{params: params}, nil
+}
+
+func (h *argon2idHasher) Hash(password string) (string, error) {
+	salt, err := GenerateRandomBytes(argon2idSaltLength)
+	if err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, argon2idKeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func (h *argon2idHasher) Verify(password, encoded string) bool {
+	if isBcryptHash(encoded) {
+		return bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password)) == nil
+	}
+
+	params, salt, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return false
+	}
+
+	otherKey := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+
+	return subtle.ConstantTimeCompare(key, otherKey) == 1
+}
+
+func (h *argon2idHasher) NeedsRehash(encoded string) bool {
+	params, salt, key, err := decodeArgon2idHash(encoded)
+	if err != nil {
+		return true
+	}
+
+	return params != h.params || len(salt) != argon2idSaltLength || len(key) != argon2idKeyLength
+}
+
+// decodeArgon2idHash parses $argon2id$v=19$m=65536,t=3,p=2$<salt>$<key>.
+func decodeArgon2idHash(encoded string) (params Argon2idParams, salt, key []byte, err error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("not an argon2id hash")
+	}
+
+	var version int
+	_, err = fmt.Sscanf(parts[2], "v=%d", &version)
+	if err != nil {
+		return
+	}
+	if version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
+	if err != nil {
+		return
+	}
+
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return
+	}
+
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return
+	}
+
+	// argon2.IDKey panics on a zero key length or parallelism
+	if len(salt) == 0 || len(key) == 0 || params.Iterations == 0 || params.Parallelism == 0 {
+		return params, nil, nil, fmt.Errorf("invalid argon2id hash parameters")
+	}
+
+	return
+}
+
+func isBcryptHash(encoded string) bool {
+	return strings.HasPrefix(encoded, "$2a$") || strings.HasPrefix(encoded, "$2b$") || strings.HasPrefix(encoded, "$2y$")
+}
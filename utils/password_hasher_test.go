@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHasher(t *testing.T) {
+	params := Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}
+	hasher, err := NewPasswordHasher(params)
+	assert.NoError(t, err)
+
+	encoded, err := hasher.Hash("correct horse")
+	assert.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$[A-Za-z0-9+/]+\$[A-Za-z0-9+/]+$`, encoded)
+	assert.True(t, hasher.Verify("correct horse", encoded))
+	assert.False(t, hasher.Verify("wrong horse", encoded))
+	assert.False(t, hasher.NeedsRehash(encoded))
+
+	// stronger parameters make existing hashes outdated, they still verify
+	stronger, err := NewPasswordHasher(Argon2idParams{Memory: 2048, Iterations: 1, Parallelism: 1})
+	assert.NoError(t, err)
+	assert.True(t, stronger.Verify("correct horse", encoded))
+	assert.True(t, stronger.NeedsRehash(encoded))
+
+	// legacy bcrypt hashes are verified and always rehashed
+	legacy, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	assert.True(t, hasher.Verify("correct horse", string(legacy)))
+	assert.False(t, hasher.Verify("wrong horse", string(legacy)))
+	assert.True(t, hasher.NeedsRehash(string(legacy)))
+
+	assert.False(t, hasher.Verify("", ""))
+	assert.False(t, hasher.Verify("x", "$argon2id$v=19$m=1024,t=1,p=1$bad$"))
+}
+
+func TestArgon2idParams(t *testing.T) {
+	invalid := []Argon2idParams{
+		{Memory: 1024, Iterations: 0, Parallelism: 1},
+		{Memory: 1024, Iterations: 1, Parallelism: 0},
+		{Memory: 15, Iterations: 1, Parallelism: 2},
+	}
+	for _, params := range invalid {
+		_, err := NewPasswordHasher(params)
+		assert.Error(t, err, "%+v", params)
+	}
+
+	assert.NoError(t, Argon2idParams{Memory: 16, Iterations: 1, Parallelism: 2}.Validate())
+}
+
+func TestArgon2idParamsFromEnv(t *testing.T) {
+	defer os.Unsetenv("PASSWORD_ARGON2_PARALLELISM")
+
+	params, err := Argon2idParamsFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, Argon2idParams{Memory: 64 * 1024, Iterations: 3, Parallelism: 2}, params)
+
+	// 257 would wrap to a parallelism of 1
+	os.Setenv("PASSWORD_ARGON2_PARALLELISM", "257")
+	_, err = Argon2idParamsFromEnv()
+	assert.Error(t, err)
+
+	os.Setenv("PASSWORD_ARGON2_PARALLELISM", "0")
+	_, err = Argon2idParamsFromEnv()
+	assert.Error(t, err)
+}
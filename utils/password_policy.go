@@ -0,0 +1,188 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/nbutton23/zxcvbn-go"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// passwordStrengthMaxInput only the beginning of very long passwords is given to the strength estimator, it is slow on long input
+const passwordStrengthMaxInput = 64
+
+// PasswordPolicyConfig rules of the password policy.
+type PasswordPolicyConfig struct {
+	MinLength           int
+	MaxLength           int
+	MinCharacterClasses int // of lowercase, uppercase, digits, symbols
+	MinStrength         int // zxcvbn score 0-4
+	// BreachedHashesDir directory of k-anonymity prefix files: one file per 5 hex chars SHA-1 prefix (e.g. 21BD1.txt),
+	// each line holding the remaining 35 hex chars, optionally followed by ":count". Empty disables the screening.
+	BreachedHashesDir string
+}
+
+// PasswordPolicyConfigFromEnv func for read the password policy from PASSWORD_* envs.
+func PasswordPolicyConfigFromEnv() PasswordPolicyConfig {
+	return PasswordPolicyConfig{
+		MinLength:           GetEnvInt("PASSWORD_MIN_LENGTH", 10),
+		MaxLength:           GetEnvInt("PASSWORD_MAX_LENGTH", 128),
+		MinCharacterClasses: GetEnvInt("PASSWORD_MIN_CHARACTER_CLASSES", 2),
+		MinStrength:         GetEnvInt("PASSWORD_MIN_STRENGTH", 2),
+		BreachedHashesDir:   GetEnv("PASSWORD_BREACHED_HASHES_DIR", ""),
+	}
+}
+
+type passwordPolicy struct {
+	config PasswordPolicyConfig
+}
+
+// NewPasswordPolicy func for create the password policy, the breached hashes directory must exist when configured.
+func NewPasswordPolicy(config PasswordPolicyConfig) (domain.PasswordPolicy, error) {
+	if config.BreachedHashesDir != "" {
+		info, err := os.Stat(config.BreachedHashesDir)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("%s is not a directory", config.BreachedHashesDir)
+		}
+	}
+
+	return &passwordPolicy{config: config}, nil
+}
+
+func (p *passwordPolicy) Check(password string, identity ...string) error {
+	var violations []domain.PasswordViolation
+	length := utf8.RuneCountInString(password)
+
+	if length < p.config.MinLength {
+		violations = append(violations, domain.PasswordViolation{Code: domain.ConstPasswordTooShort, Param: p.config.MinLength})
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		// password terlalu panjang tidak perlu diperiksa lebih lanjut
+		return domain.PasswordPolicyError{Field: "password", Violations: []domain.PasswordViolation{
+			{Code: domain.ConstPasswordTooLong, Param: p.config.MaxLength},
+		}}
+	}
+	if characterClasses(password) < p.config.MinCharacterClasses {
+		violations = append(violations, domain.PasswordViolation{Code: domain.ConstPasswordMissingClasses, Param: p.config.MinCharacterClasses})
+	}
+	if containsIdentity(password, identity) {
+		violations = append(violations, domain.PasswordViolation{Code: domain.ConstPasswordContainsIdentity})
+	}
+	if p.config.MinStrength > 0 && passwordStrength(password, identity) < p.config.MinStrength {
+		violations = append(violations, domain.PasswordViolation{Code: domain.ConstPasswordTooWeak})
+	}
+
+	breached, err := p.isBreached(password)
+	if err != nil {
+		return err
+	}
+	if breached {
+		violations = append(violations, domain.PasswordViolation{Code: domain.ConstPasswordBreached})
+	}
+
+	if len(violations) > 0 {
+		return domain.PasswordPolicyError{Field: "password", Violations: violations}
+	}
+
+	return nil
+}
+
+// isBreached looks up the SHA-1 of the password in the prefix file of its first 5 hex chars.
+func (p *passwordPolicy) isBreached(password string) (bool, error) {
+	if p.config.BreachedHashesDir == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	digest := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := digest[:5], digest[5:]
+
+	f, err := os.Open(filepath.Join(p.config.BreachedHashesDir, prefix+".txt"))
+	if os.IsNotExist(err) {
+		f, err = os.Open(filepath.Join(p.config.BreachedHashesDir, prefix))
+	}
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.IndexByte(line, ':'); i >= 0 {
+			line = line[:i]
+		}
+		if strings.EqualFold(line, suffix) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
+
+// characterClasses counts the classes of lowercase, uppercase, digits and symbols used by the password.
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = 1
+		case unicode.IsUpper(r):
+			upper = 1
+		case unicode.IsDigit(r):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// containsIdentity reports whether the password contains the username, the email address or its local part.
+func containsIdentity(password string, identity []string) bool {
+	password = strings.ToLower(password)
+	for _, value := range identityTerms(identity) {
+		if len(value) >= 3 && strings.Contains(password, value) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func identityTerms(identity []string) (terms []string) {
+	for _, value := range identity {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		terms = append(terms, value)
+		if i := strings.IndexByte(value, '@'); i > 0 {
+			terms = append(terms, value[:i])
+		}
+	}
+
+	return
+}
+
+// passwordStrength estimates how hard the password is to guess, from 0 (trivial) to 4 (very strong).
+func passwordStrength(password string, identity []string) int {
+	if utf8.RuneCountInString(password) > passwordStrengthMaxInput {
+		password = string([]rune(password)[:passwordStrengthMaxInput])
+	}
+
+	return zxcvbn.PasswordStrength(password, identityTerms(identity)).Score
+}
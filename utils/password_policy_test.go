@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func violationCodes(err error) (codes []string) {
+	if pp, ok := err.(domain.PasswordPolicyError); ok {
+		for _, v := range pp.Violations {
+			codes = append(codes, v.Code)
+		}
+	}
+	return
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	policy, err := NewPasswordPolicy(PasswordPolicyConfig{MinLength: 10, MaxLength: 64, MinCharacterClasses: 3, MinStrength: 3})
+	assert.NoError(t, err)
+
+	assert.NoError(t, policy.Check("violet-Gravel-Canoe-17", "jane", "jane@example.com"))
+	assert.Equal(t, []string{domain.ConstPasswordTooShort, domain.ConstPasswordMissingClasses, domain.ConstPasswordTooWeak}, violationCodes(policy.Check("a")))
+	assert.Contains(t, violationCodes(policy.Check("Janedoe-Violet-Gravel-17", "janedoe", "jd@example.com")), domain.ConstPasswordContainsIdentity)
+	assert.Contains(t, violationCodes(policy.Check("Xyz-Violet-Gravel-17", "", "xyz@example.com")), domain.ConstPasswordContainsIdentity)
+	assert.Equal(t, []string{domain.ConstPasswordTooLong}, violationCodes(policy.Check(string(make([]byte, 65)))))
+}
+
+func TestPasswordPolicy_Breached(t *testing.T) {
+	dir := t.TempDir()
+	password := "P@ssw0rd-Violet-Gravel"
+	policy, err := NewPasswordPolicy(PasswordPolicyConfig{BreachedHashesDir: dir})
+	assert.NoError(t, err)
+	assert.NoError(t, policy.Check(password))
+
+	digest := sha1Hex(password)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, digest[:5]+".txt"), []byte("0000000000000000000000000000000000A:3\r\n"+digest[5:]+":42\r\n"), 0o644))
+	assert.Equal(t, []string{domain.ConstPasswordBreached}, violationCodes(policy.Check(password)))
+
+	_, err = NewPasswordPolicy(PasswordPolicyConfig{BreachedHashesDir: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestPasswordViolation_Message(t *testing.T) {
+	v := domain.PasswordViolation{Code: domain.ConstPasswordTooShort, Param: 10}
+	assert.Equal(t, "password must be at least 10 characters long", v.Message("en"))
+	assert.Equal(t, "password minimal 10 karakter", v.Message("id"))
+	assert.Equal(t, "password must be at least 10 characters long", v.Message("fr"))
+}
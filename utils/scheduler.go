@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// RunEvery func for running a background job periodically.
+// The job is executed once right away, then every interval.
+// Errors are logged, they don't stop the schedule. An interval that is not positive is an error.
+func RunEvery(name string, interval time.Duration, job func() error) error {
+	if interval <= 0 {
+		return fmt.Errorf("scheduled job %q needs a positive interval, got %v", name, interval)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := job(); err != nil {
+				log.Printf("Oops... Scheduled job %q failed! Reason: %v", name, err)
+			}
+			<-ticker.C
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunEvery(t *testing.T) {
+	assert.Error(t, RunEvery("zero", 0, func() error { return nil }))
+	assert.Error(t, RunEvery("negative", -time.Minute, func() error { return nil }))
+
+	ran := make(chan struct{}, 1)
+	require.NoError(t, RunEvery("job", time.Hour, func() error {
+		ran <- struct{}{}
+		return nil
+	}))
+
+	select {
+	case <-ran:
+	case <-time.After(time.Second):
+		t.Fatal("job did not run right away")
+	}
+}
@@ -0,0 +1,113 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"net/http"
+
+	// decoders of the accepted image formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+// thumbnailQuality JPEG quality of the generated thumbnails
+const thumbnailQuality = 85
+
+// SniffImage returns the content type of an image detected from its content, ok is false unless it is
+// a JPEG, PNG or GIF image.
+func SniffImage(data []byte) (contentType string, ok bool) {
+	contentType = http.DetectContentType(data)
+
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return contentType, true
+	}
+
+	return contentType, false
+}
+
+// ImageDimensions returns the width and height of an image without decoding its pixels.
+func ImageDimensions(data []byte) (width, height int, err error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return
+	}
+
+	return config.Width, config.Height, nil
+}
+
+// DecodeImage decodes a JPEG, PNG or GIF image.
+func DecodeImage(data []byte) (image.Image, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Thumbnail scales the image down to the width keeping its aspect ratio, transparent areas become white.
+// Every pixel of the thumbnail is the average of the pixels it covers. An image narrower than the width is not enlarged.
+func Thumbnail(img image.Image, width int) image.Image {
+	b := img.Bounds()
+
+	// gambar digambar di atas latar putih supaya bagian transparan tidak menjadi hitam di JPEG
+	src := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(src, src.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Over)
+
+	if b.Dx() <= width {
+		return src
+	}
+
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*b.Dy()/height, (y+1)*b.Dy()/height
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < width; x++ {
+			x0, x1 := x*b.Dx()/width, (x+1)*b.Dx()/width
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, bl, a, n int
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += int(src.Pix[i])
+					g += int(src.Pix[i+1])
+					bl += int(src.Pix[i+2])
+					a += int(src.Pix[i+3])
+					i += 4
+					n++
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / n)
+			dst.Pix[j+1] = uint8(g / n)
+			dst.Pix[j+2] = uint8(bl / n)
+			dst.Pix[j+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+// EncodeJPEG encodes the image as JPEG with the quality used for thumbnails.
+func EncodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: thumbnailQuality})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
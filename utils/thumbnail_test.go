@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int, c color.Color) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, c)
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSniffImage(t *testing.T) {
+	contentType, ok := SniffImage(testPNG(t, 2, 2, color.Black))
+	assert.True(t, ok)
+	assert.Equal(t, "image/png", contentType)
+
+	_, ok = SniffImage([]byte("<html><body>not an image</body></html>"))
+	assert.False(t, ok)
+}
+
+func TestThumbnail(t *testing.T) {
+	data := testPNG(t, 1000, 500, color.NRGBA{R: 200, G: 10, B: 10, A: 255})
+
+	width, height, err := ImageDimensions(data)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1000, 500}, []int{width, height})
+
+	img, err := DecodeImage(data)
+	require.NoError(t, err)
+
+	thumb := Thumbnail(img, 320)
+	assert.Equal(t, image.Rect(0, 0, 320, 160), thumb.Bounds())
+	r, g, b, _ := thumb.At(100, 100).RGBA()
+	assert.Equal(t, []uint32{200, 10, 10}, []uint32{r >> 8, g >> 8, b >> 8})
+
+	encoded, err := EncodeJPEG(thumb)
+	require.NoError(t, err)
+	contentType, _ := SniffImage(encoded)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	small, err := DecodeImage(testPNG(t, 100, 150, color.Transparent))
+	require.NoError(t, err)
+	thumb = Thumbnail(small, 320)
+	assert.Equal(t, image.Rect(0, 0, 100, 150), thumb.Bounds(), "not enlarged")
+	r, g, b, _ = thumb.At(50, 50).RGBA()
+	assert.Equal(t, []uint32{255, 255, 255}, []uint32{r >> 8, g >> 8, b >> 8}, "transparent becomes white")
+}
@@ -48,3 +48,22 @@ func GenerateRandomStringURLSafe(n int) (string, error) {
 	b, err := GenerateRandomBytes(n)
 	return base64.URLEncoding.EncodeToString(b), err
 }
+
+// GenerateRandomNumericString returns a securely generated string
+// of n decimal digits, suitable for secret codes sent by email.
+// It will return an error if the system's secure random
+// number generator fails to function correctly, in which
+// case the caller should not continue.
+func GenerateRandomNumericString(n int) (string, error) {
+	const digits = "0123456789"
+	ret := make([]byte, n)
+	for i := 0; i < n; i++ {
+		num, err := rand.Int(rand.Reader, big.NewInt(int64(len(digits))))
+		if err != nil {
+			return "", err
+		}
+		ret[i] = digits[num.Int64()]
+	}
+
+	return string(ret), nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"golang.org/x/text/unicode/norm"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultReservedUsernames names implying an official account or clashing with routes and system addresses
+var defaultReservedUsernames = []string{
+	"admin", "administrator", "root", "superuser", "sysadmin", "system", "support", "help", "helpdesk",
+	"staff", "moderator", "mod", "official", "security", "abuse", "postmaster", "hostmaster", "webmaster",
+	"noreply", "mail", "email", "info", "contact", "billing", "api", "www", "auth", "login", "signup",
+	"user", "users", "account", "settings", "deleted", "anonymous", "null", "undefined", "cooljar",
+}
+
+// confusables maps lookalike characters to the Latin letter they are mistaken for, a subset of Unicode TR39
+// covering the Cyrillic, Greek and Latin letters and digits commonly used in spoofed names.
+var confusables = map[rune]string{
+	// Cyrillic
+	'а': "a", 'в': "b", 'е': "e", 'ё': "e", 'һ': "h", 'і': "i", 'ї': "i", 'ј': "j", 'к': "k", 'м': "m",
+	'н': "h", 'о': "o", 'р': "p", 'с': "c", 'т': "t", 'у': "y", 'х': "x", 'ѕ': "s", 'ԁ': "d", 'ԛ': "q",
+	'ԝ': "w", 'ӏ': "l", 'ь': "b",
+	// Greek
+	'α': "a", 'β': "b", 'ε': "e", 'η': "n", 'ι': "i", 'κ': "k", 'ν': "v", 'ο': "o", 'ρ': "p", 'τ': "t",
+	'υ': "u", 'χ': "x", 'ω': "w",
+	// Latin and digits
+	'ı': "i", 'ɩ': "i", 'ł': "l", 'ø': "o", 'ß': "ss", 'æ': "ae", 'œ': "oe", 'đ': "d", 'ħ': "h", 'ɡ': "g",
+	'0': "o", '1': "l", '|': "l",
+}
+
+// confusableSequences letter pairs rendered like a single letter, applied after the character mapping
+var confusableSequences = strings.NewReplacer("rn", "m", "vv", "w", "cl", "d")
+
+// UsernameSkeleton returns the form of the username two lookalike usernames share: compatibility decomposed,
+// without combining marks, lowercased and with confusable characters replaced.
+func UsernameSkeleton(username string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(username) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		r = unicode.ToLower(r)
+		if s, ok := confusables[r]; ok {
+			b.WriteString(s)
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return confusableSequences.Replace(b.String())
+}
+
+// UsernamePolicyConfig rules of the username policy.
+type UsernamePolicyConfig struct {
+	MinLength int
+	MaxLength int
+	// Reserved names nobody can register, lookalikes of them are rejected too
+	Reserved []string
+}
+
+// UsernamePolicyConfigFromEnv func for read the username policy from USERNAME_* envs,
+// USERNAME_RESERVED adds comma separated names to the default reserved list.
+func UsernamePolicyConfigFromEnv() UsernamePolicyConfig {
+	reserved := append([]string{}, defaultReservedUsernames...)
+	for _, name := range strings.Split(os.Getenv("USERNAME_RESERVED"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			reserved = append(reserved, name)
+		}
+	}
+
+	return UsernamePolicyConfig{
+		MinLength: GetEnvInt("USERNAME_MIN_LENGTH", 3),
+		MaxLength: GetEnvInt("USERNAME_MAX_LENGTH", 32),
+		Reserved:  reserved,
+	}
+}
+
+type usernamePolicy struct {
+	config   UsernamePolicyConfig
+	reserved map[string]bool
+}
+
+// NewUsernamePolicy func for create the username policy.
+func NewUsernamePolicy(config UsernamePolicyConfig) domain.UsernamePolicy {
+	reserved := make(map[string]bool, len(config.Reserved))
+	for _, name := range config.Reserved {
+		reserved[UsernameSkeleton(strings.TrimSpace(name))] = true
+	}
+
+	return &usernamePolicy{config: config, reserved: reserved}
+}
+
+func (p *usernamePolicy) Check(username string) error {
+	length := utf8.RuneCountInString(username)
+	if length < p.config.MinLength {
+		return domain.DataValidationError{Field: "username", Message: fmt.Sprintf("username must be at least %d characters long", p.config.MinLength)}
+	}
+	if p.config.MaxLength > 0 && length > p.config.MaxLength {
+		return domain.DataValidationError{Field: "username", Message: fmt.Sprintf("username must be at most %d characters long", p.config.MaxLength)}
+	}
+
+	if p.reserved[UsernameSkeleton(username)] {
+		return domain.ErrUsernameReserved
+	}
+
+	return nil
+}
+
+func (p *usernamePolicy) MaxLength() int {
+	return p.config.MaxLength
+}
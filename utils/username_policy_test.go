@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/cooljar/go-postgres-fiber/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsernameSkeleton(t *testing.T) {
+	assert.Equal(t, UsernameSkeleton("paypal"), UsernameSkeleton("pаypаl")) // Cyrillic а
+	assert.Equal(t, UsernameSkeleton("jose"), UsernameSkeleton("josé"))
+	assert.Equal(t, UsernameSkeleton("bill"), UsernameSkeleton("bi1l"))
+	assert.Equal(t, UsernameSkeleton("modern"), UsernameSkeleton("modem"))
+	assert.Equal(t, UsernameSkeleton("ｊａｎｅ"), UsernameSkeleton("jane")) // fullwidth
+	assert.NotEqual(t, UsernameSkeleton("jane"), UsernameSkeleton("june"))
+}
+
+func TestUsernamePolicy_Check(t *testing.T) {
+	policy := NewUsernamePolicy(UsernamePolicyConfig{MinLength: 3, MaxLength: 8, Reserved: []string{"admin", "support"}})
+
+	assert.NoError(t, policy.Check("jane"))
+	assert.Error(t, policy.Check("jo"))
+	assert.Error(t, policy.Check("janedoe123"))
+	assert.Equal(t, domain.ErrUsernameReserved, policy.Check("admin"))
+	assert.Equal(t, domain.ErrUsernameReserved, policy.Check("admín"))
+	assert.Equal(t, domain.ErrUsernameReserved, policy.Check("suppоrt")) // Cyrillic о
+}
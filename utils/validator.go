@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"github.com/cooljar/go-postgres-fiber/domain"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"reflect"
@@ -31,5 +32,21 @@ func NewValidator() *validator.Validate {
 		return false
 	})
 
+	// Custom validation for ISBN-10 and ISBN-13 with checksum, replaces the builtin one to allow hyphens.
+	// An empty ISBN is valid, normalize it with NormalizeISBN before saving.
+	_ = validate.RegisterValidation("isbn", func(fl validator.FieldLevel) bool {
+		field := fl.Field().String()
+		if field == "" {
+			return true
+		}
+		_, ok := NormalizeISBN(field)
+		return ok
+	})
+
+	// Custom validation for ISO 4217 currency codes in circulation.
+	_ = validate.RegisterValidation("currency", func(fl validator.FieldLevel) bool {
+		return domain.IsCurrency(fl.Field().String())
+	})
+
 	return validate
 }